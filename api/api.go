@@ -1,12 +1,25 @@
 package api
 
 import (
+	"fmt"
+	"sync"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
 	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
+	"github.com/diogovalentte/dashboard/api/metrics"
+	"github.com/diogovalentte/dashboard/api/openapi"
+	"github.com/diogovalentte/dashboard/api/routes/api_keys"
 	"github.com/diogovalentte/dashboard/api/routes/health_check"
 	"github.com/diogovalentte/dashboard/api/routes/jobs"
 	"github.com/diogovalentte/dashboard/api/routes/system"
 	"github.com/diogovalentte/dashboard/api/routes/trackers"
+	"github.com/diogovalentte/dashboard/api/routes/users"
+	"github.com/diogovalentte/dashboard/api/routes/webhooks"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 )
 
 var jobsList *job.Jobs
@@ -18,33 +31,90 @@ func setRouterJobsList(jobsList *job.Jobs) gin.HandlerFunc {
 	}
 }
 
+var setupValidationsOnce sync.Once
+
+// setupValidations registers the custom validations of the requests in the Gin validator.
+// They're registered only once, as the validator isn't safe to change while it validates other requests.
+func setupValidations() {
+	setupValidationsOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			panic("the Gin validator engine isn't a go-playground validator")
+		}
+		if err := trackers.RegisterValidations(v); err != nil {
+			panic(fmt.Sprintf("couldn't register the requests validations: %s", err))
+		}
+	})
+}
+
 func SetupRouter() *gin.Engine {
-	router := gin.Default()
+	setupValidations()
+
+	router := gin.New()
+	router.Use(logging.RequestID(), logging.RequestLogger(), metrics.HTTPMiddleware(), apierror.Middleware())
+	router.NoRoute(apierror.NoRoute)
 	jobsList = job.NewJobsList()
 	router.Use(setRouterJobsList(jobsList))
 
+	// Prometheus metrics, doesn't need an API key
+	router.GET("/metrics", metrics.Handler())
+
 	v1 := router.Group("/v1")
-	// Health check route
+	// Health check route, doesn't need an API key
 	{
 		health_check.HealthCheckRoute(v1)
 	}
+	// OpenAPI document and its Swagger UI page, don't need an API key
+	{
+		v1.GET("/openapi.json", openapi.Handler(openapi.Build(apiInfo, operations())))
+		v1.GET("/docs", openapi.SwaggerUIHandler(apiInfo.Title, "openapi.json"))
+	}
 
+	// All the other routes need an API key
+	authenticated := v1.Group("", auth.Authenticate())
+
+	// API keys routes
+	apiKeysGroup := authenticated.Group("/api_keys")
+	{
+		api_keys.APIKeysRoutes(apiKeysGroup)
+	}
+	// Users routes
+	usersGroup := authenticated.Group("/users")
+	{
+		users.UsersRoutes(usersGroup)
+	}
 	// Jobs routes
-	jobsGroup := v1.Group("/jobs")
+	jobsGroup := authenticated.Group("/jobs")
 	{
 		jobs.JobsRoutes(jobsGroup)
 	}
 	// System routes
-	systemGroup := v1.Group("/system")
+	systemGroup := authenticated.Group("/system")
 	{
 		system.SystemRoutes(systemGroup)
 	}
+	// Webhooks routes
+	webhooksGroup := authenticated.Group("/webhooks")
+	{
+		webhooks.WebhooksRoutes(webhooksGroup)
+	}
 	// Trackers routes
-	trackersGroup := v1.Group("/trackers")
+	trackersGroup := authenticated.Group("/trackers")
 	{
+		trackers.TrackersRoutes(trackersGroup)
 		trackers.GamesTrackerRoutes(trackersGroup)
 		trackers.MediasTrackerRoutes(trackersGroup)
 	}
+	// Trackers feeds routes, they accept the API key in the query string for the calendar and feed readers apps
+	feedsGroup := v1.Group("/trackers", auth.AllowQueryKey(), auth.Authenticate())
+	{
+		trackers.FeedsRoutes(feedsGroup)
+	}
+	// Trackers public routes, like the cover thumbnails of the feeds, they're signed instead of needing an API key
+	publicTrackersGroup := v1.Group("/trackers")
+	{
+		trackers.PublicRoutes(publicTrackersGroup)
+	}
 
 	return router
 }
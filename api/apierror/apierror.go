@@ -0,0 +1,143 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/diogovalentte/dashboard/api/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// A Code identifies the kind of an API error, each code has a single status code
+type Code string
+
+const (
+	// The request is malformed or has invalid values, 400
+	CodeValidation Code = "validation_error"
+	// The request doesn't have a valid API key, 401
+	CodeUnauthorized Code = "unauthorized"
+	// The user or API key can't do the request, 403
+	CodeForbidden Code = "forbidden"
+	// The route or the requested resource doesn't exist, 404
+	CodeNotFound Code = "not_found"
+	// The resource already exists, 409
+	CodeConflict Code = "conflict"
+	// The API failed, like a database error or a panic, 500
+	CodeInternal Code = "internal_error"
+	// Scraping the resource from a site, like Steam or IMDB, failed, 502
+	CodeUpstreamScrape Code = "upstream_scrape_error"
+)
+
+// EnumNames returns the codes, for the API documentation
+func (Code) EnumNames() []string {
+	return []string{
+		string(CodeValidation), string(CodeUnauthorized), string(CodeForbidden), string(CodeNotFound),
+		string(CodeConflict), string(CodeInternal), string(CodeUpstreamScrape),
+	}
+}
+
+// An Error is the body of all the API error responses
+type Error struct {
+	Status  int    `json:"-"`
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+	// Extra data about the error, like the existing entry of a conflict
+	Details any `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// WithDetails returns a copy of the error with the details
+func (e *Error) WithDetails(details any) *Error {
+	withDetails := *e
+	withDetails.Details = details
+
+	return &withDetails
+}
+
+// Validation returns a 400 error with the validation_error code
+func Validation(message string) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeValidation, Message: message}
+}
+
+// Unauthorized returns a 401 error with the unauthorized code
+func Unauthorized(message string) *Error {
+	return &Error{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: message}
+}
+
+// Forbidden returns a 403 error with the forbidden code
+func Forbidden(message string) *Error {
+	return &Error{Status: http.StatusForbidden, Code: CodeForbidden, Message: message}
+}
+
+// NotFound returns a 404 error with the not_found code
+func NotFound(message string) *Error {
+	return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: message}
+}
+
+// Conflict returns a 409 error with the conflict code
+func Conflict(message string) *Error {
+	return &Error{Status: http.StatusConflict, Code: CodeConflict, Message: message}
+}
+
+// Internal returns a 500 error with the internal_error code
+func Internal(message string) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: message}
+}
+
+// UpstreamScrape returns a 502 error with the upstream_scrape_error code
+func UpstreamScrape(message string) *Error {
+	return &Error{Status: http.StatusBadGateway, Code: CodeUpstreamScrape, Message: message}
+}
+
+// From returns the API error in the error chain, or an internal error with the error message if there is none
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	return Internal(err.Error())
+}
+
+// Respond aborts the request responding with the error, converted to an API error by From.
+// The error is added to the request errors, so it's logged with the request.
+func Respond(c *gin.Context, err error) {
+	apiErr := From(err)
+	c.Error(err)
+	c.AbortWithStatusJSON(apiErr.Status, apiErr)
+}
+
+// Middleware responds with the last error added with c.Error by the handlers that didn't respond,
+// and with an internal error to the requests that panic, logging the panic with the stack trace.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logging.Request(c).Error("panic handling the request", "error", fmt.Sprint(recovered), "stack", strings.TrimSpace(string(debug.Stack())))
+				if c.Writer.Written() {
+					c.Abort()
+					return
+				}
+				Respond(c, Internal("internal error while handling the request"))
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			apiErr := From(c.Errors.Last().Err)
+			c.AbortWithStatusJSON(apiErr.Status, apiErr)
+		}
+	}
+}
+
+// NoRoute responds with a not found error to the requests that don't match a route
+func NoRoute(c *gin.Context) {
+	Respond(c, NotFound(fmt.Sprintf("route %s %s not found", c.Request.Method, c.Request.URL.Path)))
+}
@@ -0,0 +1,89 @@
+package apierror
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+	var logs bytes.Buffer
+	if err := logging.Setup(&logs, "debug"); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(logging.RequestID(), Middleware())
+	router.NoRoute(NoRoute)
+	router.GET("/not_found", func(c *gin.Context) {
+		Respond(c, NotFound("game do not exists"))
+	})
+	router.GET("/wrapped", func(c *gin.Context) {
+		err := Conflict("Terraria is already in the Games Tracker").WithDetails(gin.H{"name": "Terraria"})
+		Respond(c, fmt.Errorf("couldn't add the game: %w", err))
+	})
+	router.GET("/untyped", func(c *gin.Context) {
+		Respond(c, errors.New("database is locked"))
+	})
+	router.GET("/context_error", func(c *gin.Context) {
+		c.Error(UpstreamScrape("couldn't find the game name"))
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("test panic")
+	})
+
+	testTable := []struct {
+		path               string
+		expectedStatusCode int
+		expectedCode       Code
+		expectedMessage    string
+		expectedDetails    any
+	}{
+		{"/not_found", http.StatusNotFound, CodeNotFound, "game do not exists", nil},
+		{"/wrapped", http.StatusConflict, CodeConflict, "Terraria is already in the Games Tracker", map[string]any{"name": "Terraria"}},
+		{"/untyped", http.StatusInternalServerError, CodeInternal, "database is locked", nil},
+		{"/context_error", http.StatusBadGateway, CodeUpstreamScrape, "couldn't find the game name", nil},
+		{"/panic", http.StatusInternalServerError, CodeInternal, "internal error while handling the request", nil},
+		{"/unknown", http.StatusNotFound, CodeNotFound, "route GET /unknown not found", nil},
+	}
+	for _, test := range testTable {
+		logs.Reset()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != test.expectedStatusCode {
+			t.Errorf("%s: expected status code: %d, actual status code: %d", test.path, test.expectedStatusCode, w.Code)
+		}
+		var res struct {
+			Code    Code   `json:"code"`
+			Message string `json:"message"`
+			Details any    `json:"details"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Errorf("%s: invalid JSON body %q: %s", test.path, w.Body.String(), err)
+			continue
+		}
+		if res.Code != test.expectedCode || res.Message != test.expectedMessage || fmt.Sprint(res.Details) != fmt.Sprint(test.expectedDetails) {
+			t.Errorf("%s: unexpected error: %+v", test.path, res)
+		}
+
+		if test.path == "/panic" {
+			requestID := w.Header().Get(logging.RequestIDHeader)
+			if !strings.Contains(logs.String(), `"msg":"panic handling the request"`) || !strings.Contains(logs.String(), requestID) {
+				t.Errorf("expected the panic to be logged with the request ID: %s", logs.String())
+			}
+		}
+	}
+}
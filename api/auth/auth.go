@@ -0,0 +1,309 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/logging"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// A Scope is what an API key can do
+type Scope string
+
+const (
+	// Can only make requests that don't change anything, like GET requests
+	ScopeRead Scope = "read"
+	// Can make any request, including the ones to manage the API keys
+	ScopeWrite Scope = "write"
+)
+
+// EnumNames returns the scopes, for the API documentation
+func (Scope) EnumNames() []string {
+	return []string{string(ScopeRead), string(ScopeWrite)}
+}
+
+func (s Scope) IsValid() bool {
+	return s == ScopeRead || s == ScopeWrite
+}
+
+// Allows returns whether a key with the scope can make requests that need the required scope
+func (s Scope) Allows(required Scope) bool {
+	return s == ScopeWrite || s == required
+}
+
+// Prefix of all API keys, so they are easy to recognize
+const keyPrefix = "dash_"
+
+// An APIKey as stored in the database, without the key itself
+type APIKey struct {
+	// ID of the user that owns the key, the requests with the key are made as this user
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+	// First characters of the key, to identify it
+	Prefix    string `json:"prefix"`
+	Scope     Scope  `json:"scope"`
+	CreatedAt string `json:"created_at"`
+}
+
+// HashKey returns the hex-encoded SHA-256 hash of a key.
+// The keys are random, so a slow hash is not needed.
+func HashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateKey returns a new random key
+func GenerateKey() (string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+
+	return keyPrefix + hex.EncodeToString(randomBytes), nil
+}
+
+// CreateAPIKey creates an API key of the user and stores its hash in the database.
+// The returned key can't be retrieved later.
+func CreateAPIKey(configs *util.Configs, userID int64, name string, scope Scope) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("the API key name can't be empty")
+	}
+	if !scope.IsValid() {
+		return "", fmt.Errorf("invalid scope %q, should be %q or %q", scope, ScopeRead, ScopeWrite)
+	}
+
+	key, err := GenerateKey()
+	if err != nil {
+		return "", err
+	}
+
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(
+		"INSERT INTO api_keys (user_id, name, prefix, key_hash, scope, created_at) VALUES (?, ?, ?, ?, ?, ?);",
+		userID, name, key[:len(keyPrefix)+8], HashKey(key), scope, time.Now().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// GetAPIKeys returns the API keys the user can see, ordered by user and name.
+// Admins can see the keys of all users.
+func GetAPIKeys(configs *util.Configs, user *User) ([]*APIKey, error) {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(
+		"SELECT user_id, name, prefix, scope, created_at FROM api_keys WHERE ? OR user_id = ? ORDER BY user_id, name;",
+		user.IsAdmin(), user.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := []*APIKey{}
+	for rows.Next() {
+		var apiKey APIKey
+		if err = rows.Scan(&apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.Scope, &apiKey.CreatedAt); err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, &apiKey)
+	}
+
+	return apiKeys, rows.Err()
+}
+
+// DeleteAPIKey deletes an API key of the user by its name, the requests using it will fail from now on
+func DeleteAPIKey(configs *util.Configs, userID int64, name string) error {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM api_keys WHERE user_id = ? AND name = ?;", userID, name)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return apierror.NotFound(fmt.Sprintf("API key %s does not exist", name))
+	}
+
+	return nil
+}
+
+// GetAPIKeyByKey returns the API key with the hash of the key and its user, nil if there is none
+func GetAPIKeyByKey(configs *util.Configs, key string) (*APIKey, *User, error) {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	var apiKey APIKey
+	var user User
+	err = db.QueryRow(`
+SELECT
+  k.user_id, k.name, k.prefix, k.scope, k.created_at, u.id, u.name, u.role, u.created_at
+FROM
+  api_keys k
+  JOIN users u ON u.id = k.user_id
+WHERE
+  k.key_hash = ?;`, HashKey(key),
+	).Scan(&apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.Scope, &apiKey.CreatedAt, &user.ID, &user.Name, &user.Role, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	return &apiKey, &user, nil
+}
+
+// GetRequestKey returns the key sent in the "Authorization: Bearer" or "X-API-Key" header, empty if there is none.
+// On the routes that allow it, the key can also be sent in the "api_key" query parameter.
+func GetRequestKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+
+	authorization := c.GetHeader("Authorization")
+	if len(authorization) > len("Bearer ") && strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(authorization[len("Bearer "):])
+	}
+
+	if c.GetBool("AllowQueryKey") {
+		return c.Query("api_key")
+	}
+
+	return ""
+}
+
+// AllowQueryKey lets the requests send the API key in the "api_key" query parameter, for the clients that
+// can't set headers, like calendar apps. It must be used before Authenticate.
+func AllowQueryKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("AllowQueryKey", true)
+		c.Next()
+	}
+}
+
+// GetRequiredScope returns the scope needed to make a request with the method.
+// Only GET, HEAD and OPTIONS requests can be made with read-only keys.
+func GetRequiredScope(method string) Scope {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeRead
+	default:
+		return ScopeWrite
+	}
+}
+
+// Authenticate aborts the requests without a valid API key with 401,
+// and the ones with a key without the scope needed by the request method with 403.
+// The API key is set in the context as "APIKey" and its user as "User".
+// If the auth is disabled in the configs, nothing is checked and the requests are made as the default user.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		configs, err := util.GetConfigs()
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
+		if !configs.Auth.Enabled {
+			user, err := GetUserByID(configs, database.DefaultUserID)
+			if err != nil {
+				apierror.Respond(c, err)
+				return
+			}
+			if user == nil {
+				apierror.Respond(c, apierror.Internal("the default user does not exist"))
+				return
+			}
+			c.Set("User", user)
+			logging.SetUserID(c, user.ID)
+			c.Next()
+			return
+		}
+
+		key := GetRequestKey(c)
+		if key == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="dashboard"`)
+			apierror.Respond(c, apierror.Unauthorized("missing API key"))
+			return
+		}
+
+		apiKey, user, err := GetAPIKeyByKey(configs, key)
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
+		if apiKey == nil {
+			c.Header("WWW-Authenticate", `Bearer realm="dashboard", error="invalid_token"`)
+			apierror.Respond(c, apierror.Unauthorized("invalid API key"))
+			return
+		}
+
+		c.Set("APIKey", apiKey)
+		c.Set("User", user)
+		logging.SetUserID(c, user.ID)
+		if !checkScope(c, GetRequiredScope(c.Request.Method)) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope aborts the requests with an API key without the scope with 403.
+// It must be used after Authenticate, and lets all requests pass if the auth is disabled.
+func RequireScope(scope Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !checkScope(c, scope) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func checkScope(c *gin.Context, scope Scope) bool {
+	value, exists := c.Get("APIKey")
+	if !exists {
+		// Auth disabled
+		return true
+	}
+
+	apiKey := value.(*APIKey)
+	if !apiKey.Scope.Allows(scope) {
+		apierror.Respond(c, apierror.Forbidden(fmt.Sprintf("the API key %s doesn't have the %s scope", apiKey.Name, scope)))
+		return false
+	}
+
+	return true
+}
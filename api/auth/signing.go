@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+// A URLSigner signs the URLs that don't need an API key, like the cover thumbnails of the activity feeds,
+// so they only give access to the resource they link to
+type URLSigner struct {
+	key []byte
+}
+
+// GetURLSigner returns the signer with the URL signing key of the database
+func GetURLSigner(configs *util.Configs) (*URLSigner, error) {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var key []byte
+	if err = db.QueryRow("SELECT value FROM secrets WHERE name = 'url_signing_key';").Scan(&key); err != nil {
+		return nil, err
+	}
+
+	return &URLSigner{key: key}, nil
+}
+
+// Sign returns the URL-safe HMAC-SHA256 signature of the message
+func (signer *URLSigner) Sign(message string) string {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write([]byte(message))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns whether the signature is the signature of the message
+func (signer *URLSigner) Verify(message, signature string) bool {
+	return hmac.Equal([]byte(signer.Sign(message)), []byte(signature))
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// A Role is what a user can see and manage
+type Role string
+
+const (
+	// Can see the entries and jobs of all users, and manage the users
+	RoleAdmin Role = "admin"
+	// Can only see and manage its own entries, jobs, and API keys
+	RoleUser Role = "user"
+)
+
+// EnumNames returns the roles, for the API documentation
+func (Role) EnumNames() []string {
+	return []string{string(RoleAdmin), string(RoleUser)}
+}
+
+func (r Role) IsValid() bool {
+	return r == RoleAdmin || r == RoleUser
+}
+
+// A User owns tracker entries, jobs, and API keys
+type User struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Role      Role   `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// CanSee returns whether the user can see the entries and jobs of the user with the ID
+func (u *User) CanSee(userID int64) bool {
+	return u.IsAdmin() || u.ID == userID
+}
+
+// CreateUser creates a user and returns it
+func CreateUser(configs *util.Configs, name string, role Role) (*User, error) {
+	if name == "" {
+		return nil, fmt.Errorf("the user name can't be empty")
+	}
+	if !role.IsValid() {
+		return nil, fmt.Errorf("invalid role %q, should be %q or %q", role, RoleAdmin, RoleUser)
+	}
+
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	user := User{Name: name, Role: role, CreatedAt: time.Now().Format("2006-01-02 15:04:05")}
+	result, err := db.Exec("INSERT INTO users (name, role, created_at) VALUES (?, ?, ?);", user.Name, user.Role, user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	user.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetUsers returns all users, ordered by name
+func GetUsers(configs *util.Configs) ([]*User, error) {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, name, role, created_at FROM users ORDER BY name;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*User{}
+	for rows.Next() {
+		var user User
+		if err = rows.Scan(&user.ID, &user.Name, &user.Role, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+
+	return users, rows.Err()
+}
+
+// GetUserByName returns the user with the name, nil if there is none
+func GetUserByName(configs *util.Configs, name string) (*User, error) {
+	return getUser(configs, "name = ?", name)
+}
+
+// GetUserByID returns the user with the ID, nil if there is none
+func GetUserByID(configs *util.Configs, id int64) (*User, error) {
+	return getUser(configs, "id = ?", id)
+}
+
+func getUser(configs *util.Configs, condition string, args ...interface{}) (*User, error) {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var user User
+	err = db.QueryRow("SELECT id, name, role, created_at FROM users WHERE "+condition+";", args...).
+		Scan(&user.ID, &user.Name, &user.Role, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// DeleteUser deletes a user by its name, together with its entries and API keys.
+// The default user, which makes the requests when the auth is disabled, and the last admin can't be deleted.
+func DeleteUser(configs *util.Configs, name string) error {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	var role Role
+	err = tx.QueryRow("SELECT id, role FROM users WHERE name = ?;", name).Scan(&id, &role)
+	if err == sql.ErrNoRows {
+		return apierror.NotFound(fmt.Sprintf("user %s does not exist", name))
+	} else if err != nil {
+		return err
+	}
+	if id == database.DefaultUserID {
+		return apierror.Validation(fmt.Sprintf("the user %s is the default user, which makes the requests when the auth is disabled, and can't be deleted", name))
+	}
+	if role == RoleAdmin {
+		var admins int
+		if err = tx.QueryRow("SELECT COUNT(*) FROM users WHERE role = ?;", RoleAdmin).Scan(&admins); err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return apierror.Validation(fmt.Sprintf("the user %s is the last admin and can't be deleted", name))
+		}
+	}
+
+	if _, err = tx.Exec("DELETE FROM users WHERE id = ?;", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetUser returns the user making the request, set by the Authenticate middleware
+func GetUser(c *gin.Context) *User {
+	return c.MustGet("User").(*User)
+}
+
+// RequireAdmin aborts the requests of users that are not admins with 403.
+// It must be used after Authenticate.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := GetUser(c)
+		if !user.IsAdmin() {
+			apierror.Respond(c, apierror.Forbidden(fmt.Sprintf("the user %s is not an admin", user.Name)))
+			return
+		}
+
+		c.Next()
+	}
+}
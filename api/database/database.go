@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+var (
+	// Paths of the databases that already had their migrations applied by this process
+	migratedDBs = map[string]bool{}
+	mutex       sync.Mutex
+)
+
+// OpenTrackersDB opens the trackers database inside the configured databases folder.
+// The first time the database is opened, all pending migrations are applied.
+func OpenTrackersDB(configs *util.Configs) (*sql.DB, error) {
+	dbPath := filepath.Join(configs.Database.FolderPath, "trackers.db")
+
+	return Open(dbPath, TrackersMigrations)
+}
+
+// Open opens a SQLite database with the foreign keys enforcement enabled and the queries latency
+// recorded in the metrics, and applies the migrations that were not applied yet.
+func Open(dbPath string, migrations []Migration) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dbPath+"?_foreign_keys=on")
+	if err != nil {
+		return nil, err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !migratedDBs[dbPath] {
+		err = Migrate(db, migrations)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		migratedDBs[dbPath] = true
+	}
+
+	return db, nil
+}
+
+// A Migration changes the database schema (and data) from one version to the next one.
+// The migrations are identified by their position in a slice, the first one is version 1.
+type Migration func(tx *sql.Tx) error
+
+// Migrate applies the migrations with a version greater than the database's user_version.
+// Each migration runs in its own transaction together with the user_version update.
+// The foreign keys enforcement is disabled while the migrations run, so they can rebuild tables,
+// and the foreign keys are checked before each migration is committed.
+func Migrate(db *sql.DB, migrations []Migration) error {
+	version, err := GetVersion(db)
+	if err != nil {
+		return err
+	}
+	if version >= len(migrations) {
+		return nil
+	}
+
+	// The foreign_keys pragma is set by connection and can't be changed inside a transaction
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF;")
+	if err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON;")
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		err = migrations[i](tx)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error while applying migration %d: %s", i+1, err)
+		}
+
+		err = checkForeignKeys(tx)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error while applying migration %d: %s", i+1, err)
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d;", i+1))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkForeignKeys returns an error if any row references a row that doesn't exist
+func checkForeignKeys(tx *sql.Tx) error {
+	rows, err := tx.Query("PRAGMA foreign_key_check;")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var fkID int
+		if err = rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return err
+		}
+		return fmt.Errorf("row %d of the table %s references a row of the table %s that doesn't exist", rowID.Int64, table, parent)
+	}
+
+	return rows.Err()
+}
+
+// GetVersion returns the current schema version of the database
+func GetVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("PRAGMA user_version;").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
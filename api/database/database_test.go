@@ -0,0 +1,249 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateTrackersCSVColumns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "trackers.db")
+
+	// Create a database in the state the scripts/setup_db.py script leaves it
+	db, err := Open(dbPath, TrackersMigrations[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+INSERT INTO games_tracker (name, tags, developers, publishers)
+VALUES
+  ('Terraria', 'Sandbox,Survival, 2D', 'Re-Logic', 'Re-Logic'),
+  ('Empty', '', '', NULL);
+INSERT INTO medias_tracker (name, genres, staff)
+VALUES
+  ('The Dark Knight', 'Action,Crime,Drama', 'Christopher Nolan,Christian Bale');
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// Apply the remaining migrations
+	delete(migratedDBs, dbPath)
+	db, err = Open(dbPath, TrackersMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	version, err := GetVersion(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(TrackersMigrations) {
+		t.Errorf("expected version: %d, actual version: %d", len(TrackersMigrations), version)
+	}
+
+	testTable := []struct {
+		query    string
+		owner    string
+		expected []string
+	}{
+		{"SELECT t.name FROM games_tags l JOIN tags t ON t.id = l.tag_id WHERE l.game_name = ? ORDER BY l.position", "Terraria", []string{"Sandbox", "Survival", "2D"}},
+		{"SELECT t.name FROM games_tags l JOIN tags t ON t.id = l.tag_id WHERE l.game_name = ? ORDER BY l.position", "Empty", nil},
+		{"SELECT d.name FROM games_developers l JOIN developers d ON d.id = l.developer_id WHERE l.game_name = ?", "Terraria", []string{"Re-Logic"}},
+		{"SELECT g.name FROM medias_genres l JOIN genres g ON g.id = l.genre_id WHERE l.media_name = ? ORDER BY l.position", "The Dark Knight", []string{"Action", "Crime", "Drama"}},
+		{"SELECT s.name FROM medias_staff l JOIN staff s ON s.id = l.staff_id WHERE l.media_name = ? ORDER BY l.position", "The Dark Knight", []string{"Christopher Nolan", "Christian Bale"}},
+	}
+	for _, test := range testTable {
+		rows, err := db.Query(test.query, test.owner)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var actual []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Error(err)
+			}
+			actual = append(actual, name)
+		}
+		rows.Close()
+
+		if !reflect.DeepEqual(test.expected, actual) {
+			t.Errorf("expected: %v, actual: %v", test.expected, actual)
+		}
+	}
+
+	// The comma-separated columns should be gone
+	_, err = db.Exec("SELECT tags FROM games_tracker;")
+	if err == nil {
+		t.Error("expected the games_tracker.tags column to be dropped")
+	}
+
+	// Deleting a game should delete its links
+	_, err = db.Exec("DELETE FROM games_tracker WHERE name = 'Terraria';")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var links int
+	err = db.QueryRow("SELECT COUNT(*) FROM games_tags;").Scan(&links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links != 0 {
+		t.Errorf("expected no games_tags rows after deleting the game, found %d", links)
+	}
+}
+
+func TestMigrateExternalIDs(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "trackers.db")
+
+	db, err := Open(dbPath, TrackersMigrations[:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+INSERT INTO games_tracker (name, url)
+VALUES
+  ('Terraria', 'https://store.steampowered.com/app/105600/Terraria/'),
+  ('Terraria 2', 'https://store.steampowered.com/app/105600/Terraria/?l=brazilian'),
+  ('Manual', 'https://example.com/manual');
+INSERT INTO medias_tracker (name, url)
+VALUES
+  ('The Dark Knight', 'https://www.imdb.com/title/tt0468569/?ref_=nv_sr_srsg_0');
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	delete(migratedDBs, dbPath)
+	db, err = Open(dbPath, TrackersMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	testTable := []struct {
+		table    string
+		name     string
+		expected *string
+	}{
+		{"games_tracker", "Terraria", newString("steam:105600")},
+		{"games_tracker", "Terraria 2", nil},
+		{"games_tracker", "Manual", nil},
+		{"medias_tracker", "The Dark Knight", newString("imdb:tt0468569")},
+	}
+	for _, test := range testTable {
+		var actual *string
+		err := db.QueryRow("SELECT external_id FROM "+test.table+" WHERE name = ?;", test.name).Scan(&actual)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(test.expected, actual) {
+			t.Errorf("%s: expected external ID: %v, actual external ID: %v", test.name, test.expected, actual)
+		}
+	}
+
+	// Entries with the same external ID should be rejected
+	_, err = db.Exec("INSERT INTO games_tracker (user_id, name, external_id) VALUES (1, 'Terraria 3', 'steam:105600');")
+	if err == nil {
+		t.Errorf("expected an error when inserting a duplicated external ID")
+	}
+}
+
+func TestMigrateUsers(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "trackers.db")
+
+	db, err := Open(dbPath, TrackersMigrations[:5])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+INSERT INTO games_tracker (name, url, external_id) VALUES ('Terraria', 'https://store.steampowered.com/app/105600/Terraria/', 'steam:105600');
+INSERT INTO tags (id, name) VALUES (1, 'Sandbox');
+INSERT INTO games_tags (game_name, tag_id, position) VALUES ('Terraria', 1, 1);
+INSERT INTO games_status_history (game_name, to_status, changed_at) VALUES ('Terraria', 2, '2023-01-01 00:00:00');
+INSERT INTO medias_tracker (name) VALUES ('The Dark Knight');
+INSERT INTO api_keys (name, prefix, key_hash, scope, created_at) VALUES ('dashboard', 'dash_0000', 'hash', 'write', '2023-01-01 00:00:00');
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	delete(migratedDBs, dbPath)
+	db, err = Open(dbPath, TrackersMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The existing rows are owned by the default user
+	for _, table := range []string{"games_tracker", "games_tags", "games_status_history", "medias_tracker", "api_keys"} {
+		var userID int
+		if err := db.QueryRow("SELECT user_id FROM " + table + ";").Scan(&userID); err != nil {
+			t.Error(err)
+			continue
+		}
+		if userID != DefaultUserID {
+			t.Errorf("%s: expected user ID: %d, actual user ID: %d", table, DefaultUserID, userID)
+		}
+	}
+
+	// Other users can track entries with the same name and external ID
+	_, err = db.Exec(`
+INSERT INTO users (id, name, role, created_at) VALUES (2, 'other', 'user', '2023-01-01 00:00:00');
+INSERT INTO games_tracker (user_id, name, external_id) VALUES (2, 'Terraria', 'steam:105600');
+INSERT INTO games_tags (user_id, game_name, tag_id, position) VALUES (2, 'Terraria', 1, 1);
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("INSERT INTO games_tracker (user_id, name) VALUES (2, 'Terraria');")
+	if err == nil {
+		t.Error("expected an error when inserting a duplicated name for the same user")
+	}
+
+	// The foreign keys reference the new primary key
+	_, err = db.Exec("INSERT INTO games_tags (user_id, game_name, tag_id, position) VALUES (2, 'Unknown', 1, 1);")
+	if err == nil {
+		t.Error("expected an error when linking a tag to a game that doesn't exist")
+	}
+	_, err = db.Exec("DELETE FROM users WHERE id = 2;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM games_tags;").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected the links of the deleted user games to be deleted, actual links: %d", count)
+	}
+}
+
+func newString(s string) *string {
+	return &s
+}
+
+func TestGetStatement(t *testing.T) {
+	testTable := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT name FROM games_tracker;", "select"},
+		{"\n\t\tinsert INTO games_tracker (name) VALUES (?);", "insert"},
+		{"PRAGMA user_version;", "pragma"},
+		{"BEGIN;", "other"},
+		{"", "other"},
+	}
+	for _, test := range testTable {
+		if statement := getStatement(test.query); statement != test.expected {
+			t.Errorf("query %q: expected statement %s, got %s", test.query, test.expected, statement)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/diogovalentte/dashboard/api/metrics"
+)
+
+// Name of the SQLite driver that records the queries latency in the metrics
+const driverName = "sqlite3_instrumented"
+
+func init() {
+	sql.Register(driverName, &instrumentedDriver{})
+}
+
+// The statements recorded in the metrics, the others are recorded as "other"
+var statements = map[string]bool{
+	"select": true, "insert": true, "update": true, "delete": true, "with": true,
+	"create": true, "alter": true, "drop": true, "pragma": true,
+}
+
+// getStatement returns the first keyword of the query in lowercase, like "select"
+func getStatement(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	statement := strings.ToLower(strings.TrimSuffix(fields[0], ";"))
+	if !statements[statement] {
+		return "other"
+	}
+
+	return statement
+}
+
+// instrumentedDriver opens SQLite connections that record the queries latency.
+// The latency of a query is the time to execute it or to get its first rows.
+type instrumentedDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d *instrumentedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+type instrumentedConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	defer metrics.ObserveDBQuery(getStatement(query), time.Now())
+	return c.SQLiteConn.ExecContext(ctx, query, args)
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	defer metrics.ObserveDBQuery(getStatement(query), time.Now())
+	return c.SQLiteConn.QueryContext(ctx, query, args)
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.SQLiteConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedStmt{stmt.(*sqlite3.SQLiteStmt), getStatement(query)}, nil
+}
+
+type instrumentedStmt struct {
+	*sqlite3.SQLiteStmt
+	statement string
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	defer metrics.ObserveDBQuery(s.statement, time.Now())
+	return s.SQLiteStmt.ExecContext(ctx, args)
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	defer metrics.ObserveDBQuery(s.statement, time.Now())
+	return s.SQLiteStmt.QueryContext(ctx, args)
+}
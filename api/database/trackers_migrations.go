@@ -0,0 +1,541 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TrackersMigrations are the migrations of the trackers database, in order
+var TrackersMigrations = []Migration{
+	createTrackersTables,
+	normalizeTrackersEntities,
+	createStatusHistoryTables,
+	addExternalIDs,
+	createAPIKeysTable,
+	addUsers,
+	createWebhookDeliveriesTable,
+	createReleaseRemindersTable,
+	createStarsHistoryTables,
+	createSecretsTable,
+}
+
+// createTrackersTables creates the tables created by the scripts/setup_db.py script.
+// Databases created by the script already have them, so nothing changes.
+func createTrackersTables(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS games_tracker (
+    url VARCHAR(200),
+    name VARCHAR(50) PRIMARY KEY,
+    cover_img BLOB,
+    release_date DATE,
+    tags TEXT,
+    developers TEXT,
+    publishers TEXT,
+    priority SMALLINT,
+    status SMALLINT,
+    stars SMALLINT,
+    purchased_or_gamepass BOOLEAN,
+    started_date DATE,
+    finished_dropped_date DATE,
+    commentary TEXT
+);
+
+CREATE TABLE IF NOT EXISTS medias_tracker (
+    url VARCHAR(200),
+    name VARCHAR(50) PRIMARY KEY,
+    media_type VARCHAR(20),
+    cover_img BLOB,
+    release_date DATE,
+    genres TEXT,
+    staff TEXT,
+    priority SMALLINT,
+    status SMALLINT,
+    stars SMALLINT,
+    started_date DATE,
+    finished_dropped_date DATE,
+    commentary TEXT
+);
+`)
+
+	return err
+}
+
+// An entity that was stored as a comma-separated column of a tracker table
+// and now has its own table and a many-to-many table linking it to the tracker table.
+type csvEntityColumn struct {
+	trackerTable string
+	column       string
+	entityTable  string
+	linkTable    string
+	ownerColumn  string
+	entityColumn string
+}
+
+var csvEntityColumns = []csvEntityColumn{
+	{"games_tracker", "tags", "tags", "games_tags", "game_name", "tag_id"},
+	{"games_tracker", "developers", "developers", "games_developers", "game_name", "developer_id"},
+	{"games_tracker", "publishers", "publishers", "games_publishers", "game_name", "publisher_id"},
+	{"medias_tracker", "genres", "genres", "medias_genres", "media_name", "genre_id"},
+	{"medias_tracker", "staff", "staff", "medias_staff", "media_name", "staff_id"},
+}
+
+// normalizeTrackersEntities moves the tags, developers, publishers, genres and staff
+// from the comma-separated columns to their own tables, then drops the old columns.
+func normalizeTrackersEntities(tx *sql.Tx) error {
+	for _, c := range csvEntityColumns {
+		_, err := tx.Exec(fmt.Sprintf(`
+CREATE TABLE %s (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE %s (
+    %s VARCHAR(50) NOT NULL REFERENCES %s (name) ON DELETE CASCADE ON UPDATE CASCADE,
+    %s INTEGER NOT NULL REFERENCES %s (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (%s, %s)
+);
+
+CREATE INDEX %s_%s_idx ON %s (%s);
+`,
+			c.entityTable,
+			c.linkTable,
+			c.ownerColumn, c.trackerTable,
+			c.entityColumn, c.entityTable,
+			c.ownerColumn, c.entityColumn,
+			c.linkTable, c.entityColumn, c.linkTable, c.entityColumn,
+		))
+		if err != nil {
+			return err
+		}
+
+		err = migrateCSVEntityColumn(tx, c)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", c.trackerTable, c.column))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrateCSVEntityColumn(tx *sql.Tx, c csvEntityColumn) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT name, COALESCE(%s, '') FROM %s;", c.column, c.trackerTable))
+	if err != nil {
+		return err
+	}
+
+	values := map[string]string{}
+	for rows.Next() {
+		var owner, csv string
+		if err = rows.Scan(&owner, &csv); err != nil {
+			rows.Close()
+			return err
+		}
+		values[owner] = csv
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	insertEntity := fmt.Sprintf("INSERT INTO %s (name) VALUES (?) ON CONFLICT (name) DO NOTHING;", c.entityTable)
+	selectEntity := fmt.Sprintf("SELECT id FROM %s WHERE name = ?;", c.entityTable)
+	insertLink := fmt.Sprintf("INSERT OR IGNORE INTO %s (%s, %s, position) VALUES (?, ?, ?);", c.linkTable, c.ownerColumn, c.entityColumn)
+	for owner, csv := range values {
+		for position, name := range strings.Split(csv, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+
+			if _, err = tx.Exec(insertEntity, name); err != nil {
+				return err
+			}
+			var id int64
+			if err = tx.QueryRow(selectEntity, name).Scan(&id); err != nil {
+				return err
+			}
+			if _, err = tx.Exec(insertLink, owner, id, position); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// createStatusHistoryTables creates the tables with the status changes of the games and medias.
+// The changes made before this migration are unknown, so the tables start empty.
+func createStatusHistoryTables(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE games_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_name VARCHAR(50) NOT NULL REFERENCES games_tracker (name) ON DELETE CASCADE ON UPDATE CASCADE,
+    from_status SMALLINT,
+    to_status SMALLINT NOT NULL,
+    changed_at DATETIME NOT NULL
+);
+
+CREATE INDEX games_status_history_game_name_idx ON games_status_history (game_name);
+
+CREATE TABLE medias_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    media_name VARCHAR(50) NOT NULL REFERENCES medias_tracker (name) ON DELETE CASCADE ON UPDATE CASCADE,
+    from_status SMALLINT,
+    to_status SMALLINT NOT NULL,
+    changed_at DATETIME NOT NULL
+);
+
+CREATE INDEX medias_status_history_media_name_idx ON medias_status_history (media_name);
+`)
+
+	return err
+}
+
+// An externalIDColumn is how the external IDs of a tracker are found in the entries URLs
+type externalIDColumn struct {
+	trackerTable string
+	urlRegex     *regexp.Regexp
+	prefix       string
+}
+
+// addExternalIDs adds the external_id column with the Steam app ID of the games and the IMDB ID of the medias,
+// like "steam:105600" and "imdb:tt0468569", found in their URLs. When entries have the same external ID,
+// only the first one added gets it, so the unique index can be created.
+func addExternalIDs(tx *sql.Tx) error {
+	columns := []externalIDColumn{
+		{"games_tracker", regexp.MustCompile(`/app/(\d+)`), "steam"},
+		{"medias_tracker", regexp.MustCompile(`/title/(tt\d+)`), "imdb"},
+	}
+
+	for _, c := range columns {
+		_, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN external_id TEXT;", c.trackerTable))
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(fmt.Sprintf("SELECT name, COALESCE(url, '') FROM %s ORDER BY rowid;", c.trackerTable))
+		if err != nil {
+			return err
+		}
+		var names, externalIDs []string
+		seen := map[string]bool{}
+		for rows.Next() {
+			var name, url string
+			if err = rows.Scan(&name, &url); err != nil {
+				rows.Close()
+				return err
+			}
+			match := c.urlRegex.FindStringSubmatch(url)
+			if match == nil {
+				continue
+			}
+			externalID := c.prefix + ":" + match[1]
+			if seen[externalID] {
+				continue
+			}
+			seen[externalID] = true
+			names = append(names, name)
+			externalIDs = append(externalIDs, externalID)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		for i, name := range names {
+			_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET external_id = ? WHERE name = ?;", c.trackerTable), externalIDs[i], name)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s_external_id_idx ON %s (external_id) WHERE external_id IS NOT NULL;", c.trackerTable, c.trackerTable))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createAPIKeysTable creates the table with the keys used to authenticate on the API.
+// Only the SHA-256 hash of the keys is stored, the prefix is kept to identify them.
+func createAPIKeysTable(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scope VARCHAR(10) NOT NULL,
+    created_at DATETIME NOT NULL
+);
+`)
+
+	return err
+}
+
+// ID of the user that owns the entries and API keys created before the users existed
+const DefaultUserID = 1
+
+// A userOwnedTable is a table rebuilt by the addUsers migration with a user_id column
+type userOwnedTable struct {
+	name string
+	// CREATE TABLE statement, formatted with the table name
+	createSQL string
+	// Columns copied from the old table
+	columns string
+}
+
+var userOwnedTables = []userOwnedTable{
+	{"games_tracker", `
+CREATE TABLE %s (
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    url VARCHAR(200),
+    name VARCHAR(50) NOT NULL,
+    cover_img BLOB,
+    release_date DATE,
+    priority SMALLINT,
+    status SMALLINT,
+    stars SMALLINT,
+    purchased_or_gamepass BOOLEAN,
+    started_date DATE,
+    finished_dropped_date DATE,
+    commentary TEXT,
+    external_id TEXT,
+    PRIMARY KEY (user_id, name)
+);`, "url, name, cover_img, release_date, priority, status, stars, purchased_or_gamepass, started_date, finished_dropped_date, commentary, external_id"},
+	{"medias_tracker", `
+CREATE TABLE %s (
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    url VARCHAR(200),
+    name VARCHAR(50) NOT NULL,
+    media_type VARCHAR(20),
+    cover_img BLOB,
+    release_date DATE,
+    priority SMALLINT,
+    status SMALLINT,
+    stars SMALLINT,
+    started_date DATE,
+    finished_dropped_date DATE,
+    commentary TEXT,
+    external_id TEXT,
+    PRIMARY KEY (user_id, name)
+);`, "url, name, media_type, cover_img, release_date, priority, status, stars, started_date, finished_dropped_date, commentary, external_id"},
+	{"games_status_history", `
+CREATE TABLE %s (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    game_name VARCHAR(50) NOT NULL,
+    from_status SMALLINT,
+    to_status SMALLINT NOT NULL,
+    changed_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id, game_name) REFERENCES games_tracker (user_id, name) ON DELETE CASCADE ON UPDATE CASCADE
+);`, "id, game_name, from_status, to_status, changed_at"},
+	{"medias_status_history", `
+CREATE TABLE %s (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    media_name VARCHAR(50) NOT NULL,
+    from_status SMALLINT,
+    to_status SMALLINT NOT NULL,
+    changed_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id, media_name) REFERENCES medias_tracker (user_id, name) ON DELETE CASCADE ON UPDATE CASCADE
+);`, "id, media_name, from_status, to_status, changed_at"},
+	{"api_keys", `
+CREATE TABLE %s (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scope VARCHAR(10) NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE (user_id, name)
+);`, "id, name, prefix, key_hash, scope, created_at"},
+}
+
+// addUsers creates the users table with a default admin user that owns all existing entries and API keys.
+// The tracker tables are rebuilt with the user_id in their primary key, so different users can track entries
+// with the same name, and the tables linked to them are rebuilt to reference the new primary key.
+// The names of the entries were the primary keys, so the old indexes and foreign keys are recreated.
+func addUsers(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    role VARCHAR(10) NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+INSERT INTO users (id, name, role, created_at) VALUES (?, 'default', 'admin', ?);
+`, DefaultUserID, time.Now().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return err
+	}
+
+	tables := append([]userOwnedTable{}, userOwnedTables...)
+	for _, c := range csvEntityColumns {
+		tables = append(tables, userOwnedTable{c.linkTable, fmt.Sprintf(`
+CREATE TABLE %%s (
+    user_id INTEGER NOT NULL,
+    %s VARCHAR(50) NOT NULL,
+    %s INTEGER NOT NULL REFERENCES %s (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (user_id, %s, %s),
+    FOREIGN KEY (user_id, %s) REFERENCES %s (user_id, name) ON DELETE CASCADE ON UPDATE CASCADE
+);`,
+			c.ownerColumn,
+			c.entityColumn, c.entityTable,
+			c.ownerColumn, c.entityColumn,
+			c.ownerColumn, c.trackerTable,
+		), fmt.Sprintf("%s, %s, position", c.ownerColumn, c.entityColumn)})
+	}
+
+	// Copy the tables, then replace the old ones. The foreign keys are not enforced while migrating,
+	// so the old tables can be dropped without their rows being deleted in cascade.
+	for _, t := range tables {
+		_, err = tx.Exec(fmt.Sprintf(t.createSQL, t.name+"_new"))
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(fmt.Sprintf("INSERT INTO %s_new (user_id, %s) SELECT ?, %s FROM %s;", t.name, t.columns, t.columns, t.name), DefaultUserID)
+		if err != nil {
+			return err
+		}
+	}
+	for _, t := range tables {
+		_, err = tx.Exec(fmt.Sprintf("DROP TABLE %s;", t.name))
+		if err != nil {
+			return err
+		}
+	}
+	for _, t := range tables {
+		_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s_new RENAME TO %s;", t.name, t.name))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+CREATE UNIQUE INDEX games_tracker_external_id_idx ON games_tracker (user_id, external_id) WHERE external_id IS NOT NULL;
+CREATE UNIQUE INDEX medias_tracker_external_id_idx ON medias_tracker (user_id, external_id) WHERE external_id IS NOT NULL;
+CREATE INDEX games_status_history_game_name_idx ON games_status_history (user_id, game_name);
+CREATE INDEX medias_status_history_media_name_idx ON medias_status_history (user_id, media_name);
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
+`)
+	if err != nil {
+		return err
+	}
+	for _, c := range csvEntityColumns {
+		_, err = tx.Exec(fmt.Sprintf("CREATE INDEX %s_%s_idx ON %s (%s);", c.linkTable, c.entityColumn, c.linkTable, c.entityColumn))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createWebhookDeliveriesTable creates the log of the events sent to the webhooks.
+// Each delivery is a row updated after each attempt.
+func createWebhookDeliveriesTable(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE webhook_deliveries (
+    id CHAR(36) PRIMARY KEY,
+    url VARCHAR(200) NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    state VARCHAR(10) NOT NULL,
+    attempts INTEGER NOT NULL,
+    last_status_code INTEGER,
+    last_error TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_created_at_idx ON webhook_deliveries (created_at);
+`)
+
+	return err
+}
+
+// createReleaseRemindersTable creates the log of the release reminders sent, so each reminder is sent once.
+// The release date is part of the key, so the reminders are sent again if the release date changes.
+func createReleaseRemindersTable(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE release_reminders (
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    tracker VARCHAR(20) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    release_date VARCHAR(10) NOT NULL,
+    days_before INTEGER NOT NULL,
+    sent_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, tracker, name, release_date, days_before)
+);
+`)
+
+	return err
+}
+
+// createStarsHistoryTables creates the tables with the stars changes of the games and medias, the ratings
+// in the activity feeds. The ratings made before this migration are unknown, so the tables start empty.
+func createStarsHistoryTables(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE games_stars_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    game_name VARCHAR(50) NOT NULL,
+    from_stars SMALLINT NOT NULL,
+    to_stars SMALLINT NOT NULL,
+    changed_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id, game_name) REFERENCES games_tracker (user_id, name) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX games_stars_history_game_name_idx ON games_stars_history (user_id, game_name);
+
+CREATE TABLE medias_stars_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    media_name VARCHAR(50) NOT NULL,
+    from_stars SMALLINT NOT NULL,
+    to_stars SMALLINT NOT NULL,
+    changed_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id, media_name) REFERENCES medias_tracker (user_id, name) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX medias_stars_history_media_name_idx ON medias_stars_history (user_id, media_name);
+`)
+
+	return err
+}
+
+// createSecretsTable creates the table with the secrets generated by the API, with a random key to sign the URLs
+// that don't need an API key, like the cover thumbnails of the activity feeds
+func createSecretsTable(tx *sql.Tx) error {
+	urlSigningKey := make([]byte, 32)
+	if _, err := rand.Read(urlSigningKey); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`
+CREATE TABLE secrets (
+    name VARCHAR(50) PRIMARY KEY,
+    value BLOB NOT NULL
+);
+
+INSERT INTO secrets (name, value) VALUES ('url_signing_key', ?);
+`, urlSigningKey)
+
+	return err
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/openapi"
+	"github.com/diogovalentte/dashboard/api/routes/api_keys"
+	"github.com/diogovalentte/dashboard/api/routes/health_check"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+	"github.com/diogovalentte/dashboard/api/routes/users"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/diogovalentte/dashboard/api/webhook"
+)
+
+var apiInfo = openapi.Info{
+	Title:   "Personal Dashboard API",
+	Version: "1",
+	Description: "API of the Games and Medias Trackers. The routes that don't respond with something else respond with a message, " +
+		"and all errors have a code and a message. The routes with the wait field or query parameter create a job, " +
+		"which responds with \"Job created with success\" unless wait is true.",
+}
+
+var (
+	userQuery      = openapi.Query("user", "Name of the user to limit the response to, only admins can set it to other users")
+	apiKeyQuery    = openapi.Query("api_key", "API key, for the apps that can't send it in a header")
+	dateRangeQuery = []openapi.Parameter{
+		openapi.Query("from", "Start of the date range, YYYY-MM-DD"),
+		openapi.Query("to", "End of the date range, YYYY-MM-DD"),
+	}
+	statusQuery       = openapi.QueryArray("status", "Status name or number, can be repeated")
+	activityFeedQuery = []openapi.Parameter{
+		openapi.Query("tracker", "Only include the activity of the tracker", "games", "medias"),
+		openapi.Query("limit", "Max number of items, from 1 to 500, 50 by default"),
+		apiKeyQuery,
+		userQuery,
+	}
+)
+
+// gamesResponse and mediasResponse are the responses of the routes that list games and medias
+var (
+	gamesResponse  = openapi.Object{"games": []*trackers.GetGameProperties{}}
+	mediasResponse = openapi.Object{"medias": []*trackers.GetMediaProperties{}}
+)
+
+// operations are the routes of the API documented in the OpenAPI document.
+// The TestOpenAPIRoutes test fails if a route of the router is missing here, or the other way around.
+func operations() []openapi.Operation {
+	ops := []openapi.Operation{
+		// Docs, metrics, and health
+		{Method: http.MethodGet, Path: "/metrics", Tag: "system", Summary: "Prometheus metrics", Public: true, ResponseContentTypes: []string{"text/plain"}},
+		{Method: http.MethodGet, Path: "/v1/openapi.json", Tag: "docs", Summary: "This OpenAPI document", Public: true, Response: openapi.Object{}},
+		{Method: http.MethodGet, Path: "/v1/docs", Tag: "docs", Summary: "Swagger UI of the OpenAPI document", Public: true, ResponseContentTypes: []string{"text/html"}},
+		{Method: http.MethodGet, Path: "/v1/health", Tag: "health", Summary: "Responds OK", Public: true, ResponseContentTypes: []string{"text/plain"}},
+		{Method: http.MethodGet, Path: "/v1/health/live", Tag: "health", Summary: "Whether the API is running", Public: true, Response: openapi.Object{"status": ""}},
+		{Method: http.MethodGet, Path: "/v1/health/ready", Tag: "health", Summary: "Whether the API can handle requests, 503 if any check fails", Public: true, Response: health_check.ReadyResponse{}},
+
+		// API keys
+		{Method: http.MethodGet, Path: "/v1/api_keys/get_all", Tag: "api_keys", Summary: "List the API keys of the user, or of all users for admins", Response: openapi.Object{"api_keys": []auth.APIKey{}}},
+		{Method: http.MethodPost, Path: "/v1/api_keys/create", Tag: "api_keys", Summary: "Create an API key, the response is the only time the key is shown", Request: api_keys.CreateAPIKeyRequest{}, Response: openapi.Object{"message": "", "key": ""}},
+		{Method: http.MethodPost, Path: "/v1/api_keys/delete", Tag: "api_keys", Summary: "Delete an API key", Request: api_keys.DeleteAPIKeyRequest{}},
+
+		// Users
+		{Method: http.MethodGet, Path: "/v1/users/get_all", Tag: "users", Summary: "List the users, admins only", Response: openapi.Object{"users": []*auth.User{}}},
+		{Method: http.MethodPost, Path: "/v1/users/create", Tag: "users", Summary: "Create a user, admins only", Request: users.CreateUserRequest{}, Response: openapi.Object{"message": "", "user": auth.User{}}},
+		{Method: http.MethodPost, Path: "/v1/users/delete", Tag: "users", Summary: "Delete a user, admins only", Request: users.DeleteUserRequest{}},
+
+		// Jobs
+		{Method: http.MethodGet, Path: "/v1/jobs/get_all", Tag: "jobs", Summary: "List the jobs of the user, or of all users for admins", Response: openapi.Object{"jobs": []*job.Job{}}},
+		{Method: http.MethodDelete, Path: "/v1/jobs/delete_all", Tag: "jobs", Summary: "Delete the jobs of the user, or of all users for admins"},
+
+		// System
+		{Method: http.MethodGet, Path: "/v1/system/get_geckodrivers", Tag: "system", Summary: "Addresses of the GeckoDriver instances", Response: openapi.Object{"addresses": []string{}}},
+		{Method: http.MethodGet, Path: "/v1/system/configs", Tag: "system", Summary: "State of the configs reloads", Response: openapi.Object{"configs": util.ConfigsStatus{}}},
+		{Method: http.MethodPost, Path: "/v1/system/reload_configs", Tag: "system", Summary: "Reload the configs file, admins only", Response: openapi.Object{"message": "", "changes": []util.ConfigChange{}, "configs": util.ConfigsStatus{}}},
+
+		// Webhooks
+		{Method: http.MethodGet, Path: "/v1/webhooks/get_deliveries", Tag: "webhooks", Summary: "Last deliveries of events to the webhooks, admins only", Parameters: []openapi.Parameter{openapi.Query("limit", "Number of deliveries, 50 by default")}, Response: openapi.Object{"deliveries": []*webhook.Delivery{}}},
+
+		// Trackers
+		{Method: http.MethodGet, Path: "/v1/trackers/get_enums", Tag: "trackers", Summary: "Valid statuses, priorities, and media types", Response: openapi.Object{
+			"statuses": []trackers.EnumValue{}, "priorities": []trackers.EnumValue{}, "media_types": []trackers.EnumValue{},
+		}},
+		{Method: http.MethodGet, Path: "/v1/trackers/stats", Tag: "trackers", Summary: "Aggregated values of the trackers, the date range limits the started, finished, and dropped entries",
+			Parameters: append(dateRangeQuery, userQuery), Response: openapi.Object{"games": trackers.TrackerStats{}, "medias": trackers.TrackerStats{}}},
+		{Method: http.MethodGet, Path: "/v1/trackers/reports/:year", Tag: "trackers", Summary: "Report of the entries finished and dropped in the year",
+			Parameters: []openapi.Parameter{
+				{Name: "year", In: "path", Type: 0},
+				openapi.Query("format", "Format of the report, json by default", "json", "markdown", "html"),
+				userQuery,
+			},
+			Response: openapi.Object{"report": trackers.YearReport{}}, ResponseContentTypes: []string{"text/markdown", "text/html"}},
+		{Method: http.MethodGet, Path: "/v1/trackers/export", Tag: "trackers", Summary: "Export the games and medias, they can be imported back",
+			Parameters: []openapi.Parameter{
+				openapi.Query("format", "Format of the export, csv by default", "csv", "json", "ndjson"),
+				openapi.Query("tracker", "Only export the entries of the tracker", "games", "medias"),
+				statusQuery,
+				openapi.QueryArray("tag", "Tag name, can be repeated"),
+				openapi.QueryArray("developer", "Developer name, can be repeated"),
+				openapi.QueryArray("publisher", "Publisher name, can be repeated"),
+				openapi.QueryArray("genre", "Genre name, can be repeated"),
+				openapi.QueryArray("staff", "Staff member name, can be repeated"),
+				openapi.Query("include_covers", "Include the base64-encoded cover images", "true", "false"),
+				userQuery,
+			},
+			Response: []*trackers.ExportEntry{}, ResponseContentTypes: []string{"text/csv", "application/x-ndjson"}},
+		{Method: http.MethodPost, Path: "/v1/trackers/import", Tag: "trackers", Summary: "Import games and medias from the request body or the file field of a multipart form",
+			Parameters: []openapi.Parameter{
+				openapi.Query("format", "Format of the file, csv by default", "csv", "json", "ndjson", "imdb", "letterboxd"),
+				openapi.Query("tracker", "Tracker of the entries without one", "games", "medias"),
+				openapi.Query("update", "Update the entries that already exist with the fields the file has, else they're skipped", "true", "false"),
+				openapi.Query("dry_run", "Only report what would be created, updated, or skipped", "true", "false"),
+				openapi.Query("wait", "Wait for the import to be done before responding", "true", "false"),
+			},
+			RequestContentTypes: []string{"text/csv", "application/json", "application/x-ndjson", "multipart/form-data"}, Response: trackers.ImportReport{}},
+		{Method: http.MethodGet, Path: "/v1/trackers/calendar.ics", Tag: "trackers", Summary: "iCalendar feed of the release dates of the to be released entries, the API key can be sent in the api_key query parameter",
+			Parameters: []openapi.Parameter{
+				openapi.Query("tracker", "Only include the entries of the tracker", "games", "medias"),
+				openapi.QueryArray("priority", "Priority name or number, can be repeated"),
+				openapi.QueryArray("tag", "Tag name, can be repeated"),
+				openapi.QueryArray("developer", "Developer name, can be repeated"),
+				openapi.QueryArray("publisher", "Publisher name, can be repeated"),
+				openapi.QueryArray("genre", "Genre name, can be repeated"),
+				openapi.QueryArray("staff", "Staff member name, can be repeated"),
+				openapi.Query("include_started_finished", "Also include the started, finished, and dropped dates of the entries", "true", "false"),
+				apiKeyQuery,
+				userQuery,
+			},
+			ResponseContentTypes: []string{"text/calendar"}},
+		{Method: http.MethodGet, Path: "/v1/trackers/activity.atom", Tag: "trackers", Summary: "Atom feed of the games and medias added, started, finished, dropped, and rated, the API key can be sent in the api_key query parameter",
+			Parameters: activityFeedQuery, ResponseContentTypes: []string{"application/atom+xml"}},
+		{Method: http.MethodGet, Path: "/v1/trackers/activity.rss", Tag: "trackers", Summary: "RSS feed of the games and medias added, started, finished, dropped, and rated, the API key can be sent in the api_key query parameter",
+			Parameters: activityFeedQuery, ResponseContentTypes: []string{"application/rss+xml"}},
+		{Method: http.MethodGet, Path: "/v1/trackers/cover_thumbnail", Tag: "trackers", Summary: "Thumbnail of the cover image of a game or media, linked by the activity feeds, it needs the entry token of the feeds instead of an API key",
+			Public: true, Parameters: []openapi.Parameter{
+				openapi.Query("tracker", "Tracker of the entry", "games", "medias"),
+				openapi.Query("user_id", "ID of the user that owns the entry"),
+				openapi.Query("name", "Name of the entry"),
+				openapi.Query("token", "Signature of the entry, from the thumbnail URLs of the feeds"),
+			},
+			ResponseContentTypes: []string{"image/jpeg", "image/png"}},
+		{Method: http.MethodPost, Path: "/v1/trackers/check_releases", Tag: "trackers", Summary: "Send the due release reminders and move the released entries now, admins only", Response: openapi.Object{"message": "", "check": trackers.ReleasesCheck{}}},
+	}
+
+	gamesOps := []openapi.Operation{
+		{Method: http.MethodPost, Path: "/v1/trackers/games_tracker/add_game", Summary: "Scrape a game from its Steam URL and add it", Request: trackers.AddGameRequest{}},
+		{Method: http.MethodPost, Path: "/v1/trackers/games_tracker/add_games", Summary: "Add many games, one child job for each", Request: trackers.AddGamesRequest{},
+			Response: openapi.Object{"message": "", "results": []*trackers.BatchItemResult{}}},
+		{Method: http.MethodPost, Path: "/v1/trackers/games_tracker/sync_steam", Summary: "Add the games owned and wishlisted by a Steam account", Request: trackers.SteamSyncRequest{},
+			Response: trackers.SteamSyncReport{}},
+		{Method: http.MethodPost, Path: "/v1/trackers/games_tracker/add_game_manually", Summary: "Add a game without scraping it", Request: trackers.GameProperties{}},
+		{Method: http.MethodPost, Path: "/v1/trackers/games_tracker/update_game", Summary: "Update a game", Request: trackers.UpdateGameRequest{}},
+		{Method: http.MethodPost, Path: "/v1/trackers/games_tracker/delete_game", Summary: "Delete a game", Request: trackers.DeleteGameRequest{}},
+		{Method: http.MethodPost, Path: "/v1/trackers/games_tracker/get_game", Summary: "Get a game by its name", Request: trackers.GetGameRequest{},
+			Response: openapi.Object{"game": trackers.GetGameProperties{}}},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_all_games", Summary: "List all games", Parameters: []openapi.Parameter{userQuery}, Response: gamesResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_playing_games", Summary: "List the games being played", Parameters: []openapi.Parameter{userQuery}, Response: gamesResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_to_be_released_games", Summary: "List the games to be released", Parameters: []openapi.Parameter{userQuery}, Response: gamesResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_not_started_games", Summary: "List the games not started", Parameters: []openapi.Parameter{userQuery}, Response: gamesResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_finished_games", Summary: "List the finished games", Parameters: []openapi.Parameter{userQuery}, Response: gamesResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_dropped_games", Summary: "List the dropped games", Parameters: []openapi.Parameter{userQuery}, Response: gamesResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_filtered_games", Summary: "List the games linked to all tags, developers, and publishers in the query",
+			Parameters: []openapi.Parameter{
+				openapi.QueryArray("tag", "Tag name, can be repeated"),
+				openapi.QueryArray("developer", "Developer name, can be repeated"),
+				openapi.QueryArray("publisher", "Publisher name, can be repeated"),
+				userQuery,
+			},
+			Response: gamesResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_tags", Summary: "Count the games of each tag", Parameters: []openapi.Parameter{userQuery},
+			Response: openapi.Object{"tags": []*trackers.EntityCount{}}},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_developers", Summary: "Count the games of each developer", Parameters: []openapi.Parameter{userQuery},
+			Response: openapi.Object{"developers": []*trackers.EntityCount{}}},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_publishers", Summary: "Count the games of each publisher", Parameters: []openapi.Parameter{userQuery},
+			Response: openapi.Object{"publishers": []*trackers.EntityCount{}}},
+		{Method: http.MethodGet, Path: "/v1/trackers/games_tracker/get_timeline", Summary: "Status changes of the games, oldest first",
+			Parameters: []openapi.Parameter{openapi.Query("name", "Name of the game to limit the timeline to"), userQuery},
+			Response:   openapi.Object{"timeline": []*trackers.StatusChange{}}},
+	}
+
+	mediasOps := []openapi.Operation{
+		{Method: http.MethodPost, Path: "/v1/trackers/medias_tracker/add_media", Summary: "Scrape a media from its IMDB URL and add it", Request: trackers.AddMediaRequest{}},
+		{Method: http.MethodPost, Path: "/v1/trackers/medias_tracker/add_medias", Summary: "Add many medias, one child job for each", Request: trackers.AddMediasRequest{},
+			Response: openapi.Object{"message": "", "results": []*trackers.BatchItemResult{}}},
+		{Method: http.MethodPost, Path: "/v1/trackers/medias_tracker/add_media_manually", Summary: "Add a media without scraping it", Request: trackers.MediaProperties{}},
+		{Method: http.MethodPost, Path: "/v1/trackers/medias_tracker/update_media", Summary: "Update a media", Request: trackers.UpdateMediaRequest{}},
+		{Method: http.MethodPost, Path: "/v1/trackers/medias_tracker/delete_media", Summary: "Delete a media", Request: trackers.DeleteMediaRequest{}},
+		{Method: http.MethodPost, Path: "/v1/trackers/medias_tracker/get_media", Summary: "Get a media by its name", Request: trackers.GetMediaRequest{},
+			Response: openapi.Object{"media": trackers.GetMediaProperties{}}},
+		{Method: http.MethodGet, Path: "/v1/trackers/medias_tracker/get_all_medias", Summary: "List all medias", Parameters: []openapi.Parameter{userQuery}, Response: mediasResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/medias_tracker/get_watching_reading_medias", Summary: "List the medias being watched or read", Parameters: []openapi.Parameter{userQuery}, Response: mediasResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/medias_tracker/get_to_be_released_medias", Summary: "List the medias to be released", Parameters: []openapi.Parameter{userQuery}, Response: mediasResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/medias_tracker/get_not_started_medias", Summary: "List the medias not started", Parameters: []openapi.Parameter{userQuery}, Response: mediasResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/medias_tracker/get_finished_medias", Summary: "List the finished medias", Parameters: []openapi.Parameter{userQuery}, Response: mediasResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/medias_tracker/get_dropped_medias", Summary: "List the dropped medias", Parameters: []openapi.Parameter{userQuery}, Response: mediasResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/medias_tracker/get_filtered_medias", Summary: "List the medias linked to all genres and staff members in the query",
+			Parameters: []openapi.Parameter{
+				openapi.QueryArray("genre", "Genre name, can be repeated"),
+				openapi.QueryArray("staff", "Staff member name, can be repeated"),
+				userQuery,
+			},
+			Response: mediasResponse},
+		{Method: http.MethodGet, Path: "/v1/trackers/medias_tracker/get_genres", Summary: "Count the medias of each genre", Parameters: []openapi.Parameter{userQuery},
+			Response: openapi.Object{"genres": []*trackers.EntityCount{}}},
+		{Method: http.MethodGet, Path: "/v1/trackers/medias_tracker/get_staff", Summary: "Count the medias of each staff member", Parameters: []openapi.Parameter{userQuery},
+			Response: openapi.Object{"staff": []*trackers.EntityCount{}}},
+		{Method: http.MethodGet, Path: "/v1/trackers/medias_tracker/get_timeline", Summary: "Status changes of the medias, oldest first",
+			Parameters: []openapi.Parameter{openapi.Query("name", "Name of the media to limit the timeline to"), userQuery},
+			Response:   openapi.Object{"timeline": []*trackers.StatusChange{}}},
+	}
+
+	for _, op := range gamesOps {
+		op.Tag = "games_tracker"
+		ops = append(ops, op)
+	}
+	for _, op := range mediasOps {
+		op.Tag = "medias_tracker"
+		ops = append(ops, op)
+	}
+
+	return ops
+}
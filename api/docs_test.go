@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api/openapi"
+)
+
+// TestOpenAPIRoutes fails when a route is added to or removed from the router without updating the operations
+func TestOpenAPIRoutes(t *testing.T) {
+	routes := map[string]bool{}
+	for _, route := range SetupRouter().Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	documented := map[string]bool{}
+	for _, operation := range operations() {
+		key := operation.Method + " " + operation.Path
+		if documented[key] {
+			t.Errorf("%s is documented more than once", key)
+		}
+		documented[key] = true
+	}
+
+	var missing, removed []string
+	for route := range routes {
+		if !documented[route] {
+			missing = append(missing, route)
+		}
+	}
+	for operation := range documented {
+		if !routes[operation] {
+			removed = append(removed, operation)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(removed)
+	if len(missing) > 0 {
+		t.Errorf("routes missing in the OpenAPI operations: %s", strings.Join(missing, ", "))
+	}
+	if len(removed) > 0 {
+		t.Errorf("OpenAPI operations without a route: %s", strings.Join(removed, ", "))
+	}
+}
+
+func TestOpenAPIDocumentRoute(t *testing.T) {
+	router := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/openapi.json", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code: %d, actual status code: %d", http.StatusOK, w.Code)
+	}
+	var document openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	if document.OpenAPI == "" || len(document.Paths) == 0 {
+		t.Fatalf("unexpected document: %s", w.Body.String())
+	}
+
+	// The request schemas are generated from the types, and the components they reference must exist
+	addGame := document.Paths["/v1/trackers/games_tracker/add_game"]["post"]
+	if addGame == nil || addGame.RequestBody == nil {
+		t.Fatal("expected the add_game operation with a request body")
+	}
+	ref := addGame.RequestBody.Content["application/json"].Schema.Ref
+	schema := document.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]
+	if schema == nil {
+		t.Fatalf("the %s component doesn't exist", ref)
+	}
+	if schema.Properties["url"] == nil || schema.Properties["url"].Format != "uri" {
+		t.Errorf("expected the url property with the uri format, got %+v", schema.Properties["url"])
+	}
+	if report := document.Paths["/v1/trackers/reports/{year}"]["get"]; report == nil || report.Parameters[0].In != "path" {
+		t.Errorf("expected the year path parameter in the report operation")
+	}
+	if health := document.Paths["/v1/health/ready"]["get"]; health == nil || health.Security == nil || len(*health.Security) != 0 {
+		t.Errorf("expected the ready route to not need an API key")
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/v1/docs", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"openapi.json"`) {
+		t.Errorf("unexpected Swagger UI page: %d %s", w.Code, w.Body.String())
+	}
+}
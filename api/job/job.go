@@ -1,9 +1,12 @@
 package job
 
 import (
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/diogovalentte/dashboard/api/metrics"
+	"github.com/diogovalentte/dashboard/api/webhook"
 	"github.com/google/uuid"
 )
 
@@ -29,8 +32,19 @@ type Job struct {
 	// It will be set by the SetCompletedState or the SetFailedState functions.
 	// Should have format "2006-01-02 15:04:05"
 	Completed_Failed_At string
-	id                  uuid.UUID
-	mutex               sync.Mutex
+	// Jobs started by this job, like one job for each item of a batch
+	Children []*Job
+	// ID of the user that created the job
+	UserID int64
+	// ID of the request that created the job, to find its logs
+	RequestID string
+	id        uuid.UUID
+	mutex     sync.Mutex
+}
+
+// Logger returns the default logger with the job ID, task, request ID, and user ID
+func (job *Job) Logger() *slog.Logger {
+	return slog.Default().With("job_id", job.id.String(), "task", job.Task, "request_id", job.RequestID, "user_id", job.UserID)
 }
 
 // The Set*State functions set the current state of a Job
@@ -92,6 +106,9 @@ func (job *Job) SetCompletedStateWithValue(stateMessage, value string) {
 	job.State = "Completed"
 	job.StateDescription = stateMessage
 	job.Value = value
+	job.Logger().Info("job completed", "state_description", stateMessage, "value", value)
+	metrics.ObserveJob(job.Task, job.State)
+	webhook.Send(webhook.EventJobCompleted, job.getEvent())
 }
 
 func (job *Job) SetCompletedState(stateMessage string) {
@@ -102,6 +119,9 @@ func (job *Job) SetCompletedState(stateMessage string) {
 	job.Completed_Failed_At = now
 	job.StateDescription = stateMessage
 	job.State = "Completed"
+	job.Logger().Info("job completed", "state_description", stateMessage, "value", job.Value)
+	metrics.ObserveJob(job.Task, job.State)
+	webhook.Send(webhook.EventJobCompleted, job.getEvent())
 }
 
 // Set a state of failed to the job
@@ -116,6 +136,68 @@ func (job *Job) SetFailedState(err error) {
 	job.Completed_Failed_At = now
 	job.State = "Failed"
 	job.StateDescription = err.Error()
+	job.Logger().Error("job failed", "error", err, "value", job.Value)
+	metrics.ObserveJob(job.Task, job.State)
+	webhook.Send(webhook.EventJobFailed, job.getEvent())
+}
+
+// An Event is a job sent to the webhooks when it completes or fails
+type Event struct {
+	ID                string `json:"id"`
+	Task              string `json:"task"`
+	State             string `json:"state"`
+	StateDescription  string `json:"state_description"`
+	Value             string `json:"value"`
+	CreatedAt         string `json:"created_at"`
+	CompletedFailedAt string `json:"completed_failed_at"`
+	UserID            int64  `json:"user_id"`
+	RequestID         string `json:"request_id"`
+}
+
+// getEvent returns the job's current state as an Event, the job mutex must be locked
+func (job *Job) getEvent() Event {
+	return Event{
+		ID:                job.id.String(),
+		Task:              job.Task,
+		State:             job.State,
+		StateDescription:  job.StateDescription,
+		Value:             job.Value,
+		CreatedAt:         job.CreatedAt,
+		CompletedFailedAt: job.Completed_Failed_At,
+		UserID:            job.UserID,
+		RequestID:         job.RequestID,
+	}
+}
+
+// AddChild adds a job started by this job
+func (job *Job) AddChild(child *Job) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	child.id = uuid.New()
+	child.UserID = job.UserID
+	child.RequestID = job.RequestID
+	job.Children = append(job.Children, child)
+}
+
+// GetChildrenProgress returns how many children jobs completed, failed, and exist
+func (job *Job) GetChildrenProgress() (completed, failed, total int) {
+	job.mutex.Lock()
+	children := job.Children
+	job.mutex.Unlock()
+
+	for _, child := range children {
+		child.mutex.Lock()
+		switch child.State {
+		case "Completed":
+			completed++
+		case "Failed":
+			failed++
+		}
+		child.mutex.Unlock()
+	}
+
+	return completed, failed, len(children)
 }
 
 func NewJobsList() *Jobs {
@@ -148,9 +230,38 @@ func (jobs *Jobs) GetJobs() []*Job {
 	return jobs.Jobs
 }
 
+// GetUserJobs returns the jobs created by the user
+func (jobs *Jobs) GetUserJobs(userID int64) []*Job {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+
+	userJobs := []*Job{}
+	for _, job := range jobs.Jobs {
+		if job.UserID == userID {
+			userJobs = append(userJobs, job)
+		}
+	}
+
+	return userJobs
+}
+
 func (jobs *Jobs) DeleteAllJobs() {
 	jobs.mutex.Lock()
 	defer jobs.mutex.Unlock()
 
 	jobs.Jobs = []*Job{}
 }
+
+// DeleteUserJobs deletes the jobs created by the user
+func (jobs *Jobs) DeleteUserJobs(userID int64) {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+
+	otherJobs := []*Job{}
+	for _, job := range jobs.Jobs {
+		if job.UserID != userID {
+			otherJobs = append(otherJobs, job)
+		}
+	}
+	jobs.Jobs = otherJobs
+}
@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Header with the ID of a request, sent by the client or generated by the API
+const RequestIDHeader = "X-Request-ID"
+
+// The request IDs sent by the clients are only used if they match it, so they are safe to log
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Level of the logs, it can be changed while the API is running
+var level = new(slog.LevelVar)
+
+// Setup makes the default logger, also used by the log package, write JSON logs to the writer
+func Setup(w io.Writer, levelName string) error {
+	if err := SetLevel(levelName); err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+
+	return nil
+}
+
+// SetLevel sets the minimum level of the logs, it can be debug, info, warn, or error
+func SetLevel(levelName string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(levelName)); err != nil {
+		return fmt.Errorf("invalid log level %q, should be debug, info, warn, or error", levelName)
+	}
+	level.Set(l)
+
+	return nil
+}
+
+// Fatal logs the error and exits
+func Fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// GetRequestID returns the ID of the request, set by the RequestID middleware
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("RequestID")
+}
+
+// Request returns the default logger with the request ID
+func Request(c *gin.Context) *slog.Logger {
+	return slog.Default().With("request_id", GetRequestID(c))
+}
+
+// RequestID sets the ID of the request in the context as "RequestID" and in the X-Request-ID response header.
+// The ID sent by the client in the X-Request-ID header is used if valid, otherwise a new one is generated.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Set("RequestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// RequestLogger logs each request after it's handled, with its status code and latency.
+// It must be used after RequestID.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip", c.ClientIP(),
+		}
+		if userID, ok := getUserID(c); ok {
+			attrs = append(attrs, "user_id", userID)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+
+		logLevel := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			logLevel = slog.LevelError
+		case status >= http.StatusBadRequest:
+			logLevel = slog.LevelWarn
+		}
+		Request(c).Log(c.Request.Context(), logLevel, "request", attrs...)
+	}
+}
+
+// SetUserID sets the ID of the user making the request, so it's logged with the request
+func SetUserID(c *gin.Context, userID int64) {
+	c.Set("UserID", userID)
+}
+
+// getUserID returns the ID of the user making the request, if it was authenticated
+func getUserID(c *gin.Context) (int64, bool) {
+	value, exists := c.Get("UserID")
+	if !exists {
+		return 0, false
+	}
+
+	return value.(int64), true
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTestLogs makes the default logger write to the returned buffer until the test ends
+func setupTestLogs(t *testing.T) *bytes.Buffer {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	var logs bytes.Buffer
+	if err := Setup(&logs, "debug"); err != nil {
+		t.Fatal(err)
+	}
+
+	return &logs
+}
+
+// getLogRecords returns the JSON log records with the message
+func getLogRecords(t *testing.T, logs *bytes.Buffer, msg string) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON log line %q: %s", line, err)
+		}
+		if record["msg"] == msg {
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+func TestRequestID(t *testing.T) {
+	logs := setupTestLogs(t)
+
+	router := gin.New()
+	router.Use(RequestID(), RequestLogger())
+	router.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"request_id": GetRequestID(c)})
+	})
+
+	testTable := []struct {
+		path               string
+		requestID          string
+		expectedStatusCode int
+		// Whether the request ID sent should be kept
+		expectSameID bool
+	}{
+		{"/ok", "", http.StatusOK, false},
+		{"/ok", "client-request.1", http.StatusOK, true},
+		{"/ok", "invalid request id\n", http.StatusOK, false},
+	}
+
+	for _, test := range testTable {
+		logs.Reset()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+		if test.requestID != "" {
+			req.Header.Set(RequestIDHeader, test.requestID)
+		}
+		router.ServeHTTP(w, req)
+
+		if w.Code != test.expectedStatusCode {
+			t.Errorf("%s: expected status code: %d, actual status code: %d", test.path, test.expectedStatusCode, w.Code)
+		}
+		requestID := w.Header().Get(RequestIDHeader)
+		if requestID == "" {
+			t.Errorf("%s: missing the %s response header", test.path, RequestIDHeader)
+		}
+		if (requestID == test.requestID) != test.expectSameID {
+			t.Errorf("%s: unexpected request ID %q for the sent ID %q", test.path, requestID, test.requestID)
+		}
+
+		records := getLogRecords(t, logs, "request")
+		if len(records) != 1 {
+			t.Errorf("%s: expected one request log, got %d: %s", test.path, len(records), logs.String())
+			continue
+		}
+		if records[0]["request_id"] != requestID || records[0]["status"] != float64(test.expectedStatusCode) || records[0]["route"] != test.path {
+			t.Errorf("%s: unexpected request log: %v", test.path, records[0])
+		}
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	logs := setupTestLogs(t)
+	defer SetLevel("debug")
+
+	if err := SetLevel("warn"); err != nil {
+		t.Fatal(err)
+	}
+	slog.Info("hidden")
+	slog.Warn("shown")
+	if strings.Contains(logs.String(), "hidden") || !strings.Contains(logs.String(), "shown") {
+		t.Errorf("unexpected logs with the warn level: %s", logs.String())
+	}
+
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("expected an error with an invalid level")
+	}
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prefix of all metrics names
+const namespace = "dashboard"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route, and status code.",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	jobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_total",
+		Help:      "Finished jobs by task and final state.",
+	}, []string{"task", "state"})
+
+	scrapeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scrape_duration_seconds",
+		Help:      "Duration of the scrapes by provider and result.",
+		// The scrapes wait for pages to load, so they take seconds
+		Buckets: []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120},
+	}, []string{"provider", "result"})
+	scrapeFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scrape_failures_total",
+		Help:      "Failed scrapes by provider and the field that couldn't be scraped.",
+	}, []string{"provider", "field"})
+
+	// GeckoDriverPoolSize is the number of GeckoDriver instances in the pool
+	GeckoDriverPoolSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "geckodriver_pool_size",
+		Help:      "GeckoDriver instances in the pool.",
+	})
+	// GeckoDriverPoolInUse is the number of GeckoDriver instances being used, including
+	// the ones removed from the pool that are stopped when released
+	GeckoDriverPoolInUse = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "geckodriver_pool_in_use",
+		Help:      "GeckoDriver instances being used.",
+	})
+	// GeckoDriverPoolWaiters is the number of callers waiting for an available GeckoDriver instance
+	GeckoDriverPoolWaiters = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "geckodriver_pool_waiters",
+		Help:      "Callers waiting for an available GeckoDriver instance.",
+	})
+	// GeckoDriverRestarts is the number of GeckoDriver instances restarted because they stopped responding
+	GeckoDriverRestarts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "geckodriver_restarts_total",
+		Help:      "GeckoDriver instances restarted because they stopped responding.",
+	})
+
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of the database queries by statement, like select or insert.",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+	}, []string{"statement"})
+)
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// HTTPMiddleware records the latency and status code of the requests by route.
+// The requests that don't match a route are recorded with the "unmatched" route,
+// so random paths don't create new metrics.
+func HTTPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveJob records a job that finished with the state, like "Completed" or "Failed"
+func ObserveJob(task, state string) {
+	jobs.WithLabelValues(task, state).Inc()
+}
+
+// ObserveDBQuery records the latency of a database query that started at start by its statement, like "select"
+func ObserveDBQuery(statement string, start time.Time) {
+	dbQueryDuration.WithLabelValues(statement).Observe(time.Since(start).Seconds())
+}
+
+// A Scrape records the duration of a scrape and the field it failed on
+type Scrape struct {
+	provider string
+	start    time.Time
+	// Field being scraped, set before scraping each field.
+	// If the scrape fails, it's the field it failed on.
+	Field string
+}
+
+// NewScrape starts recording a scrape of the provider, like "steam" or "imdb"
+func NewScrape(provider string) *Scrape {
+	return &Scrape{provider: provider, start: time.Now(), Field: "page"}
+}
+
+// Done records the scrape duration, and the current field as failed if err isn't nil
+func (s *Scrape) Done(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+		scrapeFailures.WithLabelValues(s.provider, s.Field).Inc()
+	}
+	scrapeDuration.WithLabelValues(s.provider, result).Observe(time.Since(s.start).Seconds())
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getMetrics returns the metrics served by the router in the Prometheus text format
+func getMetrics(t *testing.T, router *gin.Engine) string {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	body, _ := io.ReadAll(w.Body)
+
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	router := gin.New()
+	router.Use(HTTPMiddleware())
+	router.GET("/metrics", Handler())
+	router.GET("/games/:name", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/games/a", "/games/b", "/random"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+	}
+
+	ObserveJob("Test task", "Failed")
+	ObserveDBQuery("select", time.Now())
+	scrape := NewScrape("test")
+	scrape.Done(nil)
+	scrape = NewScrape("test")
+	scrape.Field = "name"
+	scrape.Done(errors.New("element not found"))
+	GeckoDriverPoolSize.Set(3)
+
+	body := getMetrics(t, router)
+	expectedLines := []string{
+		// The route is used instead of the path
+		`dashboard_http_requests_total{method="GET",route="/games/:name",status="404"} 2`,
+		`dashboard_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`dashboard_http_request_duration_seconds_count{method="GET",route="/games/:name"} 2`,
+		`dashboard_jobs_total{state="Failed",task="Test task"} 1`,
+		`dashboard_db_query_duration_seconds_count{statement="select"} 1`,
+		`dashboard_scrape_duration_seconds_count{provider="test",result="success"} 1`,
+		`dashboard_scrape_duration_seconds_count{provider="test",result="failure"} 1`,
+		`dashboard_scrape_failures_total{field="name",provider="test"} 1`,
+		`dashboard_geckodriver_pool_size 3`,
+		`dashboard_geckodriver_pool_in_use 0`,
+		`dashboard_geckodriver_pool_waiters 0`,
+		`dashboard_geckodriver_restarts_total 0`,
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected the metrics to have the line %s", line)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/diogovalentte/dashboard/api/webhook"
+)
+
+// A Notification is a message sent to the channels, like a release reminder
+type Notification struct {
+	Title   string
+	Message string
+	// URL of the notification subject, like the entry store page, optional
+	URL string
+	// Event and data sent to the webhooks channel
+	Event string
+	Data  any
+}
+
+// A Channel sends notifications to somewhere, like an email address
+type Channel interface {
+	Name() string
+	Send(notification Notification) error
+}
+
+// GetChannels returns the channels of the reminders set in the configs
+func GetChannels(configs *util.Configs) []Channel {
+	var channels []Channel
+	if configs.Reminders.Webhooks {
+		channels = append(channels, WebhooksChannel{})
+	}
+	if configs.Reminders.SMTP.Host != "" {
+		channels = append(channels, SMTPChannel{configs.Reminders.SMTP})
+	}
+	if configs.Reminders.Ntfy.URL != "" {
+		channels = append(channels, NtfyChannel{configs.Reminders.Ntfy})
+	}
+
+	return channels
+}
+
+// WebhooksChannel sends the notifications events to the webhooks that receive them.
+// The webhooks deliveries are retried in the background, so it never fails.
+type WebhooksChannel struct{}
+
+func (WebhooksChannel) Name() string {
+	return "webhooks"
+}
+
+func (WebhooksChannel) Send(notification Notification) error {
+	webhook.Send(notification.Event, notification.Data)
+
+	return nil
+}
+
+// SMTPChannel sends the notifications as emails, like to a local relay
+type SMTPChannel struct {
+	Configs util.SMTPConfigs
+}
+
+func (SMTPChannel) Name() string {
+	return "smtp"
+}
+
+func (channel SMTPChannel) Send(notification Notification) error {
+	configs := channel.Configs
+	body := notification.Message
+	if notification.URL != "" {
+		body += "\r\n\r\n" + notification.URL
+	}
+	message := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		configs.From, strings.Join(configs.To, ", "), encodeHeader(notification.Title), time.Now().Format(time.RFC1123Z), body,
+	)
+
+	var auth smtp.Auth
+	if configs.Username != "" {
+		auth = smtp.PlainAuth("", configs.Username, configs.Password, configs.Host)
+	}
+	addr := net.JoinHostPort(configs.Host, strconv.Itoa(configs.Port))
+	if err := smtp.SendMail(addr, auth, configs.From, configs.To, []byte(message)); err != nil {
+		return fmt.Errorf("couldn't send the email to %s: %w", addr, err)
+	}
+
+	return nil
+}
+
+// NtfyChannel sends the notifications as ntfy-style HTTP push notifications: a POST to the topic URL
+// with the message as body and the title and URL as headers
+type NtfyChannel struct {
+	Configs util.NtfyConfigs
+}
+
+func (NtfyChannel) Name() string {
+	return "ntfy"
+}
+
+func (channel NtfyChannel) Send(notification Notification) error {
+	req, err := http.NewRequest(http.MethodPost, channel.Configs.URL, bytes.NewBufferString(notification.Message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", encodeHeader(notification.Title))
+	if notification.URL != "" {
+		req.Header.Set("Click", notification.URL)
+	}
+	if channel.Configs.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+channel.Configs.Token)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the ntfy server responded with the status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// encodeHeader returns the value as a header value, like the email subject. The line breaks are replaced by spaces,
+// so values like entry names can't add headers, and non-ASCII values are encoded as in RFC 2047.
+func encodeHeader(value string) string {
+	value = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(value)
+
+	return mime.QEncoding.Encode("utf-8", value)
+}
+
+// SendAll sends the notification to all channels and returns the errors of the channels that failed by their names
+func SendAll(channels []Channel, notification Notification) map[string]error {
+	errs := map[string]error{}
+	for _, channel := range channels {
+		if err := channel.Send(notification); err != nil {
+			errs[channel.Name()] = err
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+var testNotification = Notification{
+	Title:   "Terraria is released today",
+	Message: "Terraria, of the Games Tracker, is released on 2030-01-10.",
+	URL:     "https://store.steampowered.com/app/105600",
+}
+
+func TestNtfyChannel(t *testing.T) {
+	var request *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	channel := NtfyChannel{util.NtfyConfigs{URL: server.URL + "/releases", Token: "token"}}
+	if err := channel.Send(testNotification); err != nil {
+		t.Fatal(err)
+	}
+	if request.URL.Path != "/releases" || string(body) != testNotification.Message {
+		t.Errorf("unexpected request to %s: %s", request.URL.Path, body)
+	}
+	expectedHeaders := map[string]string{"Title": testNotification.Title, "Click": testNotification.URL, "Authorization": "Bearer token"}
+	for header, expected := range expectedHeaders {
+		if actual := request.Header.Get(header); actual != expected {
+			t.Errorf("expected the %s header %q, got %q", header, expected, actual)
+		}
+	}
+
+	channel.Configs.URL = server.URL + "/missing"
+	server.Config.Handler = http.NotFoundHandler()
+	if err := channel.Send(testNotification); err == nil {
+		t.Error("expected an error when the server responds with 404")
+	}
+}
+
+// serveTestSMTP accepts one SMTP session like a local relay, and sends the message data to the channel
+func serveTestSMTP(t *testing.T, listener net.Listener, messages chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	write("220 localhost test relay")
+	var data strings.Builder
+	inData := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if inData {
+			if line == ".\r\n" {
+				inData = false
+				messages <- data.String()
+				write("250 OK")
+				continue
+			}
+			data.WriteString(line)
+			continue
+		}
+
+		switch command := strings.ToUpper(strings.Fields(line)[0]); command {
+		case "EHLO", "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+			write("250 OK")
+		case "DATA":
+			inData = true
+			write("354 Go ahead")
+		case "QUIT":
+			write("221 Bye")
+			return
+		default:
+			write("502 Not implemented")
+		}
+	}
+}
+
+func TestSMTPChannel(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	messages := make(chan string, 1)
+	go serveTestSMTP(t, listener, messages)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	channel := SMTPChannel{util.SMTPConfigs{Host: host, Port: portNumber, From: "dashboard@localhost", To: []string{"me@localhost", "you@localhost"}}}
+	if err := channel.Send(testNotification); err != nil {
+		t.Fatal(err)
+	}
+
+	message := <-messages
+	for _, expected := range []string{"Subject: " + testNotification.Title, "To: me@localhost, you@localhost", testNotification.Message, testNotification.URL} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("expected %q in the message: %s", expected, message)
+		}
+	}
+}
+
+func TestNonASCIITitle(t *testing.T) {
+	notification := testNotification
+	notification.Title = "Pokémon is released today\r\nBcc: someone@localhost"
+	expectedTitle := "Pokémon is released today Bcc: someone@localhost"
+
+	var title string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		title = r.Header.Get("Title")
+	}))
+	defer server.Close()
+	if err := (NtfyChannel{util.NtfyConfigs{URL: server.URL}}).Send(notification); err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(title); err != nil || decoded != expectedTitle {
+		t.Errorf("expected the ntfy title %q, got %q (%q)", expectedTitle, decoded, title)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	messages := make(chan string, 1)
+	go serveTestSMTP(t, listener, messages)
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	channel := SMTPChannel{util.SMTPConfigs{Host: host, Port: portNumber, From: "dashboard@localhost", To: []string{"me@localhost"}}}
+	if err := channel.Send(notification); err != nil {
+		t.Fatal(err)
+	}
+
+	message := <-messages
+	headers, _, _ := strings.Cut(message, "\r\n\r\n")
+	var subject string
+	for _, line := range strings.Split(headers, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Errorf("expected the title to not add headers: %s", message)
+		}
+		if value, ok := strings.CutPrefix(line, "Subject: "); ok {
+			subject = value
+		}
+	}
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err != nil || decoded != expectedTitle {
+		t.Errorf("expected the subject %q, got %q (%q)", expectedTitle, decoded, subject)
+	}
+	for _, r := range headers {
+		if r > 127 {
+			t.Errorf("expected only ASCII headers: %s", headers)
+			break
+		}
+	}
+}
+
+func TestGetChannels(t *testing.T) {
+	configs := &util.Configs{Reminders: util.RemindersConfigs{
+		Webhooks: true,
+		Ntfy:     util.NtfyConfigs{URL: "https://ntfy.sh/releases"},
+	}}
+
+	var names []string
+	for _, channel := range GetChannels(configs) {
+		names = append(names, channel.Name())
+	}
+	if strings.Join(names, ",") != "webhooks,ntfy" {
+		t.Errorf("expected the webhooks and ntfy channels, got %v", names)
+	}
+}
@@ -0,0 +1,276 @@
+// Package openapi generates the OpenAPI 3 document of the API from the routes operations
+// and the Go types of their requests and responses.
+package openapi
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// An Operation is a route of the API and what it receives and responds
+type Operation struct {
+	Method string
+	// Path in the gin format, like /v1/trackers/reports/:year
+	Path    string
+	Tag     string
+	Summary string
+	// The route doesn't need an API key
+	Public bool
+	// The query and path parameters. The path parameters not listed are documented as strings.
+	Parameters []Parameter
+	// Zero value of the JSON request body type, nil if the route has no body
+	Request any
+	// Content types of the request body when it isn't JSON, like multipart/form-data
+	RequestContentTypes []string
+	// Zero value of the JSON response type or an Object.
+	// If nil and there are no other response content types, the route responds with a message.
+	Response any
+	// Content types of the response when it isn't JSON, like text/csv
+	ResponseContentTypes []string
+}
+
+// A Parameter is a query or path parameter of an operation
+type Parameter struct {
+	Name string
+	// "query" or "path", query if empty
+	In          string
+	Description string
+	Required    bool
+	// Zero value of the parameter type, a slice if it can be repeated. A string if nil.
+	Type any
+	// The valid values, like the formats of a route
+	Enum []string
+}
+
+// Query returns an optional string query parameter
+func Query(name, description string, enum ...string) Parameter {
+	return Parameter{Name: name, Description: description, Enum: enum}
+}
+
+// QueryArray returns an optional string query parameter that can be repeated, like ?tag=RPG&tag=Indie
+func QueryArray(name, description string) Parameter {
+	return Parameter{Name: name, Description: description, Type: []string{}}
+}
+
+// A Message is the response of the routes that only tell what they did, like "Game deleted from DB"
+type Message struct {
+	Message string `json:"message"`
+}
+
+// The Document types are the OpenAPI 3 objects, with only the fields the API needs
+type (
+	Document struct {
+		OpenAPI    string                          `json:"openapi"`
+		Info       Info                            `json:"info"`
+		Paths      map[string]map[string]*Endpoint `json:"paths"`
+		Components Components                      `json:"components"`
+		Security   []map[string][]string           `json:"security"`
+	}
+	Info struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description,omitempty"`
+	}
+	Components struct {
+		Schemas         map[string]*Schema         `json:"schemas"`
+		SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+	}
+	SecurityScheme struct {
+		Type   string `json:"type"`
+		Scheme string `json:"scheme,omitempty"`
+		In     string `json:"in,omitempty"`
+		Name   string `json:"name,omitempty"`
+	}
+	Endpoint struct {
+		Tags        []string             `json:"tags,omitempty"`
+		Summary     string               `json:"summary,omitempty"`
+		OperationID string               `json:"operationId"`
+		Parameters  []*ParameterObject   `json:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses"`
+		// Empty for the public routes, which overrides the document security
+		Security *[]map[string][]string `json:"security,omitempty"`
+	}
+	ParameterObject struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required"`
+		Schema      *Schema `json:"schema"`
+	}
+	RequestBody struct {
+		Required bool                  `json:"required"`
+		Content  map[string]*MediaType `json:"content"`
+	}
+	Response struct {
+		Description string                `json:"description"`
+		Content     map[string]*MediaType `json:"content,omitempty"`
+	}
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+)
+
+var pathParamRegex = regexp.MustCompile(`:(\w+)`)
+
+// OpenAPIPath returns the gin path in the OpenAPI format, like /reports/{year} for /reports/:year
+func OpenAPIPath(ginPath string) string {
+	return pathParamRegex.ReplaceAllString(ginPath, "{$1}")
+}
+
+// Build returns the OpenAPI document of the operations.
+// The routes that need an API key accept it in the Authorization or X-API-Key headers,
+// and all routes can respond with an apierror.Error.
+func Build(info Info, operations []Operation) *Document {
+	generator := newSchemaGenerator()
+	errorSchema := generator.schemaOf(reflect.TypeOf(apierror.Error{}))
+	messageSchema := generator.schemaOf(reflect.TypeOf(Message{}))
+
+	document := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*Endpoint{},
+		Components: Components{
+			Schemas: generator.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearer": {Type: "http", Scheme: "bearer"},
+				"apiKey": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+		Security: []map[string][]string{{"bearer": {}}, {"apiKey": {}}},
+	}
+
+	for _, operation := range operations {
+		path := OpenAPIPath(operation.Path)
+		endpoint := &Endpoint{
+			Summary:     operation.Summary,
+			OperationID: operationID(operation),
+			Parameters:  getParameters(generator, operation),
+			Responses: map[string]*Response{
+				"default": {
+					Description: "Error, its code tells the kind of error",
+					Content:     map[string]*MediaType{"application/json": {Schema: errorSchema}},
+				},
+			},
+		}
+		if operation.Tag != "" {
+			endpoint.Tags = []string{operation.Tag}
+		}
+		if operation.Public {
+			endpoint.Security = &[]map[string][]string{}
+		}
+
+		if operation.Request != nil || len(operation.RequestContentTypes) > 0 {
+			endpoint.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{}}
+			if operation.Request != nil {
+				endpoint.RequestBody.Content["application/json"] = &MediaType{Schema: generator.schemaOfValue(operation.Request)}
+			}
+			for _, contentType := range operation.RequestContentTypes {
+				endpoint.RequestBody.Content[contentType] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+			}
+		}
+
+		success := &Response{Description: "Success", Content: map[string]*MediaType{}}
+		if operation.Response != nil {
+			success.Content["application/json"] = &MediaType{Schema: generator.schemaOfValue(operation.Response)}
+		} else if len(operation.ResponseContentTypes) == 0 {
+			success.Content["application/json"] = &MediaType{Schema: messageSchema}
+		}
+		for _, contentType := range operation.ResponseContentTypes {
+			success.Content[contentType] = &MediaType{Schema: &Schema{Type: "string"}}
+		}
+		endpoint.Responses["200"] = success
+
+		if document.Paths[path] == nil {
+			document.Paths[path] = map[string]*Endpoint{}
+		}
+		document.Paths[path][strings.ToLower(operation.Method)] = endpoint
+	}
+
+	return document
+}
+
+// operationID returns an ID from the method and path, like get_v1_trackers_reports_year
+func operationID(operation Operation) string {
+	id := strings.ToLower(operation.Method) + operation.Path
+	id = strings.NewReplacer("/", "_", ":", "", ".", "_").Replace(id)
+
+	return id
+}
+
+// getParameters returns the operation parameters, with the path parameters it doesn't list
+func getParameters(generator *schemaGenerator, operation Operation) []*ParameterObject {
+	var parameters []*ParameterObject
+	listed := map[string]bool{}
+	for _, parameter := range operation.Parameters {
+		in := parameter.In
+		if in == "" {
+			in = "query"
+		}
+		schema := &Schema{Type: "string"}
+		if parameter.Type != nil {
+			schema = generator.schemaOfValue(parameter.Type)
+		}
+		if len(parameter.Enum) > 0 {
+			schema.Enum = parameter.Enum
+		}
+		parameters = append(parameters, &ParameterObject{
+			Name:        parameter.Name,
+			In:          in,
+			Description: parameter.Description,
+			Required:    parameter.Required || in == "path",
+			Schema:      schema,
+		})
+		listed[in+parameter.Name] = true
+	}
+
+	for _, match := range pathParamRegex.FindAllStringSubmatch(operation.Path, -1) {
+		if !listed["path"+match[1]] {
+			parameters = append(parameters, &ParameterObject{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	return parameters
+}
+
+// Handler responds with the document as JSON
+func Handler(document *Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, document)
+	}
+}
+
+// swaggerUIPage loads Swagger UI from a CDN, with the document at a path relative to the page
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: %q, dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
+
+// SwaggerUIHandler responds with a Swagger UI page of the document at the URL
+func SwaggerUIHandler(title, documentURL string) gin.HandlerFunc {
+	page := []byte(fmt.Sprintf(swaggerUIPage, html.EscapeString(title), documentURL))
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	}
+}
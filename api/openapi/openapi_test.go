@@ -0,0 +1,102 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testEnum int
+
+func (testEnum) EnumNames() []string {
+	return []string{"first", "second"}
+}
+
+type testItem struct {
+	Name string `json:"name" binding:"required,min=1"`
+}
+
+type testRequest struct {
+	Wait      bool        `json:"wait" binding:"-"`
+	URL       string      `json:"url" binding:"required,http_url"`
+	Kind      testEnum    `json:"kind" binding:"required,IsValidEnum"`
+	Stars     int         `json:"stars" binding:"omitempty,gte=0,lte=5"`
+	DateStr   string      `json:"date" binding:"omitempty,IsValidDate"`
+	Date      time.Time   `binding:"-"`
+	Tags      []string    `json:"tags" binding:"omitempty,max=3,dive,min=2"`
+	Items     []*testItem `json:"items" binding:"required,min=1,dive"`
+	CoverImg  []byte
+	CreatedAt time.Time
+	internal  string
+}
+
+func TestStructSchema(t *testing.T) {
+	generator := newSchemaGenerator()
+	schema := generator.schemaOf(reflect.TypeOf(&testRequest{}))
+	if schema.Ref != "#/components/schemas/testRequest" {
+		t.Fatalf("expected a reference to the component, got %+v", schema)
+	}
+	component := generator.components["testRequest"]
+
+	expectedProperties := map[string]string{
+		"wait":      `{"type":"boolean"}`,
+		"url":       `{"type":"string","format":"uri"}`,
+		"kind":      `{"type":"string","description":"The requests can also use the value number, starting at 1 in this order","enum":["first","second"]}`,
+		"stars":     `{"type":"integer","minimum":0,"maximum":5}`,
+		"date":      `{"type":"string","format":"date"}`,
+		"tags":      `{"type":"array","maxItems":3,"items":{"type":"string","minLength":2}}`,
+		"items":     `{"type":"array","minItems":1,"items":{"$ref":"#/components/schemas/testItem"}}`,
+		"CoverImg":  `{"type":"string","format":"byte"}`,
+		"CreatedAt": `{"type":"string","format":"date-time"}`,
+	}
+	if len(component.Properties) != len(expectedProperties) {
+		t.Errorf("expected the properties %v, got %v", expectedProperties, component.Properties)
+	}
+	for name, expected := range expectedProperties {
+		actual, err := json.Marshal(component.Properties[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(actual) != expected {
+			t.Errorf("%s: expected schema %s, got %s", name, expected, actual)
+		}
+	}
+	if !reflect.DeepEqual(component.Required, []string{"url", "kind", "items"}) {
+		t.Errorf("unexpected required properties: %v", component.Required)
+	}
+	if item := generator.components["testItem"]; item == nil || !reflect.DeepEqual(item.Required, []string{"name"}) {
+		t.Errorf("unexpected testItem component: %+v", item)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	document := Build(Info{Title: "Test", Version: "1"}, []Operation{
+		{Method: "GET", Path: "/v1/reports/:year", Public: true, Parameters: []Parameter{QueryArray("tag", "")}, Response: Object{"items": []testItem{}}},
+		{Method: "POST", Path: "/v1/add", Request: testRequest{}},
+	})
+
+	report := document.Paths["/v1/reports/{year}"]["get"]
+	if report == nil {
+		t.Fatalf("expected the report path, got %v", document.Paths)
+	}
+	if len(report.Parameters) != 2 || report.Parameters[1].Name != "year" || report.Parameters[1].In != "path" {
+		t.Errorf("unexpected parameters: %+v", report.Parameters)
+	}
+	if report.Security == nil || len(*report.Security) != 0 {
+		t.Errorf("expected the public operation to override the security")
+	}
+	if report.Responses["200"].Content["application/json"].Schema.Properties["items"].Type != "array" {
+		t.Errorf("unexpected response: %+v", report.Responses["200"])
+	}
+
+	add := document.Paths["/v1/add"]["post"]
+	if add.Security != nil || add.Responses["200"].Content["application/json"].Schema.Ref != "#/components/schemas/Message" {
+		t.Errorf("unexpected operation: %+v", add)
+	}
+	for _, name := range []string{"Error", "Message", "testRequest", "testItem"} {
+		if document.Components.Schemas[name] == nil {
+			t.Errorf("expected the %s component", name)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Schema is an OpenAPI 3 schema object, with only the fields the API needs
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// An Enum is a type encoded in JSON as one of a fixed set of names, like the tracker statuses
+type Enum interface {
+	EnumNames() []string
+}
+
+// An Object is a JSON object response built in the handler, like gin.H{"games": games}.
+// The values are zero values of the fields types.
+type Object map[string]any
+
+var (
+	enumType   = reflect.TypeOf((*Enum)(nil)).Elem()
+	timeType   = reflect.TypeOf(time.Time{})
+	objectType = reflect.TypeOf(Object{})
+)
+
+// schemaGenerator generates the schemas of Go types from their json and binding tags.
+// The schemas of named structs are kept as components and referenced.
+type schemaGenerator struct {
+	components map[string]*Schema
+	// Type of each component, to name the types with the same name of different packages
+	componentTypes map[string]reflect.Type
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		components:     map[string]*Schema{},
+		componentTypes: map[string]reflect.Type{},
+	}
+}
+
+// schemaOfValue returns the schema of the value's type, or of the Object's fields
+func (g *schemaGenerator) schemaOfValue(value any) *Schema {
+	if object, ok := value.(Object); ok {
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for name, fieldValue := range object {
+			schema.Properties[name] = g.schemaOfValue(fieldValue)
+		}
+		return schema
+	}
+
+	return g.schemaOf(reflect.TypeOf(value))
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	if t == nil || t == objectType {
+		return &Schema{Type: "object"}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Implements(enumType) {
+		names := reflect.Zero(t).Interface().(Enum).EnumNames()
+		schema := &Schema{Type: "string", Enum: names}
+		if t.Kind() != reflect.String {
+			schema.Description = "The requests can also use the value number, starting at 1 in this order"
+		}
+		return schema
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Encoded in base64 by encoding/json
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	}
+
+	// Interfaces can be any value
+	return &Schema{}
+}
+
+// component adds the struct schema to the components if it's not there and returns its name
+func (g *schemaGenerator) component(t reflect.Type) string {
+	name := t.Name()
+	if existingType, ok := g.componentTypes[name]; ok && existingType != t {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	if _, ok := g.componentTypes[name]; ok {
+		return name
+	}
+
+	// Added before generating the fields, so recursive types reference it
+	g.componentTypes[name] = t
+	g.components[name] = &Schema{}
+	*g.components[name] = *g.structSchema(t)
+
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		jsonTag, hasJSONTag := field.Tag.Lookup("json")
+		name := strings.Split(jsonTag, ",")[0]
+		binding := field.Tag.Get("binding")
+		if name == "-" || (!hasJSONTag && binding == "-") {
+			// Without a JSON name, the fields ignored by the validation are set by the API, like parsed dates
+			continue
+		}
+
+		if field.Anonymous && !hasJSONTag && field.Type.Kind() == reflect.Struct {
+			embedded := g.structSchema(field.Type)
+			for embeddedName, embeddedSchema := range embedded.Properties {
+				schema.Properties[embeddedName] = embeddedSchema
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := g.schemaOf(field.Type)
+		if applyBinding(fieldSchema, field.Type, binding) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = fieldSchema
+	}
+
+	return schema
+}
+
+// applyBinding adds the validator rules of the binding tag to the schema and returns whether the field is required.
+// The rules after dive apply to the items of slices.
+func applyBinding(schema *Schema, t reflect.Type, binding string) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+	rules := strings.Split(binding, ",")
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "dive":
+			if schema.Items != nil && schema.Items.Ref == "" {
+				applyBinding(schema.Items, t.Elem(), strings.Join(rules[i+1:], ","))
+			}
+			return required
+		case "min", "gte", "max", "lte", "len":
+			applyLimit(schema, t, name, param)
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "url", "http_url":
+			schema.Format = "uri"
+		case "email":
+			schema.Format = "email"
+		case "numeric":
+			schema.Pattern = "^[0-9]+$"
+		case "IsValidDate":
+			schema.Format = "date"
+		}
+	}
+
+	return required
+}
+
+// applyLimit sets the minimum or maximum of numbers, the length of strings, or the items of slices
+func applyLimit(schema *Schema, t reflect.Type, rule, param string) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	isMin := rule == "min" || rule == "gte" || rule == "len"
+	isMax := rule == "max" || rule == "lte" || rule == "len"
+
+	switch t.Kind() {
+	case reflect.String:
+		length := int(limit)
+		if isMin {
+			schema.MinLength = &length
+		}
+		if isMax {
+			schema.MaxLength = &length
+		}
+	case reflect.Map:
+		// The number of properties isn't documented
+	case reflect.Slice, reflect.Array:
+		length := int(limit)
+		if isMin {
+			schema.MinItems = &length
+		}
+		if isMax {
+			schema.MaxItems = &length
+		}
+	default:
+		if isMin {
+			schema.Minimum = &limit
+		}
+		if isMax {
+			schema.Maximum = &limit
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package api_keys
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeysRoutes registers the routes to manage the API keys, they need the write scope
+func APIKeysRoutes(group *gin.RouterGroup) {
+	group.Use(auth.RequireScope(auth.ScopeWrite))
+	{
+		group.GET("/get_all", GetAPIKeys)
+		group.POST("/create", CreateAPIKey)
+		group.POST("/delete", DeleteAPIKey)
+	}
+}
+
+func GetAPIKeys(c *gin.Context) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	apiKeys, err := auth.GetAPIKeys(configs, auth.GetUser(c))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": apiKeys})
+}
+
+type CreateAPIKeyRequest struct {
+	Name  string     `json:"name" binding:"required"`
+	Scope auth.Scope `json:"scope" binding:"required"`
+	// Name of the user that will own the key, the request user by default.
+	// Only admins can create keys of other users.
+	User string `json:"user"`
+}
+
+// CreateAPIKey creates an API key and responds with it.
+// It's the only time the key is shown, only its hash is stored.
+func CreateAPIKey(c *gin.Context) {
+	var requestData CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		apierror.Respond(c, apierror.Validation(err.Error()))
+		return
+	}
+	if !requestData.Scope.IsValid() {
+		apierror.Respond(c, apierror.Validation(`scope should be "read" or "write"`))
+		return
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	user, ok := getKeyUser(c, configs, requestData.User)
+	if !ok {
+		return
+	}
+
+	key, err := auth.CreateAPIKey(configs, user.ID, requestData.Name, requestData.Scope)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: api_keys.user_id, api_keys.name") {
+			apierror.Respond(c, apierror.Conflict("there is already an API key named "+requestData.Name))
+			return
+		}
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key created", "key": key})
+}
+
+type DeleteAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+	// Name of the user that owns the key, the request user by default.
+	// Only admins can delete keys of other users.
+	User string `json:"user"`
+}
+
+func DeleteAPIKey(c *gin.Context) {
+	var requestData DeleteAPIKeyRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		apierror.Respond(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	user, ok := getKeyUser(c, configs, requestData.User)
+	if !ok {
+		return
+	}
+
+	err = auth.DeleteAPIKey(configs, user.ID, requestData.Name)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key deleted"})
+}
+
+// getKeyUser returns the user with the name, or the request user if the name is empty.
+// On errors, it responds with the error and returns false.
+func getKeyUser(c *gin.Context, configs *util.Configs, name string) (*auth.User, bool) {
+	requestUser := auth.GetUser(c)
+	if name == "" || name == requestUser.Name {
+		return requestUser, true
+	}
+	if !requestUser.IsAdmin() {
+		apierror.Respond(c, apierror.Forbidden("only admins can manage the API keys of other users"))
+		return nil, false
+	}
+
+	user, err := auth.GetUserByName(configs, name)
+	if err != nil {
+		apierror.Respond(c, err)
+		return nil, false
+	}
+	if user == nil {
+		apierror.Respond(c, apierror.NotFound(fmt.Sprintf("user %s does not exist", name)))
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,162 @@
+package api_keys_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+func TestAuthentication(t *testing.T) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The default databases folder is the working directory, the package source folder
+	databaseConfigs := configs.Database
+	configs.Database.FolderPath = t.TempDir()
+	defer func() { configs.Database = databaseConfigs }()
+	authConfigs := configs.Auth
+	configs.Auth.Enabled = true
+	defer func() { configs.Auth = authConfigs }()
+
+	readKey, err := auth.CreateAPIKey(configs, database.DefaultUserID, "Test Read Key", auth.ScopeRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auth.DeleteAPIKey(configs, database.DefaultUserID, "Test Read Key")
+	writeKey, err := auth.CreateAPIKey(configs, database.DefaultUserID, "Test Write Key", auth.ScopeWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auth.DeleteAPIKey(configs, database.DefaultUserID, "Test Write Key")
+
+	router := api.SetupRouter()
+
+	testTable := []struct {
+		method             string
+		path               string
+		headers            map[string]string
+		expectedStatusCode int
+	}{
+		// Open route
+		{http.MethodGet, "/v1/health", nil, http.StatusOK},
+		// No key or invalid key
+		{http.MethodGet, "/v1/jobs/get_all", nil, http.StatusUnauthorized},
+		{http.MethodGet, "/v1/jobs/get_all", map[string]string{"Authorization": "Bearer dash_invalid"}, http.StatusUnauthorized},
+		// Read key
+		{http.MethodGet, "/v1/jobs/get_all", map[string]string{"Authorization": "Bearer " + readKey}, http.StatusOK},
+		{http.MethodGet, "/v1/jobs/get_all", map[string]string{"X-API-Key": readKey}, http.StatusOK},
+		{http.MethodDelete, "/v1/jobs/delete_all", map[string]string{"Authorization": "Bearer " + readKey}, http.StatusForbidden},
+		{http.MethodGet, "/v1/api_keys/get_all", map[string]string{"Authorization": "Bearer " + readKey}, http.StatusForbidden},
+		// Write key
+		{http.MethodDelete, "/v1/jobs/delete_all", map[string]string{"Authorization": "Bearer " + writeKey}, http.StatusOK},
+		{http.MethodGet, "/v1/api_keys/get_all", map[string]string{"Authorization": "bearer " + writeKey}, http.StatusOK},
+		// Key in the query string, only on the feeds routes
+		{http.MethodGet, "/v1/trackers/calendar.ics?api_key=" + readKey, nil, http.StatusOK},
+		{http.MethodGet, "/v1/trackers/activity.atom?api_key=" + readKey, nil, http.StatusOK},
+		{http.MethodGet, "/v1/trackers/calendar.ics?api_key=dash_invalid", nil, http.StatusUnauthorized},
+		{http.MethodGet, "/v1/jobs/get_all?api_key=" + readKey, nil, http.StatusUnauthorized},
+		// Cover thumbnails need a token signed for the entry instead of a key
+		{http.MethodGet, "/v1/trackers/cover_thumbnail?tracker=games&user_id=1&name=Missing&token=invalid", nil, http.StatusForbidden},
+	}
+
+	for _, test := range testTable {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(test.method, test.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for header, value := range test.headers {
+			req.Header.Set(header, value)
+		}
+		router.ServeHTTP(w, req)
+
+		if test.expectedStatusCode != w.Code {
+			t.Errorf("%s %s with %v: expected status code: %d, actual status code: %d, body: %s", test.method, test.path, test.headers, test.expectedStatusCode, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestAPIKeysRoutes(t *testing.T) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The default databases folder is the working directory, the package source folder
+	databaseConfigs := configs.Database
+	configs.Database.FolderPath = t.TempDir()
+	defer func() { configs.Database = databaseConfigs }()
+	authConfigs := configs.Auth
+	configs.Auth.Enabled = false
+	defer func() { configs.Auth = authConfigs }()
+
+	router := api.SetupRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/v1/api_keys/create", `{"name": "Test Routes Key", "scope": "read"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code: %d, actual status code: %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(created.Key, "dash_") {
+		t.Errorf("unexpected key: %s", created.Key)
+	}
+
+	w = serve(http.MethodPost, "/v1/api_keys/create", `{"name": "Test Routes Key", "scope": "read"}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status code: %d, actual status code: %d", http.StatusConflict, w.Code)
+	}
+	w = serve(http.MethodPost, "/v1/api_keys/create", `{"name": "Test Invalid Scope Key", "scope": "admin"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status code: %d, actual status code: %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = serve(http.MethodGet, "/v1/api_keys/get_all", "")
+	var res struct {
+		APIKeys []*auth.APIKey `json:"api_keys"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, apiKey := range res.APIKeys {
+		if apiKey.Name == "Test Routes Key" {
+			found = true
+			if apiKey.Scope != auth.ScopeRead || !strings.HasPrefix(created.Key, apiKey.Prefix) {
+				t.Errorf("unexpected API key: %+v", apiKey)
+			}
+		}
+	}
+	if !found {
+		t.Error("created API key not listed")
+	}
+	if strings.Contains(w.Body.String(), auth.HashKey(created.Key)) {
+		t.Error("the API keys hashes should not be listed")
+	}
+
+	w = serve(http.MethodPost, "/v1/api_keys/delete", `{"name": "Test Routes Key"}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code: %d, actual status code: %d", http.StatusOK, w.Code)
+	}
+	w = serve(http.MethodPost, "/v1/api_keys/delete", `{"name": "Test Routes Key"}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status code: %d, actual status code: %d", http.StatusNotFound, w.Code)
+	}
+}
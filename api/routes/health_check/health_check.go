@@ -1,15 +1,165 @@
 package health_check
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/scraping"
+	"github.com/diogovalentte/dashboard/api/util"
 	"github.com/gin-gonic/gin"
 )
 
+// Time each GeckoDriver instance has to answer its status endpoint
+const geckoDriverTimeout = 5 * time.Second
+
 func HealthCheckRoute(group *gin.RouterGroup) {
 	group.GET("/health", healthCheck)
+	group.GET("/health/live", liveCheck)
+	group.GET("/health/ready", readyCheck)
 }
 
 func healthCheck(c *gin.Context) {
 	c.String(http.StatusOK, "OK")
 }
+
+// liveCheck responds OK while the API is running, even if it can't handle requests yet
+func liveCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": statusOK})
+}
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+// A Check is the result of checking a dependency the API needs to handle requests
+type Check struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	// Why the check failed, or details about the dependency if it didn't
+	Message string `json:"message,omitempty"`
+}
+
+type ReadyResponse struct {
+	Status string  `json:"status"`
+	Checks []Check `json:"checks"`
+}
+
+// readyCheck runs all checks concurrently. It responds with 200 if all checks pass, 503 otherwise.
+func readyCheck(c *gin.Context) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, ReadyResponse{
+			Status: statusFail,
+			Checks: []Check{{Name: "configs", Status: statusFail, Message: err.Error()}},
+		})
+		return
+	}
+
+	checks := map[string]func() (string, error){
+		"database": func() (string, error) { return checkDatabase(configs) },
+		"firefox": func() (string, error) {
+			return configs.Firefox.BinaryPath, util.CheckExecutable(configs.Firefox.BinaryPath)
+		},
+		"disk_space": func() (string, error) { return checkDiskSpace(configs) },
+	}
+	pool := scraping.GetGeckoDriverPool()
+	if pool == nil {
+		checks["geckodriver"] = func() (string, error) {
+			return "", fmt.Errorf("the GeckoDriver pool was not created")
+		}
+	} else {
+		for _, instance := range pool.Instances() {
+			instance := instance
+			checks[fmt.Sprintf("geckodriver:%d", instance.Port)] = func() (string, error) {
+				return "", instance.Status(geckoDriverTimeout)
+			}
+		}
+	}
+
+	response := ReadyResponse{Status: statusOK, Checks: runChecks(checks)}
+	statusCode := http.StatusOK
+	for _, check := range response.Checks {
+		if check.Status != statusOK {
+			response.Status = statusFail
+			statusCode = http.StatusServiceUnavailable
+		}
+	}
+
+	c.JSON(statusCode, response)
+}
+
+// runChecks runs the checks concurrently and returns their results ordered by name
+func runChecks(checks map[string]func() (string, error)) []Check {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]Check, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+
+			start := time.Now()
+			message, err := checks[name]()
+			result := Check{
+				Name:      name,
+				Status:    statusOK,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+				Message:   message,
+			}
+			if err != nil {
+				result.Status, result.Message = statusFail, err.Error()
+			}
+			results[i] = result
+		}(i, name)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// checkDatabase checks whether the trackers database can be queried and has all migrations applied
+func checkDatabase(configs *util.Configs) (string, error) {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	version, err := database.GetVersion(db)
+	if err != nil {
+		return "", err
+	}
+	if version != len(database.TrackersMigrations) {
+		return "", fmt.Errorf("the database schema version is %d, expected %d", version, len(database.TrackersMigrations))
+	}
+
+	return fmt.Sprintf("schema version %d", version), nil
+}
+
+// checkDiskSpace checks whether the databases folder has at least the configured free disk space
+func checkDiskSpace(configs *util.Configs) (string, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(configs.Database.FolderPath, &stat); err != nil {
+		return "", err
+	}
+	freeMB := stat.Bavail * uint64(stat.Bsize) / (1024 * 1024)
+
+	message := fmt.Sprintf("%d MB free", freeMB)
+	if freeMB < uint64(configs.Health.MinFreeDiskMB) {
+		return "", fmt.Errorf("%s, expected at least %d MB", message, configs.Health.MinFreeDiskMB)
+	}
+
+	return message, nil
+}
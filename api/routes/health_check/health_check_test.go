@@ -0,0 +1,120 @@
+package health_check_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/health_check"
+	"github.com/diogovalentte/dashboard/api/scraping"
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+func TestLiveRoute(t *testing.T) {
+	router := api.SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/health/live", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code: %d, actual status code: %d", http.StatusOK, w.Code)
+	}
+	if w.Body.String() != `{"status":"ok"}` {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestReadyRoute(t *testing.T) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	minFreeDiskMB := configs.Health.MinFreeDiskMB
+	defer func() { configs.Health.MinFreeDiskMB = minFreeDiskMB }()
+
+	router := api.SetupRouter()
+	instances := scraping.GetGeckoDriverPool().Instances()
+	expectedNames := []string{"database", "disk_space", "firefox"}
+	for _, instance := range instances {
+		expectedNames = append(expectedNames, fmt.Sprintf("geckodriver:%d", instance.Port))
+	}
+
+	testTable := []struct {
+		minFreeDiskMB      int
+		expectedStatusCode int
+		expectedStatus     string
+		// Name of the only check expected to fail
+		expectedFailed string
+	}{
+		{0, http.StatusOK, "ok", ""},
+		{math.MaxInt32, http.StatusServiceUnavailable, "fail", "disk_space"},
+	}
+	for _, test := range testTable {
+		configs.Health.MinFreeDiskMB = test.minFreeDiskMB
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/v1/health/ready", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != test.expectedStatusCode {
+			t.Errorf("expected status code: %d, actual status code: %d", test.expectedStatusCode, w.Code)
+		}
+		var res health_check.ReadyResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Status != test.expectedStatus {
+			t.Errorf("expected status %s, got %s", test.expectedStatus, res.Status)
+		}
+		if len(res.Checks) != len(expectedNames) {
+			t.Fatalf("expected the checks %v, got %+v", expectedNames, res.Checks)
+		}
+		for i, check := range res.Checks {
+			if check.Name != expectedNames[i] {
+				t.Errorf("expected the check %s, got %s", expectedNames[i], check.Name)
+			}
+			expectedCheckStatus := "ok"
+			if check.Name == test.expectedFailed {
+				expectedCheckStatus = "fail"
+			}
+			if check.Status != expectedCheckStatus {
+				t.Errorf("expected the check %s to be %s, got %+v", check.Name, expectedCheckStatus, check)
+			}
+		}
+	}
+}
+
+func TestMain(m *testing.M) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	pool, err := scraping.NewGeckoDriverPool(configs.GeckoDriver.BinaryPath, 2)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	for _, instance := range pool.Instances() {
+		if err = instance.Wait(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	result := m.Run()
+
+	if err = pool.StopAll(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	os.Exit(result)
+}
@@ -3,6 +3,8 @@ package jobs
 import (
 	"net/http"
 
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
 	"github.com/diogovalentte/dashboard/api/job"
 	"github.com/gin-gonic/gin"
 )
@@ -17,16 +19,32 @@ func JobsRoutes(group *gin.RouterGroup) {
 func getAllJobs(c *gin.Context) {
 	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "couldn't get jobs"})
+		apierror.Respond(c, apierror.Internal("couldn't get jobs"))
+		return
 	}
-	c.JSON(http.StatusOK, gin.H{"jobs": jobsList.GetJobs()})
+
+	// Admins can see the jobs of all users
+	user := auth.GetUser(c)
+	if user.IsAdmin() {
+		c.JSON(http.StatusOK, gin.H{"jobs": jobsList.GetJobs()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": jobsList.GetUserJobs(user.ID)})
 }
 
 func deleteAllJobs(c *gin.Context) {
 	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "couldn't get jobs list to delete"})
+		apierror.Respond(c, apierror.Internal("couldn't get jobs list to delete"))
+		return
+	}
+
+	// Admins delete the jobs of all users
+	user := auth.GetUser(c)
+	if user.IsAdmin() {
+		jobsList.DeleteAllJobs()
+	} else {
+		jobsList.DeleteUserJobs(user.ID)
 	}
-	jobsList.DeleteAllJobs()
 	c.JSON(http.StatusOK, gin.H{"message": "Jobs deleted with success"})
 }
@@ -0,0 +1,29 @@
+package system
+
+import (
+	"net/http"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// GetConfigsStatus responds with the configs file, the last reload, and the changes
+// in the configs file that need a restart to have effect
+func GetConfigsStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"configs": util.GetConfigsStatus()})
+}
+
+// ReloadConfigs reloads the configs file, like when the file changes or the API receives SIGHUP
+func ReloadConfigs(c *gin.Context) {
+	changes, err := util.ReloadConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	if changes == nil {
+		changes = []util.ConfigChange{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "configs reloaded", "changes": changes, "configs": util.GetConfigsStatus()})
+}
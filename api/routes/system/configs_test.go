@@ -0,0 +1,43 @@
+package system_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+func TestConfigsRoutes(t *testing.T) {
+	router := api.SetupRouter()
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		path := "/v1/system/configs"
+		if method == http.MethodPost {
+			path = "/v1/system/reload_configs"
+		}
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status code: %d, actual status code: %d, body: %s", path, http.StatusOK, w.Code, w.Body.String())
+			continue
+		}
+		var res struct {
+			Configs util.ConfigsStatus `json:"configs"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Error(err)
+			continue
+		}
+		if res.Configs.LoadedAt == "" {
+			t.Errorf("%s: expected the configs load time, body: %s", path, w.Body.String())
+		}
+	}
+}
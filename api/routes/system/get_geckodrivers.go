@@ -1,21 +1,26 @@
 package system
 
 import (
+	"net/http"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
 	"github.com/diogovalentte/dashboard/api/scraping"
 	"github.com/gin-gonic/gin"
-	"net/http"
 )
 
 func SystemRoutes(group *gin.RouterGroup) {
 	{
 		group.GET("/get_geckodrivers", GetGeckoDriverInstances)
+		group.GET("/configs", auth.RequireAdmin(), GetConfigsStatus)
+		group.POST("/reload_configs", auth.RequireAdmin(), ReloadConfigs)
 	}
 }
 
 func GetGeckoDriverInstances(c *gin.Context) {
 	pool, err := scraping.NewGeckoDriverPool("", 0)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
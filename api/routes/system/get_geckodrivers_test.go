@@ -45,7 +45,7 @@ type getGeckoDrivers struct {
 }
 
 func setup() (*scraping.GeckoDriverPool, error) {
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+	configs, err := util.GetConfigs()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,585 @@
+package trackers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// The activities of the tracker entries in the activity feeds
+const (
+	ActivityAdded    = "added"
+	ActivityStarted  = "started"
+	ActivityFinished = "finished"
+	ActivityDropped  = "dropped"
+	ActivityRated    = "rated"
+)
+
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 500
+	// Max width of the cover thumbnails in the activity feeds, in pixels
+	coverThumbnailWidth = 160
+)
+
+// An ActivityItem is a change of a game or media in the activity feeds, from their status and stars histories
+type ActivityItem struct {
+	// Stable ID of the item, from its history row
+	ID string
+	// ID of the user that owns the entry
+	UserID int64
+	// games or medias
+	Tracker  string
+	Name     string
+	URL      string
+	Activity string
+	// Stars of the rated activity
+	Stars int
+	Date  time.Time
+}
+
+func (item *ActivityItem) title() string {
+	switch item.Activity {
+	case ActivityAdded:
+		return fmt.Sprintf("Added %s", item.Name)
+	case ActivityStarted:
+		return fmt.Sprintf("Started %s", item.Name)
+	case ActivityFinished:
+		return fmt.Sprintf("Finished %s", item.Name)
+	case ActivityDropped:
+		return fmt.Sprintf("Dropped %s", item.Name)
+	case ActivityRated:
+		return fmt.Sprintf("Rated %s %d/5 stars", item.Name, item.Stars)
+	default:
+		return item.Name
+	}
+}
+
+// description returns the HTML description of the item, with its cover thumbnail
+func (item *ActivityItem) description(trackerTitle, thumbnailURL string) string {
+	return fmt.Sprintf(
+		`<p><img src="%s" alt="%s" width="%d"/></p><p>%s, in the %s, on %s.</p>`,
+		html.EscapeString(thumbnailURL), html.EscapeString(item.Name), coverThumbnailWidth,
+		html.EscapeString(item.title()), trackerTitle, item.Date.UTC().Format("2006-01-02"),
+	)
+}
+
+// getStatusActivity returns the added, started, finished, and dropped activities of the tracker, newest first
+func getStatusActivity(db *sql.DB, tracker namedTracker, scope userScope, limit int) ([]*ActivityItem, error) {
+	userCondition, args := scope.condition("h.user_id")
+	rows, err := db.Query(fmt.Sprintf(`
+SELECT
+  h.id, h.user_id, h.%s, COALESCE(t.url, ''), h.from_status, h.to_status, h.changed_at
+FROM
+  %s h
+JOIN
+  %s t ON t.user_id = h.user_id AND t.name = h.%s
+WHERE
+  (h.from_status IS NULL OR h.to_status IN (?, ?, ?))
+  AND %s
+ORDER BY
+  h.changed_at DESC, h.id DESC
+LIMIT ?;`, tracker.tt.ownerColumn, tracker.tt.historyTable, tracker.tt.table, tracker.tt.ownerColumn, userCondition,
+	), append(append([]interface{}{StatusInProgress, StatusFinished, StatusDropped}, args...), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*ActivityItem{}
+	for rows.Next() {
+		item := ActivityItem{Tracker: tracker.name}
+		var id int64
+		var fromStatus *Status
+		var toStatus Status
+		if err = rows.Scan(&id, &item.UserID, &item.Name, &item.URL, &fromStatus, &toStatus, &item.Date); err != nil {
+			return nil, err
+		}
+		item.ID = fmt.Sprintf("urn:dashboard:activity:%s:status:%d", tracker.name, id)
+		switch {
+		case fromStatus == nil:
+			item.Activity = ActivityAdded
+		case toStatus == StatusInProgress:
+			item.Activity = ActivityStarted
+		case toStatus == StatusFinished:
+			item.Activity = ActivityFinished
+		default:
+			item.Activity = ActivityDropped
+		}
+		items = append(items, &item)
+	}
+
+	return items, rows.Err()
+}
+
+// getRatedActivity returns the rated activities of the tracker, newest first
+func getRatedActivity(db *sql.DB, tracker namedTracker, scope userScope, limit int) ([]*ActivityItem, error) {
+	userCondition, args := scope.condition("h.user_id")
+	rows, err := db.Query(fmt.Sprintf(`
+SELECT
+  h.id, h.user_id, h.%s, COALESCE(t.url, ''), h.to_stars, h.changed_at
+FROM
+  %s h
+JOIN
+  %s t ON t.user_id = h.user_id AND t.name = h.%s
+WHERE
+  h.to_stars > 0
+  AND %s
+ORDER BY
+  h.changed_at DESC, h.id DESC
+LIMIT ?;`, tracker.tt.ownerColumn, tracker.tt.starsHistoryTable, tracker.tt.table, tracker.tt.ownerColumn, userCondition,
+	), append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*ActivityItem{}
+	for rows.Next() {
+		item := ActivityItem{Tracker: tracker.name, Activity: ActivityRated}
+		var id int64
+		if err = rows.Scan(&id, &item.UserID, &item.Name, &item.URL, &item.Stars, &item.Date); err != nil {
+			return nil, err
+		}
+		item.ID = fmt.Sprintf("urn:dashboard:activity:%s:stars:%d", tracker.name, id)
+		items = append(items, &item)
+	}
+
+	return items, rows.Err()
+}
+
+// getActivity returns the latest activities of the trackers in the user scope, newest first
+func getActivity(scope userScope, trackerName string, limit int) ([]*ActivityItem, error) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		return nil, err
+	}
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	items := []*ActivityItem{}
+	for _, tracker := range namedTrackers {
+		if trackerName != "" && trackerName != tracker.name {
+			continue
+		}
+		statusItems, err := getStatusActivity(db, tracker, scope, limit)
+		if err != nil {
+			return nil, err
+		}
+		ratedItems, err := getRatedActivity(db, tracker, scope, limit)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, statusItems...)
+		items = append(items, ratedItems...)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Date.After(items[j].Date)
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+// activityFeed is the activity of a feed request and how to link to the API from the feed
+type activityFeed struct {
+	items   []*ActivityItem
+	scope   userScope
+	baseURL string
+	// Signs the cover thumbnail URLs, which don't need an API key
+	signer *auth.URLSigner
+}
+
+// getActivityFeed returns the activity of the feed request.
+// On errors, it responds with the error and returns false.
+func getActivityFeed(c *gin.Context) (*activityFeed, bool) {
+	scope, ok := getUserScope(c, false)
+	if !ok {
+		return nil, false
+	}
+
+	tracker := c.Query("tracker")
+	if tracker != "" && tracker != "games" && tracker != "medias" {
+		apierror.Respond(c, apierror.Validation(fmt.Sprintf("invalid tracker %q, it should be games or medias", tracker)))
+		return nil, false
+	}
+	limit := defaultActivityLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxActivityLimit {
+			apierror.Respond(c, apierror.Validation(fmt.Sprintf("invalid limit %q, it should be a number from 1 to %d", limitStr, maxActivityLimit)))
+			return nil, false
+		}
+	}
+
+	items, err := getActivity(scope, tracker, limit)
+	if err != nil {
+		apierror.Respond(c, err)
+		return nil, false
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return nil, false
+	}
+	signer, err := auth.GetURLSigner(configs)
+	if err != nil {
+		apierror.Respond(c, err)
+		return nil, false
+	}
+
+	return &activityFeed{items: items, scope: scope, baseURL: getRequestBaseURL(c), signer: signer}, true
+}
+
+// getRequestBaseURL returns the scheme and host of the request, like https://dashboard.local
+func getRequestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	} else if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, c.Request.Host)
+}
+
+// thumbnailURL returns the URL of the item cover thumbnail, with a token signed for the entry instead of the API key,
+// as the feed readers and their image proxies can save the URLs
+func (feed *activityFeed) thumbnailURL(item *ActivityItem) string {
+	query := url.Values{
+		"tracker": {item.Tracker},
+		"user_id": {strconv.FormatInt(item.UserID, 10)},
+		"name":    {item.Name},
+		"token":   {feed.signer.Sign(coverThumbnailMessage(item.Tracker, item.UserID, item.Name))},
+	}
+
+	return feed.baseURL + "/v1/trackers/cover_thumbnail?" + query.Encode()
+}
+
+// coverThumbnailMessage returns the message signed by the token of the cover thumbnail of the entry
+func coverThumbnailMessage(tracker string, userID int64, name string) string {
+	return fmt.Sprintf("cover_thumbnail\x00%s\x00%d\x00%s", tracker, userID, name)
+}
+
+func (feed *activityFeed) updated() time.Time {
+	if len(feed.items) == 0 {
+		return time.Now()
+	}
+
+	return feed.items[0].Date
+}
+
+func getTrackerTitle(name string) string {
+	for _, tracker := range namedTrackers {
+		if tracker.name == name {
+			return tracker.title
+		}
+	}
+
+	return name
+}
+
+const (
+	activityFeedTitle = "Dashboard Trackers Activity"
+	mediaRSSNamespace = "http://search.yahoo.com/mrss/"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	MediaNS string      `xml:"xmlns:media,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string         `xml:"title"`
+	ID        string         `xml:"id"`
+	Updated   string         `xml:"updated"`
+	Link      *atomLink      `xml:"link,omitempty"`
+	Category  atomCategory   `xml:"category"`
+	Content   atomContent    `xml:"content"`
+	Thumbnail mediaThumbnail `xml:"media:thumbnail"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type mediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+// GetActivityAtom responds with an Atom feed of the latest activities of the games and medias: added, started,
+// finished, dropped, and rated. Feed readers can subscribe to it with the API key in the api_key query parameter.
+//
+// Query parameters:
+// tracker - games or medias, both by default
+// limit - max number of items, 50 by default
+// user - name of the user of the feed, only admins can set it to other users
+func GetActivityAtom(c *gin.Context) {
+	feed, ok := getActivityFeed(c)
+	if !ok {
+		return
+	}
+
+	atom := atomFeed{
+		MediaNS: mediaRSSNamespace,
+		Title:   activityFeedTitle,
+		ID:      fmt.Sprintf("urn:dashboard:activity:user:%d", feed.scope.userID),
+		Updated: feed.updated().UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: auth.GetUser(c).Name},
+		Link:    atomLink{Href: feed.baseURL},
+		Entries: []atomEntry{},
+	}
+	for _, item := range feed.items {
+		thumbnailURL := feed.thumbnailURL(item)
+		entry := atomEntry{
+			Title:     item.title(),
+			ID:        item.ID,
+			Updated:   item.Date.UTC().Format(time.RFC3339),
+			Category:  atomCategory{Term: item.Activity},
+			Content:   atomContent{Type: "html", Body: item.description(getTrackerTitle(item.Tracker), thumbnailURL)},
+			Thumbnail: mediaThumbnail{URL: thumbnailURL},
+		}
+		if item.URL != "" {
+			entry.Link = &atomLink{Href: item.URL, Rel: "alternate"}
+		}
+		atom.Entries = append(atom.Entries, entry)
+	}
+
+	respondXML(c, "application/atom+xml; charset=utf-8", atom)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	MediaNS string     `xml:"xmlns:media,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link,omitempty"`
+	GUID        rssGUID        `xml:"guid"`
+	PubDate     string         `xml:"pubDate"`
+	Category    string         `xml:"category"`
+	Description string         `xml:"description"`
+	Thumbnail   mediaThumbnail `xml:"media:thumbnail"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+// GetActivityRSS responds with the activity feed of GetActivityAtom as RSS 2.0
+func GetActivityRSS(c *gin.Context) {
+	feed, ok := getActivityFeed(c)
+	if !ok {
+		return
+	}
+
+	rss := rssFeed{
+		Version: "2.0",
+		MediaNS: mediaRSSNamespace,
+		Channel: rssChannel{
+			Title:         activityFeedTitle,
+			Link:          feed.baseURL,
+			Description:   "Games and medias added, started, finished, dropped, and rated in the trackers",
+			LastBuildDate: feed.updated().UTC().Format(time.RFC1123Z),
+			Items:         []rssItem{},
+		},
+	}
+	for _, item := range feed.items {
+		thumbnailURL := feed.thumbnailURL(item)
+		rss.Channel.Items = append(rss.Channel.Items, rssItem{
+			Title:       item.title(),
+			Link:        item.URL,
+			GUID:        rssGUID{ID: item.ID},
+			PubDate:     item.Date.UTC().Format(time.RFC1123Z),
+			Category:    item.Activity,
+			Description: item.description(getTrackerTitle(item.Tracker), thumbnailURL),
+			Thumbnail:   mediaThumbnail{URL: thumbnailURL},
+		})
+	}
+
+	respondXML(c, "application/rss+xml; charset=utf-8", rss)
+}
+
+func respondXML(c *gin.Context, contentType string, v interface{}) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), body...))
+}
+
+// GetCoverThumbnail responds with a thumbnail of the cover image of a game or media, for the activity feeds.
+// It doesn't need an API key, but a token signed for the entry, from the thumbnail URLs of the feeds.
+// The covers that can't be decoded, or that are already small, are sent as they are.
+//
+// Query parameters:
+// tracker - games or medias
+// user_id - ID of the user that owns the entry
+// name - name of the entry
+// token - signature of the entry
+func GetCoverThumbnail(c *gin.Context) {
+	var tt trackerTable
+	trackerName := c.Query("tracker")
+	for _, tracker := range namedTrackers {
+		if tracker.name == trackerName {
+			tt = tracker.tt
+		}
+	}
+	if tt.table == "" {
+		apierror.Respond(c, apierror.Validation(fmt.Sprintf("invalid tracker %q, it should be games or medias", trackerName)))
+		return
+	}
+	userID, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+	if err != nil {
+		apierror.Respond(c, apierror.Validation(fmt.Sprintf("invalid user_id %q", c.Query("user_id"))))
+		return
+	}
+	name := c.Query("name")
+	if name == "" {
+		apierror.Respond(c, apierror.Validation("name is required"))
+		return
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	signer, err := auth.GetURLSigner(configs)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	if !signer.Verify(coverThumbnailMessage(trackerName, userID, name), c.Query("token")) {
+		apierror.Respond(c, apierror.Forbidden("invalid cover thumbnail token"))
+		return
+	}
+
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	defer db.Close()
+
+	var coverImg []byte
+	err = db.QueryRow(fmt.Sprintf("SELECT cover_img FROM %s WHERE user_id = ? AND name = ?;", tt.table), userID, name).Scan(&coverImg)
+	if err == sql.ErrNoRows || (err == nil && len(coverImg) == 0) {
+		apierror.Respond(c, apierror.NotFound(fmt.Sprintf("%s does not exist or has no cover image", name)))
+		return
+	} else if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	thumbnail, contentType := getCoverThumbnail(coverImg)
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Data(http.StatusOK, contentType, thumbnail)
+}
+
+// getCoverThumbnail returns the cover image scaled down to the thumbnail width as JPEG, and its content type
+func getCoverThumbnail(coverImg []byte) ([]byte, string) {
+	img, _, err := image.Decode(bytes.NewReader(coverImg))
+	if err != nil || img.Bounds().Dx() <= coverThumbnailWidth {
+		return coverImg, http.DetectContentType(coverImg)
+	}
+
+	thumbnail := scaleImage(img, coverThumbnailWidth)
+	var b bytes.Buffer
+	if err = jpeg.Encode(&b, thumbnail, &jpeg.Options{Quality: 85}); err != nil {
+		return coverImg, http.DetectContentType(coverImg)
+	}
+
+	return b.Bytes(), "image/jpeg"
+}
+
+// scaleImage scales the image down to the width, keeping its aspect ratio.
+// Each pixel is the average of the pixels of its area in the image.
+func scaleImage(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	height := srcHeight * width / srcWidth
+	if height < 1 {
+		height = 1
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcY0, srcY1 := y*srcHeight/height, max((y+1)*srcHeight/height, y*srcHeight/height+1)
+		for x := 0; x < width; x++ {
+			srcX0, srcX1 := x*srcWidth/width, max((x+1)*srcWidth/width, x*srcWidth/width+1)
+			var r, g, b, a, n uint64
+			for sy := srcY0; sy < srcY1; sy++ {
+				for sx := srcX0; sx < srcX1; sx++ {
+					pr, pg, pb, pa := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+			i := scaled.PixOffset(x, y)
+			scaled.Pix[i] = uint8(r / n >> 8)
+			scaled.Pix[i+1] = uint8(g / n >> 8)
+			scaled.Pix[i+2] = uint8(b / n >> 8)
+			scaled.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	return scaled
+}
@@ -0,0 +1,189 @@
+package trackers_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+)
+
+type testAtomFeed struct {
+	Entries []struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Link  struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Content   string `xml:"content"`
+		Thumbnail struct {
+			URL string `xml:"url,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"entry"`
+}
+
+type testRSSFeed struct {
+	Items []struct {
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+		GUID  string `xml:"guid"`
+	} `xml:"channel>item"`
+}
+
+func TestActivityFeeds(t *testing.T) {
+	cover := image.NewRGBA(image.Rect(0, 0, 320, 200))
+	for x := 0; x < 320; x++ {
+		for y := 0; y < 200; y++ {
+			cover.Set(x, y, color.RGBA{uint8(x), uint8(y), 100, 255})
+		}
+	}
+	var coverPNG bytes.Buffer
+	png.Encode(&coverPNG, cover)
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(coverPNG.Bytes())
+	}))
+	defer imageServer.Close()
+
+	router := api.SetupRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Host = "dashboard.local"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	entries := []struct{ path, deletePath, name, body string }{
+		{"/v1/trackers/games_tracker/add_game_manually", "/v1/trackers/games_tracker/delete_game", "Activity Test Game", `{"wait": true, "name": "Activity Test Game", "url": "https://example.com/activity-game", "cover_img_url": "%s", "priority": "high", "status": "finished", "stars": 4, "release_date": "2020-01-01"}`},
+		{"/v1/trackers/medias_tracker/add_media_manually", "/v1/trackers/medias_tracker/delete_media", "Activity Test Media", `{"wait": true, "name": "Activity Test Media", "url": "https://example.com/activity-media", "cover_img_url": "%s", "media_type": "movie", "priority": "low", "status": "in_progress", "release_date": "2020-01-01"}`},
+	}
+	for _, entry := range entries {
+		w := serve(http.MethodPost, entry.path, fmt.Sprintf(entry.body, imageServer.URL))
+		if w.Code != http.StatusOK {
+			t.Fatalf("couldn't add the entry: %s", w.Body.String())
+		}
+		defer serve(http.MethodPost, entry.deletePath, fmt.Sprintf(`{"name": %q}`, entry.name))
+	}
+	w := serve(http.MethodPost, "/v1/trackers/medias_tracker/update_media", `{"wait": true, "name": "Activity Test Media", "media_type": "movie", "priority": "low", "status": "dropped", "stars": 2, "release_date": "2020-01-01"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("couldn't update the media: %s", w.Body.String())
+	}
+
+	// The API key of the feed request isn't copied to the thumbnail URLs, they're signed instead
+	w = serve(http.MethodGet, "/v1/trackers/activity.atom?api_key=dash_activity_test_key", "")
+	if strings.Contains(w.Body.String(), "dash_activity_test_key") {
+		t.Errorf("expected no API key in the feed: %s", w.Body.String())
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code: %d, actual status code: %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/atom+xml; charset=utf-8" {
+		t.Errorf("unexpected content type: %s", contentType)
+	}
+	var atom testAtomFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &atom); err != nil {
+		t.Fatalf("invalid Atom feed: %s: %s", err, w.Body.String())
+	}
+	expectedTitles := map[string]string{
+		"Added Activity Test Game":            "https://example.com/activity-game",
+		"Rated Activity Test Game 4/5 stars":  "https://example.com/activity-game",
+		"Added Activity Test Media":           "https://example.com/activity-media",
+		"Dropped Activity Test Media":         "https://example.com/activity-media",
+		"Rated Activity Test Media 2/5 stars": "https://example.com/activity-media",
+	}
+	var thumbnailURL string
+	for _, entry := range atom.Entries {
+		link, ok := expectedTitles[entry.Title]
+		if !ok {
+			continue
+		}
+		delete(expectedTitles, entry.Title)
+		if entry.Link.Href != link || entry.ID == "" {
+			t.Errorf("%s: unexpected link %q or ID %q", entry.Title, entry.Link.Href, entry.ID)
+		}
+		if !strings.Contains(entry.Content, `<img src="`) || !strings.HasPrefix(entry.Thumbnail.URL, "http://dashboard.local/v1/trackers/cover_thumbnail?") {
+			t.Errorf("%s: expected the cover thumbnail, got %q: %s", entry.Title, entry.Thumbnail.URL, entry.Content)
+		}
+		thumbnailURL = entry.Thumbnail.URL
+	}
+	if len(expectedTitles) > 0 {
+		t.Errorf("expected the items %v in the feed: %s", expectedTitles, w.Body.String())
+	}
+
+	thumbnail, err := url.Parse(thumbnailURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = serve(http.MethodGet, thumbnail.RequestURI(), "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("expected a JPEG thumbnail, got %d %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if w.Header().Get("Cache-Control") == "" {
+		t.Errorf("expected the thumbnail to be cached")
+	}
+	thumbnailImg, err := jpeg.Decode(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := thumbnailImg.Bounds().Size(); size.X != 160 || size.Y != 100 {
+		t.Errorf("expected a 160x100 thumbnail, got %v", size)
+	}
+
+	// The RSS feed has the same items, with the same IDs after the feed is read again
+	w = serve(http.MethodGet, "/v1/trackers/activity.rss?tracker=medias", "")
+	if contentType := w.Header().Get("Content-Type"); w.Code != http.StatusOK || contentType != "application/rss+xml; charset=utf-8" {
+		t.Fatalf("expected the RSS feed, got %d %s: %s", w.Code, contentType, w.Body.String())
+	}
+	var rss testRSSFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &rss); err != nil {
+		t.Fatalf("invalid RSS feed: %s: %s", err, w.Body.String())
+	}
+	atomIDs := map[string]string{}
+	for _, entry := range atom.Entries {
+		atomIDs[entry.Title] = entry.ID
+	}
+	var hasDropped bool
+	for _, item := range rss.Items {
+		if strings.Contains(item.Title, "Activity Test Game") {
+			t.Errorf("expected only medias in the feed, got %s", item.Title)
+		}
+		if item.Title == "Dropped Activity Test Media" {
+			hasDropped = true
+			if item.GUID != atomIDs[item.Title] || item.Link != "https://example.com/activity-media" {
+				t.Errorf("unexpected item: %+v", item)
+			}
+		}
+	}
+	if !hasDropped {
+		t.Errorf("expected Dropped Activity Test Media in the feed: %s", w.Body.String())
+	}
+
+	for _, path := range []string{"/v1/trackers/activity.atom?limit=0", "/v1/trackers/activity.rss?tracker=books", "/v1/trackers/cover_thumbnail?tracker=games", "/v1/trackers/cover_thumbnail?tracker=games&user_id=1"} {
+		if w := serve(http.MethodGet, path, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code: %d, actual status code: %d", path, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	// The token is only valid for its entry
+	query := thumbnail.Query()
+	for param, value := range map[string]string{"name": "Missing Activity Test Game", "user_id": "2", "tracker": "medias", "token": ""} {
+		changedQuery := url.Values{}
+		for key, values := range query {
+			changedQuery[key] = values
+		}
+		changedQuery.Set(param, value)
+		path := thumbnail.Path + "?" + changedQuery.Encode()
+		if w := serve(http.MethodGet, path, ""); w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected status code: %d, actual status code: %d", path, http.StatusForbidden, w.Code)
+		}
+	}
+}
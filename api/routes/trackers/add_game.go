@@ -4,18 +4,18 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
-	"path/filepath"
 	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
-
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
 	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
+	"github.com/diogovalentte/dashboard/api/metrics"
 	"github.com/diogovalentte/dashboard/api/scraping"
 	"github.com/diogovalentte/dashboard/api/util"
 	"github.com/gin-gonic/gin"
-	"github.com/gin-gonic/gin/binding"
-	"github.com/go-playground/validator/v10"
 	"github.com/tebeka/selenium"
 )
 
@@ -24,46 +24,45 @@ func AddGame(c *gin.Context) {
 	currentJob := job.Job{
 		Task:      "Add game to Games Tracker database",
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
 	}
 
 	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "couldn't create the task's job"})
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
 		return
 	}
 	jobsList.AddJob(&currentJob)
 	currentJob.SetStartingState("Processing game request")
 
 	// Validate request
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		err := v.RegisterValidation("IsValidDate", IsValidDate)
-		if err != nil {
-			currentJob.SetFailedState(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-			return
-		}
-	}
-
 	var gameRequest AddGameRequest
 	if err := c.ShouldBindJSON(&gameRequest); err != nil {
-		err = fmt.Errorf("invalid JSON fields, refer to the API documentation")
-		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		bindingErr := getBindingError(err, &gameRequest)
+		currentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
 		return
 	}
 
 	err := SetStructDateFields(&gameRequest)
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 
 	// Get game info from a web store and insert into DB
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+	configs, err := util.GetConfigs()
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
+		return
+	}
+
+	// Don't scrape games already in the tracker
+	if err := respondDuplicateGame(c, configs, gameRequest.URL); err != nil {
+		currentJob.SetFailedState(err)
 		return
 	}
 
@@ -77,8 +76,8 @@ func AddGame(c *gin.Context) {
 type AddGameRequest struct {
 	Wait                   bool      `json:"wait" binding:"-"` // Whether the requester wants to wait for the task to be done before responding
 	URL                    string    `json:"url" binding:"required,http_url"`
-	Priority               int       `json:"priority" binding:"required"`
-	Status                 int       `json:"status" binding:"required"`
+	Priority               Priority  `json:"priority" binding:"required,IsValidEnum"`
+	Status                 Status    `json:"status" binding:"required,IsValidEnum"`
 	PurchasedGamePass      bool      `json:"purchased_or_gamepass" binding:"-"`
 	Stars                  int       `json:"stars" binding:"omitempty,gte=0,lte=5"`
 	StartedDateStr         string    `json:"started_date" binding:"omitempty,IsValidDate"`
@@ -117,12 +116,13 @@ func addGameTask(currentJob *job.Job, context *gin.Context, configs *util.Config
 	if err != nil {
 		currentJob.SetFailedState(err)
 		if context != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			apierror.Respond(context, err)
 		}
 		return
 	}
 	defer wd.Close()
 	defer geckodriver.Release()
+	currentJob.Logger().Info("scraping game", "url", gameRequest.URL, "geckodriver_port", geckodriver.Port)
 
 	// Scrap game metadata
 	currentJob.SetExecutingState("Scraping game data")
@@ -130,7 +130,7 @@ func addGameTask(currentJob *job.Job, context *gin.Context, configs *util.Config
 	if err != nil {
 		currentJob.SetFailedState(err)
 		if context != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			apierror.Respond(context, err)
 		}
 		return
 	}
@@ -140,18 +140,18 @@ func addGameTask(currentJob *job.Job, context *gin.Context, configs *util.Config
 	if err != nil {
 		currentJob.SetFailedState(err)
 		if context != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			apierror.Respond(context, err)
 		}
 		return
 	}
 
 	// Insert game into DB
 	currentJob.SetExecutingStateWithValue("Adding game to DB", scrapedGameProperties.Name)
-	err = insertGameIntoDB(gameProperties)
+	err = insertGameIntoDB(currentJob.UserID, gameProperties)
 	if err != nil {
 		currentJob.SetFailedState(err)
 		if context != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			apierror.Respond(context, getInsertError(err))
 		}
 		return
 	}
@@ -163,15 +163,33 @@ func addGameTask(currentJob *job.Job, context *gin.Context, configs *util.Config
 	}
 }
 
+// GetGameMetadata scrapes the game metadata, the errors are validation errors for invalid URLs and upstream scrape errors otherwise
 func GetGameMetadata(gameURL string, wd *selenium.WebDriver) (*ScrapedGameProperties, error) {
+	scrape := metrics.NewScrape("steam")
+	properties, err := scrapeGameMetadata(gameURL, wd, scrape)
+	scrape.Done(err)
+	if err != nil {
+		if scrape.Field == "url" {
+			return nil, apierror.Validation(err.Error())
+		}
+		return nil, apierror.UpstreamScrape(err.Error())
+	}
+
+	return properties, nil
+}
+
+// scrapeGameMetadata scrapes the game metadata, setting the field being scraped in the scrape metrics
+func scrapeGameMetadata(gameURL string, wd *selenium.WebDriver, scrape *metrics.Scrape) (*ScrapedGameProperties, error) {
 	// Get game metadata from a web store (Steam)
 	gameURL = strings.SplitN(gameURL, "?", 2)[0]
 	steamPrefix := "https://store.steampowered.com/app/"
 	if isSteamURL := strings.HasPrefix(gameURL, steamPrefix); !isSteamURL {
+		scrape.Field = "url"
 		return nil, fmt.Errorf("the game url %s is not a valid Steam url, it should start with: %s", gameURL, steamPrefix)
 	}
 
 	// Get the game properties
+	scrape.Field = "page"
 	if err := (*wd).Get(gameURL); err != nil {
 		return nil, fmt.Errorf("could not get the page with URL: %s. Error: %s", gameURL, err)
 	}
@@ -215,6 +233,7 @@ func GetGameMetadata(gameURL string, wd *selenium.WebDriver) (*ScrapedGameProper
 	}
 
 	// Name
+	scrape.Field = "name"
 	gameNameElem, err := (*wd).FindElement(selenium.ByXPATH, "//div[@id='appHubAppName']")
 	if err != nil {
 		return nil, fmt.Errorf("couldn't find an element in the page: %s", err)
@@ -225,6 +244,7 @@ func GetGameMetadata(gameURL string, wd *selenium.WebDriver) (*ScrapedGameProper
 	}
 
 	// Cover URL
+	scrape.Field = "cover_url"
 	coverURLElem, err := (*wd).FindElement(selenium.ByXPATH, "//img[@class='game_header_image_full']")
 	if err != nil {
 		return nil, fmt.Errorf("couldn't find an element in the page: %s", err)
@@ -235,6 +255,7 @@ func GetGameMetadata(gameURL string, wd *selenium.WebDriver) (*ScrapedGameProper
 	}
 
 	// Release date
+	scrape.Field = "release_date"
 	var releaseDate time.Time
 	var secondErr error
 	releaseDateElem, err := (*wd).FindElement(selenium.ByXPATH, "//div[@class='release_date']/div[@class='date']")
@@ -264,6 +285,7 @@ func GetGameMetadata(gameURL string, wd *selenium.WebDriver) (*ScrapedGameProper
 	}
 
 	// Tags
+	scrape.Field = "tags"
 	var tags []string
 	tagsElems, err := (*wd).FindElements(selenium.ByXPATH, "//div[contains(@class, 'glance_tags popular_tags')]/a")
 	if err != nil {
@@ -275,6 +297,7 @@ func GetGameMetadata(gameURL string, wd *selenium.WebDriver) (*ScrapedGameProper
 	}
 
 	// Developers
+	scrape.Field = "developers"
 	var developers []string
 	developersElems, err := (*wd).FindElements(selenium.ByXPATH, "//div[@class='dev_row']/div[contains(@class, 'subtitle')][text()='Developer:']/../div[@class='summary column']/a")
 	if err != nil {
@@ -286,6 +309,7 @@ func GetGameMetadata(gameURL string, wd *selenium.WebDriver) (*ScrapedGameProper
 	}
 
 	// Publishers
+	scrape.Field = "publishers"
 	var publishers []string
 	publishersElems, err := (*wd).FindElements(selenium.ByXPATH, "//div[@class='dev_row']/div[contains(@class, 'subtitle')][text()='Publisher:']/../div[@class='summary column']/a")
 	if err != nil {
@@ -372,18 +396,14 @@ func getGameProperties(gr *AddGameRequest, sgp *ScrapedGameProperties) (*GamePro
 		return nil, err
 	}
 
-	tags := strings.Join(sgp.Tags, ",")
-	developers := strings.Join(sgp.Developers, ",")
-	publishers := strings.Join(sgp.Publishers, ",")
-
 	return &GameProperties{
 		Name:                sgp.Name,
 		URL:                 gr.URL,
 		CoverImg:            coverImg,
 		ReleaseDate:         sgp.ReleaseDate,
-		TagsStr:             tags,
-		DevelopersStr:       developers,
-		PublishersStr:       publishers,
+		Tags:                sgp.Tags,
+		Developers:          sgp.Developers,
+		Publishers:          sgp.Publishers,
 		Priority:            gr.Priority,
 		Status:              gr.Status,
 		Stars:               gr.Stars,
@@ -395,21 +415,18 @@ func getGameProperties(gr *AddGameRequest, sgp *ScrapedGameProperties) (*GamePro
 }
 
 type GameProperties struct {
-	Wait                   bool   `json:"wait" binding:"-"` // Whether the requester wants to wait for the task to be done before responding
-	URL                    string `json:"url" binding:"required"`
-	Priority               int    `json:"priority" binding:"required"`
-	Status                 int    `json:"status" binding:"required"`
-	Stars                  int    `json:"stars" binding:"omitempty,gte=0,lte=5"`
-	PurchasedOrGamePass    bool   `json:"purchased_or_gamepass" binding:"-"`
-	Name                   string `json:"name" binding:"required"`
-	CoverImgURL            string `json:"cover_img_url" binding:"required"`
+	Wait                   bool     `json:"wait" binding:"-"` // Whether the requester wants to wait for the task to be done before responding
+	URL                    string   `json:"url" binding:"required"`
+	Priority               Priority `json:"priority" binding:"required,IsValidEnum"`
+	Status                 Status   `json:"status" binding:"required,IsValidEnum"`
+	Stars                  int      `json:"stars" binding:"omitempty,gte=0,lte=5"`
+	PurchasedOrGamePass    bool     `json:"purchased_or_gamepass" binding:"-"`
+	Name                   string   `json:"name" binding:"required"`
+	CoverImgURL            string   `json:"cover_img_url" binding:"required"`
 	CoverImg               []byte
-	Tags                   []string `json:"tags" binding:"-"`
-	TagsStr                string
-	Developers             []string `json:"developers" binding:"-"`
-	DevelopersStr          string
-	Publishers             []string `json:"publishers" binding:"-"`
-	PublishersStr          string
+	Tags                   []string  `json:"tags" binding:"-"`
+	Developers             []string  `json:"developers" binding:"-"`
+	Publishers             []string  `json:"publishers" binding:"-"`
 	ReleaseDateStr         string    `json:"release_date" binding:"omitempty,IsValidDate"`
 	ReleaseDate            time.Time `binding:"-"`
 	StartedDateStr         string    `json:"started_date" binding:"omitempty,IsValidDate"`
@@ -443,26 +460,41 @@ func (gr *GameProperties) SetReleaseDate(releaseDate time.Time) {
 	gr.ReleaseDate = releaseDate
 }
 
-func insertGameIntoDB(gp *GameProperties) error {
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+func insertGameIntoDB(userID int64, gp *GameProperties) error {
+	configs, err := util.GetConfigs()
 	if err != nil {
 		return err
 	}
-	dbPath := filepath.Join(configs.Database.FolderPath, "trackers.db")
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := database.OpenTrackersDB(configs)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	stm, err := db.Prepare(`
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Check the name, external ID, and URL, so duplicates get a clear error instead of a constraint error
+	externalID := getGameExternalID(gp.URL)
+	existingName, err := findDuplicateName(tx, gamesTrackerTable, userID, gp.Name, externalID, gp.URL)
+	if err != nil {
+		return err
+	}
+	if existingName != "" {
+		return &DuplicateEntryError{Tracker: "Games Tracker", Name: existingName}
+	}
+
+	stm, err := tx.Prepare(`
 INSERT INTO games_tracker (
-  url, name, cover_img, release_date, tags, developers, publishers, priority,
-  status, stars, purchased_or_gamepass, started_date, finished_dropped_date, commentary
+  user_id, url, name, cover_img, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, commentary, external_id
 )
 VALUES (
-  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 )
   `)
 	if err != nil {
@@ -470,14 +502,20 @@ VALUES (
 	}
 	defer stm.Close()
 
+	// Set the dates of the initial status
+	now := time.Now()
+	stampStatusDates(0, gp.Status, &gp.StartedDate, &gp.FinishedDroppedDate, now)
+	err = checkEntryRules(gp.Status, gp.Stars, gp.StartedDate, gp.FinishedDroppedDate, gp.ReleaseDate)
+	if err != nil {
+		return err
+	}
+
 	_, err = stm.Exec(
+		userID,
 		gp.URL,
 		gp.Name,
 		gp.CoverImg,
 		gp.ReleaseDate,
-		gp.TagsStr,
-		gp.DevelopersStr,
-		gp.PublishersStr,
 		gp.Priority,
 		gp.Status,
 		gp.Stars,
@@ -485,11 +523,45 @@ VALUES (
 		gp.StartedDate,
 		gp.FinishedDroppedDate,
 		gp.Commentary,
+		nullableString(externalID),
 	)
 	if err != nil {
 		return err
 	}
 
+	err = setGameEntities(tx, userID, gp.Name, gp.Tags, gp.Developers, gp.Publishers)
+	if err != nil {
+		return err
+	}
+
+	err = recordStatusChange(tx, gamesTrackerTable, userID, gp.Name, 0, gp.Status, now)
+	if err != nil {
+		return err
+	}
+	if gp.Stars > 0 {
+		err = recordStarsChange(tx, gamesTrackerTable, userID, gp.Name, 0, gp.Stars, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func setGameEntities(tx *sql.Tx, userID int64, gameName string, tags, developers, publishers []string) error {
+	err := setEntities(tx, gamesTagsTable, userID, gameName, tags)
+	if err != nil {
+		return err
+	}
+	err = setEntities(tx, gamesDevelopersTable, userID, gameName, developers)
+	if err != nil {
+		return err
+	}
+	err = setEntities(tx, gamesPublishersTable, userID, gameName, publishers)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -498,38 +570,31 @@ func AddGameManually(c *gin.Context) {
 	currentJob := job.Job{
 		Task:      "Add game to Games Tracker database",
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
 	}
 
 	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "couldn't create the task's job"})
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
 		return
 	}
 	jobsList.AddJob(&currentJob)
 	currentJob.SetStartingState("Processing game request")
 
 	// Validate request
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		err := v.RegisterValidation("IsValidDate", IsValidDate)
-		if err != nil {
-			currentJob.SetFailedState(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-			return
-		}
-	}
-
 	var gameProperties GameProperties
 	if err := c.ShouldBindJSON(&gameProperties); err != nil {
-		err = fmt.Errorf("invalid JSON fields, refer to the API documentation: %s", err.Error())
-		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		bindingErr := getBindingError(err, &gameProperties)
+		currentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
 		return
 	}
 
 	err := SetStructDateFields(&gameProperties)
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -537,20 +602,16 @@ func AddGameManually(c *gin.Context) {
 	coverImg, err := util.GetImageFromURL(gameProperties.CoverImgURL)
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 	gameProperties.CoverImg = coverImg
 
-	gameProperties.TagsStr = strings.Join(gameProperties.Tags, ",")
-	gameProperties.DevelopersStr = strings.Join(gameProperties.Developers, ",")
-	gameProperties.PublishersStr = strings.Join(gameProperties.Publishers, ",")
-
 	// Insert game into DB
 	currentJob.SetExecutingStateWithValue("Adding game to DB", gameProperties.Name)
 	if !gameProperties.Wait {
 		go func(currentJob *job.Job, gameProperties *GameProperties) {
-			err := insertGameIntoDB(gameProperties)
+			err := insertGameIntoDB(currentJob.UserID, gameProperties)
 			if err != nil {
 				currentJob.SetFailedState(err)
 				return
@@ -559,10 +620,10 @@ func AddGameManually(c *gin.Context) {
 			currentJob.SetCompletedState("Game added to DB")
 		}(&currentJob, &gameProperties)
 	} else {
-		err = insertGameIntoDB(&gameProperties)
+		err = insertGameIntoDB(currentJob.UserID, &gameProperties)
 		if err != nil {
 			currentJob.SetFailedState(err)
-			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			apierror.Respond(c, getInsertError(err))
 			return
 		}
 
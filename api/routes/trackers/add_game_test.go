@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
 	"github.com/diogovalentte/dashboard/api/routes/trackers"
 	"github.com/diogovalentte/dashboard/api/scraping"
 	"net/http"
@@ -26,7 +28,7 @@ func TestGetGameMetadata(t *testing.T) {
 	}
 
 	// Get game metadata
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+	configs, err := util.GetConfigs()
 	if err != nil {
 		t.Error(err)
 		return
@@ -59,7 +61,7 @@ var addGameRouteTestTable = []*trackers.AddGameRequest{
 		Wait:                   true,
 		URL:                    "https://store.steampowered.com/app/105600/Terraria/",
 		Priority:               3,
-		Status:                 3,
+		Status:                 4,
 		PurchasedGamePass:      false,
 		Stars:                  3,
 		StartedDateStr:         "2023-01-01",
@@ -81,7 +83,7 @@ var addGameRouteTestTable = []*trackers.AddGameRequest{
 		Wait:                   true,
 		URL:                    "https://store.steampowered.com/app/1282100/Remnant_II/",
 		Priority:               2,
-		Status:                 3,
+		Status:                 4,
 		PurchasedGamePass:      false,
 		Stars:                  5,
 		StartedDateStr:         "2023-07-29",
@@ -125,7 +127,7 @@ func TestAddGameRoute(t *testing.T) {
 		}
 
 		if http.StatusOK != w.Code {
-			if actualMessage == "UNIQUE constraint failed: games_tracker.name" {
+			if w.Code == http.StatusConflict {
 				t.Log("Game already in database")
 				continue
 			} else {
@@ -151,7 +153,7 @@ var addGameManuallyRouteTestTable = []*trackers.GameProperties{
 		Wait:                   true,
 		URL:                    "https://store.steampowered.com/app/892970/Valheim/",
 		Priority:               3,
-		Status:                 3,
+		Status:                 4,
 		Stars:                  3,
 		PurchasedOrGamePass:    false,
 		Name:                   "Valheim",
@@ -213,7 +215,7 @@ func TestAddGameManuallyRoute(t *testing.T) {
 		}
 
 		if http.StatusOK != w.Code {
-			if actualMessage == "UNIQUE constraint failed: games_tracker.name" {
+			if w.Code == http.StatusConflict {
 				t.Log("Game already in database")
 				continue
 			} else {
@@ -233,3 +235,42 @@ func TestAddGameManuallyRoute(t *testing.T) {
 		}
 	}
 }
+
+func TestAddGameJobRequestID(t *testing.T) {
+	router := api.SetupRouter()
+
+	// The job is created even if the request is invalid
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/v1/trackers/games_tracker/add_game_manually", bytes.NewBufferString("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(logging.RequestIDHeader, "add-game-job-request-id")
+	router.ServeHTTP(w, req)
+	if w.Header().Get(logging.RequestIDHeader) != "add-game-job-request-id" {
+		t.Errorf("unexpected request ID header: %q", w.Header().Get(logging.RequestIDHeader))
+	}
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/v1/jobs/get_all", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(w, req)
+
+	var res struct {
+		Jobs []*job.Job `json:"jobs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	for _, currentJob := range res.Jobs {
+		if currentJob.RequestID == "add-game-job-request-id" {
+			if currentJob.State != "Failed" {
+				t.Errorf("expected the job to fail, state: %s", currentJob.State)
+			}
+			return
+		}
+	}
+	t.Error("no job with the request ID")
+}
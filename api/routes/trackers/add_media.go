@@ -4,16 +4,18 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
-	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
 	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
+	"github.com/diogovalentte/dashboard/api/metrics"
 	"github.com/diogovalentte/dashboard/api/scraping"
 	"github.com/diogovalentte/dashboard/api/util"
 	"github.com/gin-gonic/gin"
-	"github.com/gin-gonic/gin/binding"
-	"github.com/go-playground/validator/v10"
 	"github.com/tebeka/selenium"
 )
 
@@ -22,46 +24,45 @@ func AddMedia(c *gin.Context) {
 	currentJob := job.Job{
 		Task:      "Add media to Medias Tracker database",
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
 	}
 
 	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "couldn't create the task's job"})
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
 		return
 	}
 	jobsList.AddJob(&currentJob)
 	currentJob.SetStartingState("Processing media request")
 
 	// Validate request
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		err := v.RegisterValidation("IsValidDate", IsValidDate)
-		if err != nil {
-			currentJob.SetFailedState(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-			return
-		}
-	}
-
 	var mediaRequest AddMediaRequest
 	if err := c.ShouldBindJSON(&mediaRequest); err != nil {
-		err = fmt.Errorf("invalid JSON fields, refer to the API documentation")
-		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		bindingErr := getBindingError(err, &mediaRequest)
+		currentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
 		return
 	}
 
 	err := SetStructDateFields(&mediaRequest)
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 
 	// Get media info from a medias site and insert into DB
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+	configs, err := util.GetConfigs()
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
+		return
+	}
+
+	// Don't scrape medias already in the tracker
+	if err := respondDuplicateMedia(c, configs, mediaRequest.URL); err != nil {
+		currentJob.SetFailedState(err)
 		return
 	}
 
@@ -75,9 +76,9 @@ func AddMedia(c *gin.Context) {
 type AddMediaRequest struct {
 	Wait                   bool      `json:"wait" binding:"-"` // Wether the requester wants to wait for the task to be done before responding
 	URL                    string    `json:"url" binding:"required,http_url"`
-	MediaType              int       `json:"type" binding:"required"`
-	Priority               int       `json:"priority" binding:"required"`
-	Status                 int       `json:"status" binding:"required"`
+	MediaType              MediaType `json:"type" binding:"required,IsValidEnum"`
+	Priority               Priority  `json:"priority" binding:"required,IsValidEnum"`
+	Status                 Status    `json:"status" binding:"required,IsValidEnum"`
 	Stars                  int       `json:"stars" binding:"omitempty,gte=0,lte=5"`
 	StartedDateStr         string    `json:"started_date" binding:"omitempty,IsValidDate"`
 	StartedDate            time.Time `binding:"-"`
@@ -115,12 +116,13 @@ func addMediaTask(currentJob *job.Job, context *gin.Context, configs *util.Confi
 	if err != nil {
 		currentJob.SetFailedState(err)
 		if context != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			apierror.Respond(context, err)
 		}
 		return
 	}
 	defer wd.Close()
 	defer geckodriver.Release()
+	currentJob.Logger().Info("scraping media", "url", mediaRequest.URL, "geckodriver_port", geckodriver.Port)
 
 	// Scrap media metadata
 	currentJob.SetExecutingState("Scraping media data")
@@ -128,7 +130,7 @@ func addMediaTask(currentJob *job.Job, context *gin.Context, configs *util.Confi
 	if err != nil {
 		currentJob.SetFailedState(err)
 		if context != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			apierror.Respond(context, err)
 		}
 		return
 	}
@@ -138,18 +140,18 @@ func addMediaTask(currentJob *job.Job, context *gin.Context, configs *util.Confi
 	if err != nil {
 		currentJob.SetFailedState(err)
 		if context != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			apierror.Respond(context, err)
 		}
 		return
 	}
 
 	// Insert media into DB
 	currentJob.SetExecutingStateWithValue("Adding media to DB", scrapedMediaProperties.Name)
-	err = insertMediaIntoDB(mediaProperties)
+	err = insertMediaIntoDB(currentJob.UserID, mediaProperties)
 	if err != nil {
 		currentJob.SetFailedState(err)
 		if context != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			apierror.Respond(context, getInsertError(err))
 		}
 		return
 	}
@@ -161,15 +163,33 @@ func addMediaTask(currentJob *job.Job, context *gin.Context, configs *util.Confi
 	}
 }
 
+// GetMediaMetadata scrapes the media metadata, the errors are validation errors for invalid URLs and upstream scrape errors otherwise
 func GetMediaMetadata(mediaURL string, wd *selenium.WebDriver) (*ScrapedMediaProperties, error) {
+	scrape := metrics.NewScrape("imdb")
+	properties, err := scrapeMediaMetadata(mediaURL, wd, scrape)
+	scrape.Done(err)
+	if err != nil {
+		if scrape.Field == "url" {
+			return nil, apierror.Validation(err.Error())
+		}
+		return nil, apierror.UpstreamScrape(err.Error())
+	}
+
+	return properties, nil
+}
+
+// scrapeMediaMetadata scrapes the media metadata, setting the field being scraped in the scrape metrics
+func scrapeMediaMetadata(mediaURL string, wd *selenium.WebDriver, scrape *metrics.Scrape) (*ScrapedMediaProperties, error) {
 	// Get media metadata from a media site (IMDB)
 	mediaURL = strings.SplitN(mediaURL, "?", 2)[0]
 	IMDBPrefix := "https://www.imdb.com/title/"
 	if isIMDB_URL := strings.HasPrefix(mediaURL, IMDBPrefix); !isIMDB_URL {
+		scrape.Field = "url"
 		return nil, fmt.Errorf("the media url %s is not a valid IMDB url, it should start with: %s", mediaURL, IMDBPrefix)
 	}
 
 	// Get the media properties
+	scrape.Field = "page"
 	if err := (*wd).Get(mediaURL); err != nil {
 		return nil, fmt.Errorf("could not get the page with URL: %s. Error: %s", mediaURL, err)
 	}
@@ -181,6 +201,7 @@ func GetMediaMetadata(mediaURL string, wd *selenium.WebDriver) (*ScrapedMediaPro
 	}
 
 	// Name
+	scrape.Field = "name"
 	mediaNameElem, err := (*wd).FindElement(selenium.ByXPATH, "//h1[@data-testid='hero__pageTitle']")
 	if err != nil {
 		return nil, fmt.Errorf("couldn't find an element in the page: %s", err)
@@ -191,6 +212,7 @@ func GetMediaMetadata(mediaURL string, wd *selenium.WebDriver) (*ScrapedMediaPro
 	}
 
 	// Cover URL
+	scrape.Field = "cover_url"
 	coverURLElem, err := (*wd).FindElement(selenium.ByXPATH, "//*[contains(@class, 'ipc-media--poster-l')]//img[@class='ipc-image']")
 	if err != nil {
 		return nil, fmt.Errorf("couldn't find an element in the page: %s", err)
@@ -201,6 +223,7 @@ func GetMediaMetadata(mediaURL string, wd *selenium.WebDriver) (*ScrapedMediaPro
 	}
 
 	// Release date
+	scrape.Field = "release_date"
 	releaseDateElem, err := (*wd).FindElement(selenium.ByXPATH, "//a[text()='Release date']/..//ul/li/a")
 	if err != nil {
 		return nil, fmt.Errorf("couldn't find an element in the page: %s", err)
@@ -218,6 +241,7 @@ func GetMediaMetadata(mediaURL string, wd *selenium.WebDriver) (*ScrapedMediaPro
 	}
 
 	// Genres
+	scrape.Field = "genres"
 	genreElems, err := (*wd).FindElements(selenium.ByXPATH, "(//div[@class='ipc-chip-list__scroller'])[1]/a")
 	if err != nil {
 		return nil, fmt.Errorf("couldn't find an element in the page: %s", err)
@@ -228,6 +252,7 @@ func GetMediaMetadata(mediaURL string, wd *selenium.WebDriver) (*ScrapedMediaPro
 	}
 
 	// Staff
+	scrape.Field = "staff"
 	staffElems, err := (*wd).FindElements(selenium.ByXPATH, "(//ul[@class='ipc-metadata-list ipc-metadata-list--dividers-all title-pc-list ipc-metadata-list--baseAlt'])[1]/li//li/a")
 	if err != nil {
 		return nil, fmt.Errorf("couldn't find an element in the page: %s", err)
@@ -271,17 +296,14 @@ func getMediaProperties(mr *AddMediaRequest, smp *ScrapedMediaProperties) (*Medi
 		return nil, err
 	}
 
-	genres := strings.Join(smp.Genres, ",")
-	staff := strings.Join(smp.Staff, ",")
-
 	return &MediaProperties{
 		Name:                smp.Name,
 		URL:                 mr.URL,
 		MediaType:           mr.MediaType,
 		CoverImg:            coverImg,
 		ReleaseDate:         smp.ReleaseDate,
-		GenresStr:           genres,
-		StaffStr:            staff,
+		Genres:              smp.Genres,
+		Staff:               smp.Staff,
 		Priority:            mr.Priority,
 		Status:              mr.Status,
 		Stars:               mr.Stars,
@@ -292,19 +314,17 @@ func getMediaProperties(mr *AddMediaRequest, smp *ScrapedMediaProperties) (*Medi
 }
 
 type MediaProperties struct {
-	Wait                   bool   `json:"wait" binding:"-"` // Whether the requester wants to wait for the task to be done before responding
-	URL                    string `json:"url" binding:"required"`
-	Priority               int    `json:"priority" binding:"required"`
-	Status                 int    `json:"status" binding:"required"`
-	Stars                  int    `json:"stars" binding:"omitempty,gte=0,lte=5"`
-	MediaType              int    `json:"media_type" binding:"required"`
-	Name                   string `json:"name" binding:"required"`
-	CoverImgURL            string `json:"cover_img_url" binding:"required"`
+	Wait                   bool      `json:"wait" binding:"-"` // Whether the requester wants to wait for the task to be done before responding
+	URL                    string    `json:"url" binding:"required"`
+	Priority               Priority  `json:"priority" binding:"required,IsValidEnum"`
+	Status                 Status    `json:"status" binding:"required,IsValidEnum"`
+	Stars                  int       `json:"stars" binding:"omitempty,gte=0,lte=5"`
+	MediaType              MediaType `json:"media_type" binding:"required,IsValidEnum"`
+	Name                   string    `json:"name" binding:"required"`
+	CoverImgURL            string    `json:"cover_img_url" binding:"required"`
 	CoverImg               []byte
-	Genres                 []string `json:"genres" binding:"-"`
-	GenresStr              string
-	Staff                  []string `json:"staff" binding:"-"`
-	StaffStr               string
+	Genres                 []string  `json:"genres" binding:"-"`
+	Staff                  []string  `json:"staff" binding:"-"`
 	ReleaseDateStr         string    `json:"release_date" binding:"omitempty,IsValidDate"`
 	ReleaseDate            time.Time `binding:"-"`
 	StartedDateStr         string    `json:"started_date" binding:"omitempty,IsValidDate"`
@@ -338,23 +358,38 @@ func (gr *MediaProperties) SetReleaseDate(releaseDate time.Time) {
 	gr.ReleaseDate = releaseDate
 }
 
-func insertMediaIntoDB(mp *MediaProperties) error {
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+func insertMediaIntoDB(userID int64, mp *MediaProperties) error {
+	configs, err := util.GetConfigs()
 	if err != nil {
 		return err
 	}
-	dbPath := filepath.Join(configs.Database.FolderPath, "trackers.db")
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := database.OpenTrackersDB(configs)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	stm, err := db.Prepare(`
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Check the name, external ID, and URL, so duplicates get a clear error instead of a constraint error
+	externalID := getMediaExternalID(mp.URL)
+	existingName, err := findDuplicateName(tx, mediasTrackerTable, userID, mp.Name, externalID, mp.URL)
+	if err != nil {
+		return err
+	}
+	if existingName != "" {
+		return &DuplicateEntryError{Tracker: "Medias Tracker", Name: existingName}
+	}
+
+	stm, err := tx.Prepare(`
 INSERT INTO medias_tracker (
-  url, name, media_type, cover_img, release_date, genres, staff, priority,
-  status, stars, started_date, finished_dropped_date, commentary
+  user_id, url, name, media_type, cover_img, release_date, priority,
+  status, stars, started_date, finished_dropped_date, commentary, external_id
 )
 VALUES (
   ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
@@ -365,25 +400,62 @@ VALUES (
 	}
 	defer stm.Close()
 
+	// Set the dates of the initial status
+	now := time.Now()
+	stampStatusDates(0, mp.Status, &mp.StartedDate, &mp.FinishedDroppedDate, now)
+	err = checkEntryRules(mp.Status, mp.Stars, mp.StartedDate, mp.FinishedDroppedDate, mp.ReleaseDate)
+	if err != nil {
+		return err
+	}
+
 	_, err = stm.Exec(
+		userID,
 		mp.URL,
 		mp.Name,
 		mp.MediaType,
 		mp.CoverImg,
 		mp.ReleaseDate,
-		mp.GenresStr,
-		mp.StaffStr,
 		mp.Priority,
 		mp.Status,
 		mp.Stars,
 		mp.StartedDate,
 		mp.FinishedDroppedDate,
 		mp.Commentary,
+		nullableString(externalID),
 	)
 	if err != nil {
 		return err
 	}
 
+	err = setMediaEntities(tx, userID, mp.Name, mp.Genres, mp.Staff)
+	if err != nil {
+		return err
+	}
+
+	err = recordStatusChange(tx, mediasTrackerTable, userID, mp.Name, 0, mp.Status, now)
+	if err != nil {
+		return err
+	}
+	if mp.Stars > 0 {
+		err = recordStarsChange(tx, mediasTrackerTable, userID, mp.Name, 0, mp.Stars, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func setMediaEntities(tx *sql.Tx, userID int64, mediaName string, genres, staff []string) error {
+	err := setEntities(tx, mediasGenresTable, userID, mediaName, genres)
+	if err != nil {
+		return err
+	}
+	err = setEntities(tx, mediasStaffTable, userID, mediaName, staff)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -392,38 +464,31 @@ func AddMediaManually(c *gin.Context) {
 	currentJob := job.Job{
 		Task:      "Add media to Meidas Tracker database",
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
 	}
 
 	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "couldn't create the task's job"})
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
 		return
 	}
 	jobsList.AddJob(&currentJob)
 	currentJob.SetStartingState("Processing media request")
 
 	// Validate request
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		err := v.RegisterValidation("IsValidDate", IsValidDate)
-		if err != nil {
-			currentJob.SetFailedState(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-			return
-		}
-	}
-
 	var mediaProperties MediaProperties
 	if err := c.ShouldBindJSON(&mediaProperties); err != nil {
-		err = fmt.Errorf("invalid JSON fields, refer to the API documentation: %s", err.Error())
-		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		bindingErr := getBindingError(err, &mediaProperties)
+		currentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
 		return
 	}
 
 	err := SetStructDateFields(&mediaProperties)
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -431,19 +496,16 @@ func AddMediaManually(c *gin.Context) {
 	coverImg, err := util.GetImageFromURL(mediaProperties.CoverImgURL)
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 	mediaProperties.CoverImg = coverImg
 
-	mediaProperties.GenresStr = strings.Join(mediaProperties.Genres, ",")
-	mediaProperties.StaffStr = strings.Join(mediaProperties.Staff, ",")
-
 	// Insert media into DB
 	currentJob.SetExecutingStateWithValue("Adding media to DB", mediaProperties.Name)
 	if !mediaProperties.Wait {
 		go func(currentJob *job.Job, mediaProperties *MediaProperties) {
-			err := insertMediaIntoDB(mediaProperties)
+			err := insertMediaIntoDB(currentJob.UserID, mediaProperties)
 			if err != nil {
 				currentJob.SetFailedState(err)
 				return
@@ -452,10 +514,10 @@ func AddMediaManually(c *gin.Context) {
 			currentJob.SetCompletedState("Media added to DB")
 		}(&currentJob, &mediaProperties)
 	} else {
-		err = insertMediaIntoDB(&mediaProperties)
+		err = insertMediaIntoDB(currentJob.UserID, &mediaProperties)
 		if err != nil {
 			currentJob.SetFailedState(err)
-			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			apierror.Respond(c, getInsertError(err))
 			return
 		}
 
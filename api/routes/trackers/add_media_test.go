@@ -25,7 +25,7 @@ func TestGetMediaMetadata(t *testing.T) {
 	}
 
 	// Get media metadata
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+	configs, err := util.GetConfigs()
 	if err != nil {
 		t.Error(err)
 		return
@@ -66,7 +66,7 @@ var addMediaRouteTestTable = []*trackers.AddMediaRequest{
 		URL:                    "https://www.imdb.com/title/tt1586680",
 		MediaType:              1,
 		Priority:               2,
-		Status:                 3,
+		Status:                 4,
 		Stars:                  5,
 		StartedDateStr:         "2022-12-01",
 		FinishedDroppedDateStr: "2023-01-05",
@@ -77,7 +77,7 @@ var addMediaRouteTestTable = []*trackers.AddMediaRequest{
 		URL:                    "https://www.imdb.com/title/tt0468569/?ref_=chttp_t_3",
 		MediaType:              2,
 		Priority:               2,
-		Status:                 3,
+		Status:                 4,
 		Stars:                  5,
 		StartedDateStr:         "2023-07-29",
 		FinishedDroppedDateStr: "2023-08-12",
@@ -120,7 +120,7 @@ func TestAddMediaRoute(t *testing.T) {
 		}
 
 		if http.StatusOK != w.Code {
-			if actualMessage == "UNIQUE constraint failed: medias_tracker.name" {
+			if w.Code == http.StatusConflict {
 				t.Log("Media already in database")
 				continue
 			} else {
@@ -163,7 +163,7 @@ var addMediaManuallyRouteTestTable = []*trackers.MediaProperties{
 		URL:                    "https://www.imdb.com/title/tt1517268/",
 		MediaType:              1,
 		Priority:               2,
-		Status:                 3,
+		Status:                 4,
 		Stars:                  3,
 		Name:                   "Barbie",
 		CoverImgURL:            "https://m.media-amazon.com/images/M/MV5BNjU3N2QxNzYtMjk1NC00MTc4LTk1NTQtMmUxNTljM2I0NDA5XkEyXkFqcGdeQXVyODE5NzE3OTE@._V1_QL75_UX190_CR0,0,190,281_.jpg",
@@ -208,7 +208,7 @@ func TestAddMediaManuallyRoute(t *testing.T) {
 		}
 
 		if http.StatusOK != w.Code {
-			if actualMessage == "UNIQUE constraint failed: medias_tracker.name" {
+			if w.Code == http.StatusConflict {
 				t.Log("Media already in database")
 				continue
 			} else {
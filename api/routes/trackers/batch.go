@@ -0,0 +1,242 @@
+package trackers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
+	"github.com/diogovalentte/dashboard/api/scraping"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+type AddGamesRequest struct {
+	Wait  bool              `json:"wait" binding:"-"` // Whether the requester wants to wait for the task to be done before responding
+	Games []*AddGameRequest `json:"games" binding:"required,min=1,dive"`
+}
+
+type AddMediasRequest struct {
+	Wait   bool               `json:"wait" binding:"-"` // Whether the requester wants to wait for the task to be done before responding
+	Medias []*AddMediaRequest `json:"medias" binding:"required,min=1,dive"`
+}
+
+// A BatchItemResult is whether an item of a batch was added, with the error message if not
+type BatchItemResult struct {
+	URL       string `json:"url"`
+	Succeeded bool   `json:"succeeded"`
+	// The entry name when it succeeded, else the error message
+	Message string `json:"message"`
+}
+
+// AddGames scrapes and adds many games, using as many GeckoDriver instances as the pool has.
+// The batch job has a child job for each game, a game that fails doesn't stop the others.
+func AddGames(c *gin.Context) {
+	parentJob, ok := createBatchJob(c, "Add games to Games Tracker database")
+	if !ok {
+		return
+	}
+
+	var gamesRequest AddGamesRequest
+	if err := c.ShouldBindJSON(&gamesRequest); err != nil {
+		bindingErr := getBindingError(err, &gamesRequest)
+		parentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
+		return
+	}
+	for i, gameRequest := range gamesRequest.Games {
+		err := SetStructDateFields(gameRequest)
+		if err != nil {
+			err = getListItemError(err, "games", i)
+			parentJob.SetFailedState(err)
+			apierror.Respond(c, err)
+			return
+		}
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		parentJob.SetFailedState(err)
+		apierror.Respond(c, err)
+		return
+	}
+
+	urls := make([]string, 0, len(gamesRequest.Games))
+	for _, gameRequest := range gamesRequest.Games {
+		urls = append(urls, gameRequest.URL)
+	}
+	task := func(i int, childJob *job.Job) {
+		// Don't take a GeckoDriver instance for games already in the tracker
+		existingGame, err := getDuplicateGame(configs, childJob.UserID, gamesRequest.Games[i].URL)
+		if err != nil {
+			childJob.SetFailedState(err)
+			return
+		}
+		if existingGame != nil {
+			childJob.SetFailedState(&DuplicateEntryError{Tracker: "Games Tracker", Name: existingGame.Name})
+			return
+		}
+
+		addGameTask(childJob, nil, configs, gamesRequest.Games[i])
+	}
+
+	if !gamesRequest.Wait {
+		go runBatch(parentJob, "Add game to Games Tracker database", "games", urls, task)
+		c.JSON(http.StatusOK, gin.H{"message": "Job created with success"})
+	} else {
+		results := runBatch(parentJob, "Add game to Games Tracker database", "games", urls, task)
+		c.JSON(http.StatusOK, gin.H{"message": parentJob.StateDescription, "results": results})
+	}
+}
+
+// AddMedias scrapes and adds many medias, using as many GeckoDriver instances as the pool has.
+// The batch job has a child job for each media, a media that fails doesn't stop the others.
+func AddMedias(c *gin.Context) {
+	parentJob, ok := createBatchJob(c, "Add medias to Medias Tracker database")
+	if !ok {
+		return
+	}
+
+	var mediasRequest AddMediasRequest
+	if err := c.ShouldBindJSON(&mediasRequest); err != nil {
+		bindingErr := getBindingError(err, &mediasRequest)
+		parentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
+		return
+	}
+	for i, mediaRequest := range mediasRequest.Medias {
+		err := SetStructDateFields(mediaRequest)
+		if err != nil {
+			err = getListItemError(err, "medias", i)
+			parentJob.SetFailedState(err)
+			apierror.Respond(c, err)
+			return
+		}
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		parentJob.SetFailedState(err)
+		apierror.Respond(c, err)
+		return
+	}
+
+	urls := make([]string, 0, len(mediasRequest.Medias))
+	for _, mediaRequest := range mediasRequest.Medias {
+		urls = append(urls, mediaRequest.URL)
+	}
+	task := func(i int, childJob *job.Job) {
+		// Don't take a GeckoDriver instance for medias already in the tracker
+		existingMedia, err := getDuplicateMedia(configs, childJob.UserID, mediasRequest.Medias[i].URL)
+		if err != nil {
+			childJob.SetFailedState(err)
+			return
+		}
+		if existingMedia != nil {
+			childJob.SetFailedState(&DuplicateEntryError{Tracker: "Medias Tracker", Name: existingMedia.Name})
+			return
+		}
+
+		addMediaTask(childJob, nil, configs, mediasRequest.Medias[i])
+	}
+
+	if !mediasRequest.Wait {
+		go runBatch(parentJob, "Add media to Medias Tracker database", "medias", urls, task)
+		c.JSON(http.StatusOK, gin.H{"message": "Job created with success"})
+	} else {
+		results := runBatch(parentJob, "Add media to Medias Tracker database", "medias", urls, task)
+		c.JSON(http.StatusOK, gin.H{"message": parentJob.StateDescription, "results": results})
+	}
+}
+
+// createBatchJob adds the parent job of a batch to the jobs list.
+// It responds to the request and returns false if it fails.
+func createBatchJob(c *gin.Context, task string) (*job.Job, bool) {
+	parentJob := job.Job{
+		Task:      task,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
+	}
+
+	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
+	if !ok {
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
+		return nil, false
+	}
+	jobsList.AddJob(&parentJob)
+	parentJob.SetStartingState("Processing batch request")
+
+	return &parentJob, true
+}
+
+// runBatch runs the task of each URL with its own child job, at most the GeckoDriver pool size at a time.
+// The parent job shows the progress of the children, and fails only if all of them fail.
+func runBatch(parentJob *job.Job, childTask, entriesName string, urls []string, task func(i int, childJob *job.Job)) []*BatchItemResult {
+	childJobs := make([]*job.Job, len(urls))
+	for i, url := range urls {
+		childJobs[i] = &job.Job{
+			Task:      childTask,
+			CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		}
+		parentJob.AddChild(childJobs[i])
+		childJobs[i].SetStartingStateWithValue("Waiting for a GeckoDriver instance", url)
+	}
+
+	workers := scraping.GetGeckoDriverPoolSize()
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(urls) {
+		workers = len(urls)
+	}
+	parentJob.SetExecutingState(fmt.Sprintf("Adding %d %s with %d GeckoDriver instances", len(urls), entriesName, workers))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				task(i, childJobs[i])
+
+				completed, failed, total := parentJob.GetChildrenProgress()
+				parentJob.SetExecutingStateWithValue(
+					fmt.Sprintf("%d of %d %s done, %d failed", completed+failed, total, entriesName, failed),
+					urls[i],
+				)
+			}
+		}()
+	}
+	for i := range urls {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	results := make([]*BatchItemResult, len(urls))
+	for i, childJob := range childJobs {
+		results[i] = &BatchItemResult{
+			URL:       urls[i],
+			Succeeded: childJob.State == "Completed",
+			Message:   childJob.StateDescription,
+		}
+		if results[i].Succeeded {
+			results[i].Message = childJob.Value
+		}
+	}
+
+	completed, failed, total := parentJob.GetChildrenProgress()
+	if completed == 0 {
+		parentJob.SetFailedState(fmt.Errorf("couldn't add any of the %d %s", total, entriesName))
+	} else {
+		parentJob.SetCompletedStateWithValue(fmt.Sprintf("%d of %d %s added, %d failed", completed, total, entriesName, failed), "")
+	}
+
+	return results
+}
@@ -0,0 +1,75 @@
+package trackers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+)
+
+func TestAddGamesRoute(t *testing.T) {
+	router := api.SetupRouter()
+
+	gamesRequest := trackers.AddGamesRequest{
+		Wait: true,
+		Games: []*trackers.AddGameRequest{
+			{URL: "https://store.steampowered.com/app/105600/Terraria/", Priority: trackers.PriorityLow, Status: trackers.StatusInProgress},
+			{URL: "https://store.steampowered.com/app/0/Not_A_Game/", Priority: trackers.PriorityHigh, Status: trackers.StatusNotStarted},
+		},
+	}
+	requestBody, err := json.Marshal(gamesRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/v1/trackers/games_tracker/add_games", bytes.NewBuffer(requestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(w, req)
+
+	if http.StatusOK != w.Code {
+		t.Fatalf("expected status code: %d, actual status code: %d", http.StatusOK, w.Code)
+	}
+
+	var res addBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results) != len(gamesRequest.Games) {
+		t.Fatalf("expected %d results, actual results: %d", len(gamesRequest.Games), len(res.Results))
+	}
+	if first := res.Results[0]; !first.Succeeded && !strings.Contains(first.Message, "is already in the") {
+		t.Errorf("expected %s to be added, error: %s", first.URL, first.Message)
+	}
+	if second := res.Results[1]; second.Succeeded {
+		t.Errorf("expected %s to fail without stopping the batch", second.URL)
+	}
+	t.Log(res.Message)
+}
+
+func TestAddGamesRouteEmptyBatch(t *testing.T) {
+	router := api.SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/v1/trackers/games_tracker/add_games", strings.NewReader(`{"wait": true, "games": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(w, req)
+
+	if http.StatusBadRequest != w.Code {
+		t.Errorf("expected status code: %d, actual status code: %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+type addBatchResponse struct {
+	Message string                      `json:"message"`
+	Results []*trackers.BatchItemResult `json:"results"`
+}
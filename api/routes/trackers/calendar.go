@@ -0,0 +1,223 @@
+package trackers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// A CalendarEvent is an all-day event of the trackers calendar, like the release of a game
+type CalendarEvent struct {
+	// Stable ID of the event, the calendar apps replace the event with the same UID when the entry is updated
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+// calendarEntry are the values of a game or media used in the calendar
+type calendarEntry struct {
+	// games or medias
+	tracker             string
+	trackerTitle        string
+	userID              int64
+	name                string
+	url                 string
+	priority            Priority
+	status              Status
+	releaseDate         time.Time
+	startedDate         time.Time
+	finishedDroppedDate time.Time
+}
+
+// getCalendarEvents returns the events of the entry: the release if the entry is to be released,
+// and its started and finished or dropped dates if includeStartedFinished is true.
+// GetCalendar sorts the events of all entries by date.
+func (entry *calendarEntry) getCalendarEvents(includeStartedFinished bool) []*CalendarEvent {
+	nameHash := sha1.Sum([]byte(entry.name))
+	uidPrefix := fmt.Sprintf("%s-%d-%s", entry.tracker, entry.userID, hex.EncodeToString(nameHash[:]))
+	description := fmt.Sprintf("%s, %s priority.", entry.trackerTitle, entry.priority)
+	newEvent := func(kind string, date time.Time, summary string) *CalendarEvent {
+		return &CalendarEvent{
+			UID:         fmt.Sprintf("%s-%s@dashboard", uidPrefix, kind),
+			Date:        date,
+			Summary:     summary,
+			Description: description,
+			URL:         entry.url,
+		}
+	}
+
+	var events []*CalendarEvent
+	if entry.status == StatusToBeReleased && !entry.releaseDate.IsZero() {
+		events = append(events, newEvent("release", entry.releaseDate, fmt.Sprintf("%s is released", entry.name)))
+	}
+	if !includeStartedFinished {
+		return events
+	}
+	if !entry.startedDate.IsZero() && entry.status >= StatusInProgress {
+		events = append(events, newEvent("started", entry.startedDate, fmt.Sprintf("Started %s", entry.name)))
+	}
+	if !entry.finishedDroppedDate.IsZero() {
+		switch entry.status {
+		case StatusFinished:
+			events = append(events, newEvent("finished", entry.finishedDroppedDate, fmt.Sprintf("Finished %s", entry.name)))
+		case StatusDropped:
+			events = append(events, newEvent("dropped", entry.finishedDroppedDate, fmt.Sprintf("Dropped %s", entry.name)))
+		}
+	}
+
+	return events
+}
+
+// getCalendarEntries returns the games and medias of the user scope that match the request filters
+func getCalendarEntries(c *gin.Context, scope userScope, includeStartedFinished bool) ([]*calendarEntry, error) {
+	var conditions []string
+	var args []interface{}
+	if !includeStartedFinished {
+		conditions = append(conditions, "status = ?")
+		args = append(args, StatusToBeReleased)
+	}
+	priorityCondition, priorityArgs, err := getEnumFilterCondition(c, "priority", "priority", priorityNames, "priority")
+	if err != nil {
+		return nil, err
+	}
+	if priorityCondition != "" {
+		conditions = append(conditions, priorityCondition)
+		args = append(args, priorityArgs...)
+	}
+
+	query, err := getEntriesQuery(c, scope, conditions, args, false)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*calendarEntry{}
+	err = query.forEach(
+		func(game *GetGameProperties) error {
+			entries = append(entries, &calendarEntry{
+				tracker: "games", trackerTitle: "Games Tracker", userID: game.UserID, name: game.Name, url: game.URL,
+				priority: game.Priority, status: game.Status, releaseDate: game.ReleaseDate,
+				startedDate: game.StartedDate, finishedDroppedDate: game.FinishedDroppedDate,
+			})
+			return nil
+		},
+		func(media *GetMediaProperties) error {
+			entries = append(entries, &calendarEntry{
+				tracker: "medias", trackerTitle: "Medias Tracker", userID: media.UserID, name: media.Name, url: media.URL,
+				priority: media.Priority, status: media.Status, releaseDate: media.ReleaseDate,
+				startedDate: media.StartedDate, finishedDroppedDate: media.FinishedDroppedDate,
+			})
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetCalendar responds with an iCalendar (RFC 5545) feed of the to be released games and medias with a release date,
+// as all-day events on their release dates. Calendar apps can subscribe to it with the API key in the api_key query parameter.
+//
+// Query parameters:
+// tracker - games or medias, both by default
+// priority - priority name or number, can be repeated
+// tag, developer, publisher, genre, staff - entity name, can be repeated
+// include_started_finished - true to also include the started, finished, and dropped dates of the entries
+// user - name of the user of the calendar, only admins can set it to other users
+func GetCalendar(c *gin.Context) {
+	scope, ok := getUserScope(c, false)
+	if !ok {
+		return
+	}
+	includeStartedFinished := c.Query("include_started_finished") == "true"
+
+	entries, err := getCalendarEntries(c, scope, includeStartedFinished)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	var events []*CalendarEvent
+	for _, entry := range entries {
+		events = append(events, entry.getCalendarEvents(includeStartedFinished)...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Date.Before(events[j].Date)
+	})
+
+	c.Header("Content-Disposition", `inline; filename="trackers.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(writeCalendar(events, time.Now())))
+}
+
+// writeCalendar returns the iCalendar of the events, with the lines folded and ended with CRLF as in RFC 5545
+func writeCalendar(events []*CalendarEvent, now time.Time) string {
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldCalendarLine(line))
+		b.WriteString("\r\n")
+	}
+
+	dtstamp := now.UTC().Format("20060102T150405Z")
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Dashboard//Trackers Calendar//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:Dashboard Trackers")
+	for _, event := range events {
+		date := event.Date.UTC()
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + event.UID)
+		writeLine("DTSTAMP:" + dtstamp)
+		writeLine("DTSTART;VALUE=DATE:" + date.Format("20060102"))
+		writeLine("DTEND;VALUE=DATE:" + date.AddDate(0, 0, 1).Format("20060102"))
+		writeLine("SUMMARY:" + escapeCalendarText(event.Summary))
+		writeLine("DESCRIPTION:" + escapeCalendarText(event.Description))
+		if event.URL != "" {
+			writeLine("URL:" + event.URL)
+		}
+		writeLine("TRANSP:TRANSPARENT")
+		writeLine("END:VEVENT")
+	}
+	writeLine("END:VCALENDAR")
+
+	return b.String()
+}
+
+var calendarTextReplacer = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeCalendarText escapes a TEXT value of the iCalendar
+func escapeCalendarText(s string) string {
+	return calendarTextReplacer.Replace(s)
+}
+
+// foldCalendarLine splits the line in lines of at most 75 octets, the continuation lines start with a space.
+// The lines are split between UTF-8 characters.
+func foldCalendarLine(line string) string {
+	const maxOctets = 75
+
+	var b strings.Builder
+	lineOctets := 0
+	for _, r := range line {
+		size := len(string(r))
+		if lineOctets+size > maxOctets {
+			b.WriteString("\r\n ")
+			// The space counts in the continuation line length
+			lineOctets = 1
+		}
+		b.WriteRune(r)
+		lineOctets += size
+	}
+
+	return b.String()
+}
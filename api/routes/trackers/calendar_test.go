@@ -0,0 +1,122 @@
+package trackers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+)
+
+func TestGetCalendarRoute(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("PNG"))
+	}))
+	defer imageServer.Close()
+
+	router := api.SetupRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	entries := []struct{ path, deletePath, name, body string }{
+		{"/v1/trackers/games_tracker/add_game_manually", "/v1/trackers/games_tracker/delete_game", "Calendar Test Game, Deluxe Edition", `{"wait": true, "name": "Calendar Test Game, Deluxe Edition", "url": "https://example.com/calendar", "cover_img_url": "%s", "priority": "high", "status": "to_be_released", "release_date": "2030-02-01", "tags": ["Calendar Test Tag"]}`},
+		{"/v1/trackers/medias_tracker/add_media_manually", "/v1/trackers/medias_tracker/delete_media", "Calendar Test Media", `{"wait": true, "name": "Calendar Test Media", "url": "https://example.com/calendar", "cover_img_url": "%s", "media_type": "movie", "priority": "low", "status": "finished", "release_date": "2020-02-01", "started_date": "2021-03-01", "finished_dropped_date": "2021-03-02"}`},
+	}
+	for _, entry := range entries {
+		w := serve(http.MethodPost, entry.path, fmt.Sprintf(entry.body, imageServer.URL))
+		if w.Code != http.StatusOK {
+			t.Fatalf("couldn't add the entry: %s", w.Body.String())
+		}
+		defer serve(http.MethodPost, entry.deletePath, fmt.Sprintf(`{"name": %q}`, entry.name))
+	}
+
+	testTable := []struct {
+		path       string
+		expected   []string
+		unexpected []string
+	}{
+		{"/v1/trackers/calendar.ics", []string{"SUMMARY:Calendar Test Game\\, Deluxe Edition is released", "DTSTART;VALUE=DATE:20300201", "DTEND;VALUE=DATE:20300202"}, []string{"Calendar Test Media"}},
+		{"/v1/trackers/calendar.ics?tracker=medias", nil, []string{"Calendar Test Game"}},
+		{"/v1/trackers/calendar.ics?priority=low", nil, []string{"Calendar Test Game"}},
+		{"/v1/trackers/calendar.ics?priority=high&tag=Calendar+Test+Tag", []string{"Calendar Test Game"}, nil},
+		{"/v1/trackers/calendar.ics?tag=Missing+Calendar+Test+Tag", nil, []string{"Calendar Test Game"}},
+		{"/v1/trackers/calendar.ics?include_started_finished=true", []string{"Calendar Test Game", "SUMMARY:Started Calendar Test Media", "SUMMARY:Finished Calendar Test Media", "DTSTART;VALUE=DATE:20210302"}, nil},
+	}
+	var uid string
+	for _, test := range testTable {
+		w := serve(http.MethodGet, test.path, "")
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status code: %d, actual status code: %d: %s", test.path, http.StatusOK, w.Code, w.Body.String())
+			continue
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "text/calendar; charset=utf-8" {
+			t.Errorf("%s: unexpected content type: %s", test.path, contentType)
+		}
+		body := w.Body.String()
+		if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(body, "END:VCALENDAR\r\n") {
+			t.Errorf("%s: invalid calendar: %s", test.path, body)
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(body, "\r\n"), "\r\n") {
+			if len(line) > 75 {
+				t.Errorf("%s: expected the line to be folded: %s", test.path, line)
+			}
+		}
+
+		unfolded := strings.ReplaceAll(body, "\r\n ", "")
+		for _, expected := range test.expected {
+			if !strings.Contains(unfolded, expected) {
+				t.Errorf("%s: expected %q in the calendar: %s", test.path, expected, body)
+			}
+		}
+		for _, unexpected := range test.unexpected {
+			if strings.Contains(unfolded, unexpected) {
+				t.Errorf("%s: expected no %q in the calendar: %s", test.path, unexpected, body)
+			}
+		}
+		if test.path == "/v1/trackers/calendar.ics" {
+			uid = getCalendarEventUID(unfolded, "Calendar Test Game")
+		}
+	}
+
+	// The UID doesn't change when the entry is updated, so the calendar apps replace the event
+	w := serve(http.MethodPost, "/v1/trackers/games_tracker/update_game", `{"wait": true, "name": "Calendar Test Game, Deluxe Edition", "priority": "medium", "status": "to_be_released", "release_date": "2030-03-01"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("couldn't update the game: %s", w.Body.String())
+	}
+	w = serve(http.MethodGet, "/v1/trackers/calendar.ics", "")
+	unfolded := strings.ReplaceAll(w.Body.String(), "\r\n ", "")
+	if newUID := getCalendarEventUID(unfolded, "Calendar Test Game"); uid == "" || newUID != uid {
+		t.Errorf("expected the event UID %q to not change, got %q", uid, newUID)
+	}
+	if !strings.Contains(unfolded, "DTSTART;VALUE=DATE:20300301") {
+		t.Errorf("expected the updated release date in the calendar: %s", unfolded)
+	}
+
+	for _, path := range []string{"/v1/trackers/calendar.ics?tracker=books", "/v1/trackers/calendar.ics?priority=urgent"} {
+		if w := serve(http.MethodGet, path, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code: %d, actual status code: %d", path, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// getCalendarEventUID returns the UID of the first event with the summary starting with the name
+func getCalendarEventUID(calendar, name string) string {
+	for _, event := range strings.Split(calendar, "BEGIN:VEVENT")[1:] {
+		if !strings.Contains(event, "SUMMARY:"+name) {
+			continue
+		}
+		for _, line := range strings.Split(event, "\r\n") {
+			if uid, ok := strings.CutPrefix(line, "UID:"); ok {
+				return uid
+			}
+		}
+	}
+
+	return ""
+}
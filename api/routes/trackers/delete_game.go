@@ -1,14 +1,16 @@
 package trackers
 
 import (
-	"database/sql"
-	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
 	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
 	"github.com/diogovalentte/dashboard/api/util"
 	"github.com/gin-gonic/gin"
-	"net/http"
-	"path/filepath"
-	"time"
 )
 
 func DeleteGame(c *gin.Context) {
@@ -16,11 +18,13 @@ func DeleteGame(c *gin.Context) {
 	currentJob := job.Job{
 		Task:      "Delete game from Games Tracker database",
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
 	}
 
 	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "couldn't create the task's job"})
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
 		return
 	}
 	jobsList.AddJob(&currentJob)
@@ -29,18 +33,18 @@ func DeleteGame(c *gin.Context) {
 	// Validate request
 	var gameRequest DeleteGameRequest
 	if err := c.ShouldBindJSON(&gameRequest); err != nil {
-		err = fmt.Errorf("invalid JSON fields, refer to the API documentation")
-		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		bindingErr := getBindingError(err, &gameRequest)
+		currentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
 		return
 	}
 
 	// Delete game from DB
 	currentJob.SetExecutingStateWithValue("Deleting game from DB", gameRequest.Name)
-	err := deleteGame(&gameRequest)
+	err := deleteGame(currentJob.UserID, &gameRequest)
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -48,14 +52,13 @@ func DeleteGame(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Game deleted from DB"})
 }
 
-func deleteGame(gp *DeleteGameRequest) error {
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+func deleteGame(userID int64, gp *DeleteGameRequest) error {
+	configs, err := util.GetConfigs()
 	if err != nil {
 		return err
 	}
-	dbPath := filepath.Join(configs.Database.FolderPath, "trackers.db")
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := database.OpenTrackersDB(configs)
 	if err != nil {
 		return err
 	}
@@ -65,7 +68,8 @@ func deleteGame(gp *DeleteGameRequest) error {
 DELETE FROM
     games_tracker
 WHERE
-    name = ?;
+    user_id = ?
+    AND name = ?;
   `)
 	if err != nil {
 		return err
@@ -73,6 +77,7 @@ WHERE
 	defer stm.Close()
 
 	_, err = stm.Exec(
+		userID,
 		gp.Name,
 	)
 	if err != nil {
@@ -1,14 +1,16 @@
 package trackers
 
 import (
-	"database/sql"
-	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
 	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
 	"github.com/diogovalentte/dashboard/api/util"
 	"github.com/gin-gonic/gin"
-	"net/http"
-	"path/filepath"
-	"time"
 )
 
 func DeleteMedia(c *gin.Context) {
@@ -16,11 +18,13 @@ func DeleteMedia(c *gin.Context) {
 	currentJob := job.Job{
 		Task:      "Delete media from Medias Tracker database",
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
 	}
 
 	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "couldn't create the task's job"})
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
 		return
 	}
 	jobsList.AddJob(&currentJob)
@@ -29,18 +33,18 @@ func DeleteMedia(c *gin.Context) {
 	// Validate request
 	var mediaRequest DeleteMediaRequest
 	if err := c.ShouldBindJSON(&mediaRequest); err != nil {
-		err = fmt.Errorf("invalid JSON fields, refer to the API documentation")
-		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		bindingErr := getBindingError(err, &mediaRequest)
+		currentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
 		return
 	}
 
 	// Delete media from DB
 	currentJob.SetExecutingStateWithValue("Deleting media from DB", mediaRequest.Name)
-	err := deleteMedia(&mediaRequest)
+	err := deleteMedia(currentJob.UserID, &mediaRequest)
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -48,14 +52,13 @@ func DeleteMedia(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Media deleted from DB"})
 }
 
-func deleteMedia(gp *DeleteMediaRequest) error {
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+func deleteMedia(userID int64, gp *DeleteMediaRequest) error {
+	configs, err := util.GetConfigs()
 	if err != nil {
 		return err
 	}
-	dbPath := filepath.Join(configs.Database.FolderPath, "trackers.db")
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := database.OpenTrackersDB(configs)
 	if err != nil {
 		return err
 	}
@@ -65,7 +68,8 @@ func deleteMedia(gp *DeleteMediaRequest) error {
 DELETE FROM
     medias_tracker
 WHERE
-    name = ?;
+    user_id = ?
+    AND name = ?;
   `)
 	if err != nil {
 		return err
@@ -73,6 +77,7 @@ WHERE
 	defer stm.Close()
 
 	_, err = stm.Exec(
+		userID,
 		gp.Name,
 	)
 	if err != nil {
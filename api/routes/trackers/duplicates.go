@@ -0,0 +1,179 @@
+package trackers
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// Matches the title ID of IMDB URLs
+var imdbTitleURLRegex = regexp.MustCompile(`/title/(tt\d+)`)
+
+// A DuplicateEntryError is returned when adding an entry that's already in its tracker
+type DuplicateEntryError struct {
+	// "Games Tracker" or "Medias Tracker"
+	Tracker string
+	// Name of the entry already in the tracker
+	Name string
+}
+
+func (e *DuplicateEntryError) Error() string {
+	return fmt.Sprintf("%s is already in the %s", e.Name, e.Tracker)
+}
+
+// getGameExternalID returns the canonical ID of a game, like "steam:105600", empty if the URL has none
+func getGameExternalID(gameURL string) string {
+	appID := getSteamAppID(gameURL)
+	if appID == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("steam:%d", appID)
+}
+
+// getMediaExternalID returns the canonical ID of a media, like "imdb:tt0468569", empty if the URL has none
+func getMediaExternalID(mediaURL string) string {
+	match := imdbTitleURLRegex.FindStringSubmatch(mediaURL)
+	if match == nil {
+		return ""
+	}
+
+	return "imdb:" + match[1]
+}
+
+// nullableString returns nil for empty strings, so they're stored as NULL
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// findDuplicateName returns the name of the user's entry with the same name, external ID, or URL, empty if there is none.
+// Empty values are not compared.
+func findDuplicateName(q queryRower, tt trackerTable, userID int64, name, externalID, entryURL string) (string, error) {
+	var existingName string
+	err := q.QueryRow(
+		fmt.Sprintf("SELECT name FROM %s WHERE user_id = ? AND (name = ? OR (? != '' AND external_id = ?) OR (? != '' AND url = ?)) LIMIT 1;", tt.table),
+		userID, name, externalID, externalID, entryURL, entryURL,
+	).Scan(&existingName)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return existingName, err
+}
+
+func findDuplicateNameInDB(configs *util.Configs, tt trackerTable, userID int64, externalID, entryURL string) (string, error) {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	return findDuplicateName(db, tt, userID, "", externalID, entryURL)
+}
+
+// getDuplicateGame returns the user's game with the same Steam app ID or URL, nil if there is none
+func getDuplicateGame(configs *util.Configs, userID int64, gameURL string) (*GetGameProperties, error) {
+	name, err := findDuplicateNameInDB(configs, gamesTrackerTable, userID, getGameExternalID(gameURL), gameURL)
+	if err != nil || name == "" {
+		return nil, err
+	}
+
+	games, err := getGamesFromQuery(`
+SELECT
+  user_id, url, name, cover_img, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, commentary
+FROM
+  games_tracker
+WHERE
+  user_id = ?
+  AND name = ?;`, userID, name,
+	)
+	if err != nil || len(games) == 0 {
+		return nil, err
+	}
+
+	return games[0], nil
+}
+
+// getDuplicateMedia returns the user's media with the same IMDB ID or URL, nil if there is none
+func getDuplicateMedia(configs *util.Configs, userID int64, mediaURL string) (*GetMediaProperties, error) {
+	name, err := findDuplicateNameInDB(configs, mediasTrackerTable, userID, getMediaExternalID(mediaURL), mediaURL)
+	if err != nil || name == "" {
+		return nil, err
+	}
+
+	medias, err := getMediasFromQuery(`
+SELECT
+  user_id, url, name, media_type, cover_img, release_date, priority,
+  status, stars, started_date, finished_dropped_date, commentary
+FROM
+  medias_tracker
+WHERE
+  user_id = ?
+  AND name = ?;`, userID, name,
+	)
+	if err != nil || len(medias) == 0 {
+		return nil, err
+	}
+
+	return medias[0], nil
+}
+
+// getInsertError returns a conflict error for duplicated entries, else the error
+func getInsertError(err error) error {
+	if _, ok := err.(*DuplicateEntryError); ok {
+		return apierror.Conflict(err.Error())
+	}
+
+	return err
+}
+
+// respondDuplicateGame responds with 409 and the existing game in the details if the game URL is already in the user's tracker.
+// It returns the error of the duplicate, or of checking it, nil if the game is not in the tracker.
+func respondDuplicateGame(c *gin.Context, configs *util.Configs, gameURL string) error {
+	existingGame, err := getDuplicateGame(configs, auth.GetUser(c).ID, gameURL)
+	if err != nil {
+		apierror.Respond(c, err)
+		return err
+	}
+	if existingGame == nil {
+		return nil
+	}
+
+	err = &DuplicateEntryError{Tracker: "Games Tracker", Name: existingGame.Name}
+	apierror.Respond(c, apierror.Conflict(err.Error()).WithDetails(gin.H{"game": existingGame}))
+
+	return err
+}
+
+// respondDuplicateMedia responds with 409 and the existing media in the details if the media URL is already in the user's tracker.
+// It returns the error of the duplicate, or of checking it, nil if the media is not in the tracker.
+func respondDuplicateMedia(c *gin.Context, configs *util.Configs, mediaURL string) error {
+	existingMedia, err := getDuplicateMedia(configs, auth.GetUser(c).ID, mediaURL)
+	if err != nil {
+		apierror.Respond(c, err)
+		return err
+	}
+	if existingMedia == nil {
+		return nil
+	}
+
+	err = &DuplicateEntryError{Tracker: "Medias Tracker", Name: existingMedia.Name}
+	apierror.Respond(c, apierror.Conflict(err.Error()).WithDetails(gin.H{"media": existingMedia}))
+
+	return err
+}
@@ -0,0 +1,83 @@
+package trackers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+)
+
+var duplicateRouteTestTable = []struct {
+	path               string
+	body               string
+	expectedStatusCode int
+	// Field of the response details with the existing entry, empty if none
+	expectedEntryField string
+}{
+	// Same Steam app ID, with another URL
+	{"/v1/trackers/games_tracker/add_game", `{"wait": true, "url": "https://store.steampowered.com/app/9100001/?l=brazilian", "priority": "low", "status": "not_started"}`, http.StatusConflict, "game"},
+	// Same name
+	{"/v1/trackers/games_tracker/add_game_manually", `{"wait": true, "name": "Duplicate Test Game", "url": "https://example.com/game", "cover_img_url": "%s", "priority": "low", "status": "not_started"}`, http.StatusConflict, ""},
+	// Same IMDB ID, with another URL
+	{"/v1/trackers/medias_tracker/add_media", `{"wait": true, "url": "https://www.imdb.com/title/tt9100001/?ref_=nv_sr_srsg_0", "type": "movie", "priority": "low", "status": "not_started"}`, http.StatusConflict, "media"},
+}
+
+func TestDuplicateRoutes(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("PNG"))
+	}))
+	defer imageServer.Close()
+
+	router := api.SetupRouter()
+	serve := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Add the entries the test table duplicates
+	w := serve("/v1/trackers/games_tracker/add_game_manually", fmt.Sprintf(`{"wait": true, "name": "Duplicate Test Game", "url": "https://store.steampowered.com/app/9100001/Duplicate_Test_Game/", "cover_img_url": "%s", "priority": "high", "status": "not_started"}`, imageServer.URL))
+	if w.Code != http.StatusOK {
+		t.Fatalf("couldn't add the game: %s", w.Body.String())
+	}
+	defer serve("/v1/trackers/games_tracker/delete_game", `{"name": "Duplicate Test Game"}`)
+	w = serve("/v1/trackers/medias_tracker/add_media_manually", fmt.Sprintf(`{"wait": true, "name": "Duplicate Test Media", "url": "https://www.imdb.com/title/tt9100001/", "cover_img_url": "%s", "media_type": "movie", "priority": "high", "status": "not_started"}`, imageServer.URL))
+	if w.Code != http.StatusOK {
+		t.Fatalf("couldn't add the media: %s", w.Body.String())
+	}
+	defer serve("/v1/trackers/medias_tracker/delete_media", `{"name": "Duplicate Test Media"}`)
+
+	for _, test := range duplicateRouteTestTable {
+		body := test.body
+		if strings.Contains(body, "%s") {
+			body = fmt.Sprintf(body, imageServer.URL)
+		}
+		w := serve(test.path, body)
+
+		if test.expectedStatusCode != w.Code {
+			t.Errorf("%s: expected status code: %d, actual status code: %d, body: %s", test.path, test.expectedStatusCode, w.Code, w.Body.String())
+			continue
+		}
+
+		var res map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Error(err)
+			continue
+		}
+		if message, _ := res["message"].(string); !strings.Contains(message, "is already in the") {
+			t.Errorf("%s: unexpected message: %s", test.path, message)
+		}
+		if res["code"] != "conflict" {
+			t.Errorf("%s: unexpected error code: %v", test.path, res["code"])
+		}
+		details, _ := res["details"].(map[string]interface{})
+		if test.expectedEntryField != "" && details[test.expectedEntryField] == nil {
+			t.Errorf("%s: expected the existing entry in the %q details field", test.path, test.expectedEntryField)
+		}
+	}
+}
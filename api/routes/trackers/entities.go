@@ -0,0 +1,230 @@
+package trackers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// An entityTable is a table of values shared by many tracker entries, like tags or developers,
+// and the many-to-many table that links it to a tracker table.
+type entityTable struct {
+	// Table with the entities names
+	table string
+	// Table linking the tracker entries to the entities
+	linkTable string
+	// Column of the link table with the tracker entry name
+	ownerColumn string
+	// Column of the link table with the entity ID
+	entityColumn string
+}
+
+var (
+	gamesTagsTable       = entityTable{"tags", "games_tags", "game_name", "tag_id"}
+	gamesDevelopersTable = entityTable{"developers", "games_developers", "game_name", "developer_id"}
+	gamesPublishersTable = entityTable{"publishers", "games_publishers", "game_name", "publisher_id"}
+	mediasGenresTable    = entityTable{"genres", "medias_genres", "media_name", "genre_id"}
+	mediasStaffTable     = entityTable{"staff", "medias_staff", "media_name", "staff_id"}
+)
+
+// setEntities replaces the entities linked to a tracker entry of the user, keeping their order.
+// Empty names and repeated names are ignored.
+func setEntities(tx *sql.Tx, et entityTable, userID int64, owner string, names []string) error {
+	_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id = ? AND %s = ?;", et.linkTable, et.ownerColumn), userID, owner)
+	if err != nil {
+		return err
+	}
+
+	insertEntity := fmt.Sprintf("INSERT INTO %s (name) VALUES (?) ON CONFLICT (name) DO NOTHING;", et.table)
+	selectEntity := fmt.Sprintf("SELECT id FROM %s WHERE name = ?;", et.table)
+	insertLink := fmt.Sprintf("INSERT INTO %s (user_id, %s, %s, position) VALUES (?, ?, ?, ?);", et.linkTable, et.ownerColumn, et.entityColumn)
+
+	added := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || added[name] {
+			continue
+		}
+		added[name] = true
+
+		if _, err = tx.Exec(insertEntity, name); err != nil {
+			return err
+		}
+		var id int64
+		if err = tx.QueryRow(selectEntity, name).Scan(&id); err != nil {
+			return err
+		}
+		if _, err = tx.Exec(insertLink, userID, owner, id, len(added)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getEntities returns the entities names linked to each tracker entry of the entries query, in order.
+// The entries query should select the user_id and name columns of the tracker table.
+func getEntities(db *sql.DB, et entityTable, entriesQuery string, args ...interface{}) (map[entryKey][]string, error) {
+	entriesQuery = strings.TrimSuffix(strings.TrimSpace(entriesQuery), ";")
+	rows, err := db.Query(fmt.Sprintf(`
+SELECT
+  l.user_id, l.%s, e.name
+FROM
+  %s l
+  JOIN %s e ON e.id = l.%s
+WHERE
+  (l.user_id, l.%s) IN (SELECT user_id, name FROM (%s))
+ORDER BY
+  l.user_id, l.%s, l.position;`,
+		et.ownerColumn, et.linkTable, et.table, et.entityColumn, et.ownerColumn, entriesQuery, et.ownerColumn,
+	), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entities := map[entryKey][]string{}
+	for rows.Next() {
+		var owner entryKey
+		var name string
+		if err = rows.Scan(&owner.UserID, &owner.Name, &name); err != nil {
+			return nil, err
+		}
+		entities[owner] = append(entities[owner], name)
+	}
+
+	return entities, rows.Err()
+}
+
+// filterByEntity returns a SQL condition that matches the tracker entries linked to the entity name.
+// The entries are identified by the user ID and name columns of the tracker table.
+func filterByEntity(et entityTable, ownerTableColumns string) string {
+	return fmt.Sprintf(`(%s) IN (
+  SELECT l.user_id, l.%s FROM %s l JOIN %s e ON e.id = l.%s WHERE e.name = ?
+)`, ownerTableColumns, et.ownerColumn, et.linkTable, et.table, et.entityColumn)
+}
+
+// An entityFilter maps a request query parameter to the entity table it filters by
+type entityFilter struct {
+	queryParam string
+	table      entityTable
+}
+
+// getEntityFiltersCondition returns a SQL condition matching the tracker entries linked
+// to all entities in the request query parameters, and the condition arguments.
+// If the request has none of the query parameters, the condition is empty.
+func getEntityFiltersCondition(c *gin.Context, filters []entityFilter, ownerTableColumns string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for _, filter := range filters {
+		for _, value := range c.QueryArray(filter.queryParam) {
+			conditions = append(conditions, filterByEntity(filter.table, ownerTableColumns))
+			args = append(args, value)
+		}
+	}
+
+	return strings.Join(conditions, "\n  AND "), args
+}
+
+func nonNilSlice(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
+
+// An EntityCount is an entity, like a tag, and how many tracker entries are linked to it
+type EntityCount struct {
+	Name  string
+	Count int
+}
+
+func getEntitiesCount(et entityTable, scope userScope) ([]*EntityCount, error) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		return nil, err
+	}
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	userCondition, args := scope.condition("l.user_id")
+	rows, err := db.Query(fmt.Sprintf(`
+SELECT
+  e.name, COUNT(*)
+FROM
+  %s e
+  JOIN %s l ON l.%s = e.id
+WHERE
+  %s
+GROUP BY
+  e.id
+ORDER BY
+  COUNT(*) DESC, e.name;`,
+		et.table, et.linkTable, et.entityColumn, userCondition,
+	), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entitiesCount := []*EntityCount{}
+	for rows.Next() {
+		entityCount := EntityCount{}
+		if err = rows.Scan(&entityCount.Name, &entityCount.Count); err != nil {
+			return nil, err
+		}
+		entitiesCount = append(entitiesCount, &entityCount)
+	}
+
+	return entitiesCount, rows.Err()
+}
+
+func respondEntitiesCount(c *gin.Context, et entityTable, responseField string) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+
+	entitiesCount, err := getEntitiesCount(et, scope)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{responseField: entitiesCount})
+}
+
+// GetGamesTags returns all tags linked to at least one game and how many games are linked to each one
+func GetGamesTags(c *gin.Context) {
+	respondEntitiesCount(c, gamesTagsTable, "tags")
+}
+
+// GetGamesDevelopers returns all developers of at least one game and how many games each one developed
+func GetGamesDevelopers(c *gin.Context) {
+	respondEntitiesCount(c, gamesDevelopersTable, "developers")
+}
+
+// GetGamesPublishers returns all publishers of at least one game and how many games each one published
+func GetGamesPublishers(c *gin.Context) {
+	respondEntitiesCount(c, gamesPublishersTable, "publishers")
+}
+
+// GetMediasGenres returns all genres linked to at least one media and how many medias are linked to each one
+func GetMediasGenres(c *gin.Context) {
+	respondEntitiesCount(c, mediasGenresTable, "genres")
+}
+
+// GetMediasStaff returns all staff members linked to at least one media and how many medias are linked to each one
+func GetMediasStaff(c *gin.Context) {
+	respondEntitiesCount(c, mediasStaffTable, "staff")
+}
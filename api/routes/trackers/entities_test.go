@@ -0,0 +1,58 @@
+package trackers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+)
+
+var getEntitiesCountRouteTestTable = []struct {
+	path          string
+	responseField string
+}{
+	{"/v1/trackers/games_tracker/get_tags", "tags"},
+	{"/v1/trackers/games_tracker/get_developers", "developers"},
+	{"/v1/trackers/games_tracker/get_publishers", "publishers"},
+	{"/v1/trackers/medias_tracker/get_genres", "genres"},
+	{"/v1/trackers/medias_tracker/get_staff", "staff"},
+}
+
+func TestGetEntitiesCountRoutes(t *testing.T) {
+	router := api.SetupRouter()
+
+	for _, test := range getEntitiesCountRouteTestTable {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, test.path, nil)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		router.ServeHTTP(w, req)
+
+		var res map[string][]trackers.EntityCount
+		jsonBytes := w.Body.Bytes()
+		if err := json.Unmarshal(jsonBytes, &res); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if http.StatusOK != w.Code {
+			t.Errorf("expected status code: %d, actual status code: %d", http.StatusOK, w.Code)
+		}
+
+		entitiesCount, exists := res[test.responseField]
+		if !exists {
+			t.Errorf(`Response body has no field "%s"`, test.responseField)
+			continue
+		}
+		for _, entityCount := range entitiesCount {
+			if entityCount.Count < 1 {
+				t.Errorf("expected %s to be linked to at least one entry, count: %d", entityCount.Name, entityCount.Count)
+			}
+		}
+	}
+}
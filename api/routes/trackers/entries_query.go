@@ -0,0 +1,133 @@
+package trackers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// An entriesQuery has the SQL queries of the games and medias of a request that lists both trackers, like the export.
+// The query of a tracker that isn't listed is empty.
+type entriesQuery struct {
+	gamesQuery  string
+	gamesArgs   []interface{}
+	mediasQuery string
+	mediasArgs  []interface{}
+}
+
+// getEntriesQuery returns the queries of the games and medias of the user scope that match the request tracker
+// and entity filters, and the conditions, like a status filter. The conditions use the columns of both tracker tables.
+// The cover images are only read if includeCovers is true.
+func getEntriesQuery(c *gin.Context, scope userScope, conditions []string, args []interface{}, includeCovers bool) (*entriesQuery, error) {
+	tracker := c.Query("tracker")
+	if tracker != "" && tracker != "games" && tracker != "medias" {
+		return nil, apierror.Validation(fmt.Sprintf("invalid tracker %q, it should be games or medias", tracker))
+	}
+	coverImgColumn := `""`
+	if includeCovers {
+		coverImgColumn = "cover_img"
+	}
+
+	// A filter by an entity of one tracker, like tag, excludes the entries of the other tracker
+	query := &entriesQuery{}
+	if tracker != "medias" && !hasEntityFilters(c, mediasEntityFilters) {
+		condition, conditionArgs := getEntriesCondition(c, scope, gamesEntityFilters, conditions, args)
+		query.gamesQuery = fmt.Sprintf(`
+SELECT
+  user_id, url, name, %s, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, commentary
+FROM
+  games_tracker
+WHERE
+  %s
+ORDER BY
+  name;`, coverImgColumn, condition,
+		)
+		query.gamesArgs = conditionArgs
+	}
+	if tracker != "games" && !hasEntityFilters(c, gamesEntityFilters) {
+		condition, conditionArgs := getEntriesCondition(c, scope, mediasEntityFilters, conditions, args)
+		query.mediasQuery = fmt.Sprintf(`
+SELECT
+  user_id, url, name, media_type, %s, release_date, priority,
+  status, stars, started_date, finished_dropped_date, commentary
+FROM
+  medias_tracker
+WHERE
+  %s
+ORDER BY
+  name;`, coverImgColumn, condition,
+		)
+		query.mediasArgs = conditionArgs
+	}
+
+	return query, nil
+}
+
+// getEntriesCondition returns the SQL condition with the user scope, the conditions, and the entity filters of the request
+func getEntriesCondition(c *gin.Context, scope userScope, filters []entityFilter, conditions []string, args []interface{}) (string, []interface{}) {
+	userCondition, conditionArgs := scope.condition("user_id")
+	allConditions := append([]string{userCondition}, conditions...)
+	conditionArgs = append(conditionArgs, args...)
+
+	entitiesCondition, entitiesArgs := getEntityFiltersCondition(c, filters, "user_id, name")
+	if entitiesCondition != "" {
+		allConditions = append(allConditions, entitiesCondition)
+		conditionArgs = append(conditionArgs, entitiesArgs...)
+	}
+
+	return strings.Join(allConditions, "\n  AND "), conditionArgs
+}
+
+// forEach calls onGame with each game and then onMedia with each media, as they're read from the DB
+func (query *entriesQuery) forEach(onGame func(game *GetGameProperties) error, onMedia func(media *GetMediaProperties) error) error {
+	if query.gamesQuery != "" {
+		err := forEachGameFromQuery(onGame, query.gamesQuery, query.gamesArgs...)
+		if err != nil {
+			return err
+		}
+	}
+	if query.mediasQuery != "" {
+		err := forEachMediaFromQuery(onMedia, query.mediasQuery, query.mediasArgs...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasEntityFilters returns whether the request filters by any of the entities
+func hasEntityFilters(c *gin.Context, filters []entityFilter) bool {
+	for _, filter := range filters {
+		if len(c.QueryArray(filter.queryParam)) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getEnumFilterCondition returns a SQL condition matching the enum column with the values of the request
+// query parameter, like ?status=finished&status=4, and its arguments. Without the parameter, the condition is empty.
+func getEnumFilterCondition(c *gin.Context, queryParam, column string, names map[int]string, enumType string) (string, []interface{}, error) {
+	values := c.QueryArray(queryParam)
+	if len(values) == 0 {
+		return "", nil, nil
+	}
+
+	var placeholders []string
+	var args []interface{}
+	for _, valueStr := range values {
+		value, err := parseEnum(names, enumType, valueStr)
+		if err != nil {
+			return "", nil, apierror.Validation(err.Error())
+		}
+		placeholders = append(placeholders, "?")
+		args = append(args, value)
+	}
+
+	return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")), args, nil
+}
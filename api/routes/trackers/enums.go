@@ -0,0 +1,250 @@
+package trackers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// Status is the state of a game or media in the trackers.
+// It's encoded in JSON as its name, but a request can also use its number.
+type Status int
+
+const (
+	StatusToBeReleased Status = iota + 1
+	StatusNotStarted
+	// Playing for games, watching/reading for medias
+	StatusInProgress
+	StatusFinished
+	StatusDropped
+)
+
+var statusNames = map[int]string{
+	int(StatusToBeReleased): "to_be_released",
+	int(StatusNotStarted):   "not_started",
+	int(StatusInProgress):   "in_progress",
+	int(StatusFinished):     "finished",
+	int(StatusDropped):      "dropped",
+}
+
+func (s Status) String() string {
+	return enumName(statusNames, int(s))
+}
+
+// EnumNames returns the names ordered by value, for the API documentation
+func (Status) EnumNames() []string {
+	return getEnumNames(statusNames)
+}
+
+func (s Status) IsValid() bool {
+	return statusNames[int(s)] != ""
+}
+
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *Status) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(statusNames, reflect.TypeOf(*s), data, (*int)(s))
+}
+
+// Priority is how much a game or media is wanted in the trackers, the lower the value the higher the priority.
+// It's encoded in JSON as its name, but a request can also use its number.
+type Priority int
+
+const (
+	PriorityHigh Priority = iota + 1
+	PriorityMedium
+	PriorityLow
+)
+
+var priorityNames = map[int]string{
+	int(PriorityHigh):   "high",
+	int(PriorityMedium): "medium",
+	int(PriorityLow):    "low",
+}
+
+func (p Priority) String() string {
+	return enumName(priorityNames, int(p))
+}
+
+// EnumNames returns the names ordered by value, for the API documentation
+func (Priority) EnumNames() []string {
+	return getEnumNames(priorityNames)
+}
+
+func (p Priority) IsValid() bool {
+	return priorityNames[int(p)] != ""
+}
+
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(priorityNames, reflect.TypeOf(*p), data, (*int)(p))
+}
+
+// MediaType is the kind of a media in the Medias Tracker.
+// It's encoded in JSON as its name, but a request can also use its number.
+type MediaType int
+
+const (
+	MediaTypeSeries MediaType = iota + 1
+	MediaTypeMovie
+	MediaTypeBook
+	MediaTypeComicBook
+)
+
+var mediaTypeNames = map[int]string{
+	int(MediaTypeSeries):    "series",
+	int(MediaTypeMovie):     "movie",
+	int(MediaTypeBook):      "book",
+	int(MediaTypeComicBook): "comic_book",
+}
+
+func (mt MediaType) String() string {
+	return enumName(mediaTypeNames, int(mt))
+}
+
+// EnumNames returns the names ordered by value, for the API documentation
+func (MediaType) EnumNames() []string {
+	return getEnumNames(mediaTypeNames)
+}
+
+func (mt MediaType) IsValid() bool {
+	return mediaTypeNames[int(mt)] != ""
+}
+
+func (mt MediaType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mt.String())
+}
+
+func (mt *MediaType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(mediaTypeNames, reflect.TypeOf(*mt), data, (*int)(mt))
+}
+
+func enumName(names map[int]string, value int) string {
+	name, ok := names[value]
+	if !ok {
+		return fmt.Sprintf("unknown(%d)", value)
+	}
+
+	return name
+}
+
+// unmarshalEnum sets value from a JSON number or a JSON string with the value name.
+// Numbers are not checked against the names, the IsValidEnum validation does it.
+// Unknown names return a *json.UnmarshalTypeError of the enum type, so the decoder adds the field to it.
+func unmarshalEnum(names map[int]string, enumType reflect.Type, data []byte, value *int) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return json.Unmarshal(data, value)
+	}
+	for v, n := range names {
+		if n == name {
+			*value = v
+			return nil
+		}
+	}
+
+	return &json.UnmarshalTypeError{Value: fmt.Sprintf("%q", name), Type: enumType}
+}
+
+// parseEnum returns the value of a name or number, like the ones in query parameters
+func parseEnum(names map[int]string, enumType, s string) (int, error) {
+	if value, err := strconv.Atoi(s); err == nil {
+		if names[value] != "" {
+			return value, nil
+		}
+	} else {
+		for v, n := range names {
+			if n == s {
+				return v, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("invalid %s %q", enumType, s)
+}
+
+// ParseStatus returns the status of a name, like "finished", or of a number, like "4"
+func ParseStatus(s string) (Status, error) {
+	value, err := parseEnum(statusNames, "status", s)
+	return Status(value), err
+}
+
+// ParsePriority returns the priority of a name, like "high", or of a number, like "1"
+func ParsePriority(s string) (Priority, error) {
+	value, err := parseEnum(priorityNames, "priority", s)
+	return Priority(value), err
+}
+
+// ParseMediaType returns the media type of a name, like "movie", or of a number, like "2"
+func ParseMediaType(s string) (MediaType, error) {
+	value, err := parseEnum(mediaTypeNames, "media type", s)
+	return MediaType(value), err
+}
+
+// An enum is a type with a fixed set of valid values, like Status
+type enum interface {
+	IsValid() bool
+}
+
+// IsValidEnum validates that the field is one of the valid values of its enum type
+func IsValidEnum(fl validator.FieldLevel) bool {
+	value, ok := fl.Field().Interface().(enum)
+	if !ok {
+		return false
+	}
+
+	return value.IsValid()
+}
+
+// An EnumValue is a valid value of an enum and its name
+type EnumValue struct {
+	Value int    `json:"value"`
+	Name  string `json:"name"`
+}
+
+func getEnumValues(names map[int]string) []EnumValue {
+	values := make([]EnumValue, 0, len(names))
+	for value, name := range names {
+		values = append(values, EnumValue{Value: value, Name: name})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Value < values[j].Value
+	})
+
+	return values
+}
+
+func getEnumNames(names map[int]string) []string {
+	values := getEnumValues(names)
+	enumNames := make([]string, len(values))
+	for i, value := range values {
+		enumNames[i] = value.Name
+	}
+
+	return enumNames
+}
+
+// GetEnums returns the valid values of the statuses, priorities and media types
+func GetEnums(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"statuses":    getEnumValues(statusNames),
+		"priorities":  getEnumValues(priorityNames),
+		"media_types": getEnumValues(mediaTypeNames),
+	})
+}
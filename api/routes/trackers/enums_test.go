@@ -0,0 +1,106 @@
+package trackers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+)
+
+var unmarshalStatusTestTable = []struct {
+	json     string
+	expected trackers.Status
+	isValid  bool
+}{
+	{`"finished"`, trackers.StatusFinished, true},
+	{`"in_progress"`, trackers.StatusInProgress, true},
+	{`1`, trackers.StatusToBeReleased, true},
+	{`9`, trackers.Status(9), false},
+}
+
+func TestUnmarshalStatus(t *testing.T) {
+	for _, test := range unmarshalStatusTestTable {
+		var actual trackers.Status
+		if err := json.Unmarshal([]byte(test.json), &actual); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if actual != test.expected {
+			t.Errorf("expected: %s, actual: %s", test.expected, actual)
+		}
+		if actual.IsValid() != test.isValid {
+			t.Errorf("expected %s validity: %t", actual, test.isValid)
+		}
+	}
+
+	var status trackers.Status
+	if err := json.Unmarshal([]byte(`"playing"`), &status); err == nil {
+		t.Error("expected an error when unmarshaling an unknown status name")
+	}
+}
+
+func TestMarshalEnums(t *testing.T) {
+	game := trackers.GetGameProperties{Priority: trackers.PriorityLow, Status: trackers.StatusDropped}
+	jsonBytes, err := json.Marshal(game)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var res map[string]interface{}
+	if err := json.Unmarshal(jsonBytes, &res); err != nil {
+		t.Error(err)
+		return
+	}
+	if res["Priority"] != "low" {
+		t.Errorf(`expected priority: "low", actual priority: %v`, res["Priority"])
+	}
+	if res["Status"] != "dropped" {
+		t.Errorf(`expected status: "dropped", actual status: %v`, res["Status"])
+	}
+}
+
+func TestEnumNames(t *testing.T) {
+	expectedStatuses := "[to_be_released not_started in_progress finished dropped]"
+	if names := fmt.Sprint(trackers.StatusFinished.EnumNames()); names != expectedStatuses {
+		t.Errorf("expected statuses: %s, actual statuses: %s", expectedStatuses, names)
+	}
+	expectedMediaTypes := "[series movie book comic_book]"
+	if names := fmt.Sprint(trackers.MediaType(0).EnumNames()); names != expectedMediaTypes {
+		t.Errorf("expected media types: %s, actual media types: %s", expectedMediaTypes, names)
+	}
+}
+
+func TestGetEnumsRoute(t *testing.T) {
+	router := api.SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/v1/trackers/get_enums", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	router.ServeHTTP(w, req)
+
+	var res map[string][]trackers.EnumValue
+	jsonBytes := w.Body.Bytes()
+	if err := json.Unmarshal(jsonBytes, &res); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if http.StatusOK != w.Code {
+		t.Errorf("expected status code: %d, actual status code: %d", http.StatusOK, w.Code)
+	}
+
+	for _, field := range []string{"statuses", "priorities", "media_types"} {
+		if len(res[field]) == 0 {
+			t.Errorf(`Response body has no values in field "%s"`, field)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package trackers
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportColumns are the columns of the CSV export, in order.
+// New columns should only be added at the end, so scripts reading the export keep working.
+var ExportColumns = []string{
+	"tracker", "name", "url", "media_type", "status", "priority", "stars", "purchased_or_gamepass",
+	"release_date", "started_date", "finished_dropped_date",
+	"tags", "developers", "publishers", "genres", "staff",
+	"commentary", "cover_img",
+}
+
+// Separator of the values of list columns, like tags, in the CSV export
+const ExportListSeparator = "|"
+
+// An ExportEntry is a game or media in the export.
+// Dates have the format YYYY-MM-DD and are empty when not set.
+type ExportEntry struct {
+	// "game" or "media"
+	Tracker string `json:"tracker"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	// Only for medias
+	MediaType           string   `json:"media_type"`
+	Status              Status   `json:"status"`
+	Priority            Priority `json:"priority"`
+	Stars               int      `json:"stars"`
+	PurchasedOrGamePass bool     `json:"purchased_or_gamepass"`
+	ReleaseDate         string   `json:"release_date"`
+	StartedDate         string   `json:"started_date"`
+	FinishedDroppedDate string   `json:"finished_dropped_date"`
+	Tags                []string `json:"tags"`
+	Developers          []string `json:"developers"`
+	Publishers          []string `json:"publishers"`
+	Genres              []string `json:"genres"`
+	Staff               []string `json:"staff"`
+	Commentary          string   `json:"commentary"`
+	// Base64-encoded cover image, only when requested
+	CoverImg string `json:"cover_img"`
+	// The importKeptFields the import file doesn't have for the entry
+	missing map[string]bool
+}
+
+// csvRecord returns the entry values in the order of the ExportColumns
+func (ee *ExportEntry) csvRecord() []string {
+	return []string{
+		ee.Tracker,
+		ee.Name,
+		ee.URL,
+		ee.MediaType,
+		ee.Status.String(),
+		ee.Priority.String(),
+		strconv.Itoa(ee.Stars),
+		strconv.FormatBool(ee.PurchasedOrGamePass),
+		ee.ReleaseDate,
+		ee.StartedDate,
+		ee.FinishedDroppedDate,
+		strings.Join(ee.Tags, ExportListSeparator),
+		strings.Join(ee.Developers, ExportListSeparator),
+		strings.Join(ee.Publishers, ExportListSeparator),
+		strings.Join(ee.Genres, ExportListSeparator),
+		strings.Join(ee.Staff, ExportListSeparator),
+		ee.Commentary,
+		ee.CoverImg,
+	}
+}
+
+func formatExportDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.Format("2006-01-02")
+}
+
+func encodeExportCoverImg(coverImg []byte) string {
+	if len(coverImg) == 0 {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(coverImg)
+}
+
+func gameToExportEntry(game *GetGameProperties) *ExportEntry {
+	return &ExportEntry{
+		Tracker:             "game",
+		Name:                game.Name,
+		URL:                 game.URL,
+		Status:              game.Status,
+		Priority:            game.Priority,
+		Stars:               game.Stars,
+		PurchasedOrGamePass: game.PurchasedOrGamePass,
+		ReleaseDate:         formatExportDate(game.ReleaseDate),
+		StartedDate:         formatExportDate(game.StartedDate),
+		FinishedDroppedDate: formatExportDate(game.FinishedDroppedDate),
+		Tags:                game.Tags,
+		Developers:          game.Developers,
+		Publishers:          game.Publishers,
+		Genres:              []string{},
+		Staff:               []string{},
+		Commentary:          game.Commentary,
+		CoverImg:            encodeExportCoverImg(game.CoverImg),
+	}
+}
+
+func mediaToExportEntry(media *GetMediaProperties) *ExportEntry {
+	return &ExportEntry{
+		Tracker:             "media",
+		Name:                media.Name,
+		URL:                 media.URL,
+		MediaType:           media.MediaType.String(),
+		Status:              media.Status,
+		Priority:            media.Priority,
+		Stars:               media.Stars,
+		ReleaseDate:         formatExportDate(media.ReleaseDate),
+		StartedDate:         formatExportDate(media.StartedDate),
+		FinishedDroppedDate: formatExportDate(media.FinishedDroppedDate),
+		Tags:                []string{},
+		Developers:          []string{},
+		Publishers:          []string{},
+		Genres:              media.Genres,
+		Staff:               media.Staff,
+		Commentary:          media.Commentary,
+		CoverImg:            encodeExportCoverImg(media.CoverImg),
+	}
+}
+
+// GetExport streams the games and medias as CSV, a JSON array, or newline-delimited JSON.
+// Each entry is written and flushed to the client as it's read from the DB.
+//
+// Query parameters:
+// format - csv (default), json, or ndjson
+// tracker - games or medias, both by default
+// status - status name or number, can be repeated
+// tag, developer, publisher, genre, staff - entity name, can be repeated
+// include_covers - true to include the base64-encoded cover images
+// user - name of the user to export, only admins can set it to other users
+func GetExport(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	var contentType string
+	var writer exportWriter
+	switch format {
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+		writer = &csvExportWriter{w: csv.NewWriter(c.Writer)}
+	case "json":
+		contentType = "application/json; charset=utf-8"
+		writer = &jsonExportWriter{w: c.Writer}
+	case "ndjson":
+		contentType = "application/x-ndjson; charset=utf-8"
+		writer = &ndjsonExportWriter{encoder: json.NewEncoder(c.Writer)}
+	default:
+		apierror.Respond(c, apierror.Validation(fmt.Sprintf("invalid format %q, it should be csv, json, or ndjson", format)))
+		return
+	}
+
+	// The export is of the user's own entries, so it can be imported back.
+	// Admins can export the entries of other users with the user query parameter.
+	scope, ok := getUserScope(c, false)
+	if !ok {
+		return
+	}
+
+	var conditions []string
+	statusCondition, args, err := getEnumFilterCondition(c, "status", "status", statusNames, "status")
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	if statusCondition != "" {
+		conditions = append(conditions, statusCondition)
+	}
+	query, err := getEntriesQuery(c, scope, conditions, args, c.Query("include_covers") == "true")
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="trackers.%s"`, format))
+	c.Status(http.StatusOK)
+
+	err = writer.begin()
+	if err == nil {
+		writeEntry := func(entry *ExportEntry) error {
+			if err := writer.write(entry); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		}
+		err = query.forEach(
+			func(game *GetGameProperties) error { return writeEntry(gameToExportEntry(game)) },
+			func(media *GetMediaProperties) error { return writeEntry(mediaToExportEntry(media)) },
+		)
+	}
+	if err == nil {
+		err = writer.end()
+	}
+	if err != nil {
+		// The status code was already sent
+		c.Error(err)
+	}
+}
+
+// An exportWriter writes the entries of the export in a format
+type exportWriter interface {
+	begin() error
+	write(entry *ExportEntry) error
+	end() error
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (ew *csvExportWriter) begin() error {
+	return ew.w.Write(ExportColumns)
+}
+
+func (ew *csvExportWriter) write(entry *ExportEntry) error {
+	if err := ew.w.Write(entry.csvRecord()); err != nil {
+		return err
+	}
+	ew.w.Flush()
+
+	return ew.w.Error()
+}
+
+func (ew *csvExportWriter) end() error {
+	ew.w.Flush()
+
+	return ew.w.Error()
+}
+
+type jsonExportWriter struct {
+	w       gin.ResponseWriter
+	entries int
+}
+
+func (ew *jsonExportWriter) begin() error {
+	_, err := ew.w.WriteString("[")
+
+	return err
+}
+
+func (ew *jsonExportWriter) write(entry *ExportEntry) error {
+	if ew.entries > 0 {
+		if _, err := ew.w.WriteString(",\n"); err != nil {
+			return err
+		}
+	}
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err = ew.w.Write(entryJSON); err != nil {
+		return err
+	}
+	ew.entries++
+
+	return nil
+}
+
+func (ew *jsonExportWriter) end() error {
+	_, err := ew.w.WriteString("]\n")
+
+	return err
+}
+
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (ew *ndjsonExportWriter) begin() error {
+	return nil
+}
+
+func (ew *ndjsonExportWriter) write(entry *ExportEntry) error {
+	return ew.encoder.Encode(entry)
+}
+
+func (ew *ndjsonExportWriter) end() error {
+	return nil
+}
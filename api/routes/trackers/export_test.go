@@ -0,0 +1,134 @@
+package trackers_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+)
+
+var exportTestEntries = []struct{ path, deletePath, name, body string }{
+	{"/v1/trackers/games_tracker/add_game_manually", "/v1/trackers/games_tracker/delete_game", "Export Test Game", `{"wait": true, "name": "Export Test Game", "url": "https://example.com/export-game", "cover_img_url": "%s", "priority": "high", "status": "finished", "stars": 4, "purchased_or_gamepass": true, "release_date": "2020-01-02", "started_date": "2021-02-03", "finished_dropped_date": "2021-03-04", "tags": ["Export Test Tag", "RPG"], "developers": ["Export Test Developer"], "publishers": ["Export Test Publisher"], "commentary": "Great, really"}`},
+	{"/v1/trackers/games_tracker/add_game_manually", "/v1/trackers/games_tracker/delete_game", "Export Test Playing Game", `{"wait": true, "name": "Export Test Playing Game", "url": "https://example.com/export-playing", "cover_img_url": "%s", "priority": "low", "status": "in_progress", "release_date": "2020-01-02", "started_date": "2021-02-03", "tags": ["Export Test Tag"]}`},
+	{"/v1/trackers/medias_tracker/add_media_manually", "/v1/trackers/medias_tracker/delete_media", "Export Test Media", `{"wait": true, "name": "Export Test Media", "url": "https://example.com/export-media", "cover_img_url": "%s", "media_type": "movie", "priority": "medium", "status": "not_started", "release_date": "2019-05-06", "genres": ["Export Test Genre"], "staff": ["Export Test Director"]}`},
+}
+
+var exportTestGame = trackers.ExportEntry{
+	Tracker: "game", Name: "Export Test Game", URL: "https://example.com/export-game",
+	Status: trackers.StatusFinished, Priority: trackers.PriorityHigh, Stars: 4, PurchasedOrGamePass: true,
+	ReleaseDate: "2020-01-02", StartedDate: "2021-02-03", FinishedDroppedDate: "2021-03-04",
+	Tags: []string{"Export Test Tag", "RPG"}, Developers: []string{"Export Test Developer"}, Publishers: []string{"Export Test Publisher"},
+	Genres: []string{}, Staff: []string{}, Commentary: "Great, really",
+}
+
+var exportTestMedia = trackers.ExportEntry{
+	Tracker: "media", Name: "Export Test Media", URL: "https://example.com/export-media", MediaType: "movie",
+	Status: trackers.StatusNotStarted, Priority: trackers.PriorityMedium, ReleaseDate: "2019-05-06",
+	Tags: []string{}, Developers: []string{}, Publishers: []string{},
+	Genres: []string{"Export Test Genre"}, Staff: []string{"Export Test Director"},
+	CoverImg: base64.StdEncoding.EncodeToString([]byte("PNG")),
+}
+
+func TestGetExportRoute(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("PNG"))
+	}))
+	defer imageServer.Close()
+
+	router := api.SetupRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, entry := range exportTestEntries {
+		w := serve(http.MethodPost, entry.path, fmt.Sprintf(entry.body, imageServer.URL))
+		if w.Code != http.StatusOK {
+			t.Fatalf("couldn't add the entry: %s", w.Body.String())
+		}
+		defer serve(http.MethodPost, entry.deletePath, fmt.Sprintf(`{"name": %q}`, entry.name))
+	}
+
+	// CSV, with the columns in the stable order
+	w := serve(http.MethodGet, "/v1/trackers/export?tag=Export+Test+Tag&status=finished", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("expected the CSV export, got %d %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRecords := [][]string{
+		trackers.ExportColumns,
+		{"game", "Export Test Game", "https://example.com/export-game", "", "finished", "high", "4", "true",
+			"2020-01-02", "2021-02-03", "2021-03-04",
+			"Export Test Tag|RPG", "Export Test Developer", "Export Test Publisher", "", "",
+			"Great, really", ""},
+	}
+	if !reflect.DeepEqual(records, expectedRecords) {
+		t.Errorf("expected the CSV records %q, got %q", expectedRecords, records)
+	}
+
+	// JSON array
+	w = serve(http.MethodGet, "/v1/trackers/export?format=json&tracker=games&tag=Export+Test+Tag&status=finished&status=in_progress", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Fatalf("expected the JSON export, got %d %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	var entries []trackers.ExportEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("%s: %s", err, w.Body.String())
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Export Test Game", "Export Test Playing Game"}) {
+		t.Fatalf("expected the two test games, got %v", names)
+	}
+	if !reflect.DeepEqual(entries[0], exportTestGame) {
+		t.Errorf("expected the game %+v, got %+v", exportTestGame, entries[0])
+	}
+
+	// Newline-delimited JSON, with the covers
+	w = serve(http.MethodGet, "/v1/trackers/export?format=ndjson&genre=Export+Test+Genre&include_covers=true", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson; charset=utf-8" {
+		t.Fatalf("expected the NDJSON export, got %d %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	entries = nil
+	scanner := bufio.NewScanner(bytes.NewReader(w.Body.Bytes()))
+	for scanner.Scan() {
+		var entry trackers.ExportEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("%s: %s", err, scanner.Text())
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0], exportTestMedia) {
+		t.Errorf("expected only the media %+v, got %+v", exportTestMedia, entries)
+	}
+
+	// A filter of the other tracker excludes the tracker entries
+	w = serve(http.MethodGet, "/v1/trackers/export?format=json&tracker=medias&tag=Export+Test+Tag", "")
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("expected an empty export, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, path := range []string{"/v1/trackers/export?format=xml", "/v1/trackers/export?status=playing", "/v1/trackers/export?tracker=books"} {
+		w := serve(http.MethodGet, path, "")
+		if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+			t.Errorf("%s: expected status code: %d, actual status code: %d", path, http.StatusBadRequest, w.Code)
+		}
+	}
+}
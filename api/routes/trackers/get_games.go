@@ -1,14 +1,13 @@
 package trackers
 
 import (
-	"database/sql"
 	"fmt"
 	"net/http"
-	"path/filepath"
 	"sort"
-	"strings"
 	"time"
 
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/database"
 	"github.com/diogovalentte/dashboard/api/util"
 	"github.com/gin-gonic/gin"
 )
@@ -17,29 +16,37 @@ func GetGame(c *gin.Context) {
 	// Validate request
 	var gameRequest GetGameRequest
 	if err := c.ShouldBindJSON(&gameRequest); err != nil {
-		err = fmt.Errorf("invalid JSON fields, refer to the API documentation")
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		apierror.Respond(c, getBindingError(err, &gameRequest))
 		return
 	}
 
+	// Only admins can get the games of other users, with the user query parameter
+	scope, ok := getUserScope(c, false)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	// Get game
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, cover_img, release_date, tags, developers, publishers, priority,
-  status, stars, purchased_or_gamepass, started_date, finished_dropped_date, commentary
+  user_id, url, name, cover_img, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, commentary
 FROM
   games_tracker
 WHERE
-  name = '%s';`, gameRequest.Name,
+  name = ?
+  AND %s;`, userCondition,
 	)
 
-	games, err := getGamesFromQuery(sqlQuery)
+	games, err := getGamesFromQuery(sqlQuery, append([]interface{}{gameRequest.Name}, args...)...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 	if len(games) < 1 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "game do not exists"})
+		apierror.Respond(c, apierror.NotFound("game do not exists"))
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"game": games[0]})
@@ -50,17 +57,25 @@ type GetGameRequest struct {
 }
 
 func GetAllGames(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, cover_img, release_date, tags, developers, publishers, priority,
-  status, stars, purchased_or_gamepass, started_date, finished_dropped_date, commentary
+  user_id, url, name, cover_img, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, commentary
 FROM
-  games_tracker;`,
+  games_tracker
+WHERE
+  %s;`, userCondition,
 	)
 
-	games, err := getGamesFromQuery(sqlQuery)
+	games, err := getGamesFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -68,21 +83,28 @@ FROM
 }
 
 func GetPlayingGames(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, cover_img, release_date, tags, developers, publishers, priority,
-  status, stars, purchased_or_gamepass, started_date, finished_dropped_date, ""
+  user_id, url, name, cover_img, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, ""
 FROM
   games_tracker
 WHERE
-  status = 3
+  status = %d
+  AND %s
 ORDER BY
-  started_date DESC;`,
+  started_date DESC;`, StatusInProgress, userCondition,
 	)
 
-	games, err := getGamesFromQuery(sqlQuery)
+	games, err := getGamesFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -103,21 +125,28 @@ ORDER BY
 }
 
 func GetToBeReleasedGames(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, cover_img, release_date, tags, developers, publishers, priority,
-  status, stars, purchased_or_gamepass, started_date, finished_dropped_date, ""
+  user_id, url, name, cover_img, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, ""
 FROM
   games_tracker
 WHERE
-  status = 1
+  status = %d
+  AND %s
 ORDER BY
-  release_date;`,
+  release_date;`, StatusToBeReleased, userCondition,
 	)
 
-	games, err := getGamesFromQuery(sqlQuery)
+	games, err := getGamesFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -138,25 +167,32 @@ ORDER BY
 }
 
 func GetNotStartedGames(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, cover_img, release_date, tags, developers, publishers, priority,
-  status, stars, purchased_or_gamepass, started_date, finished_dropped_date, ""
+  user_id, url, name, cover_img, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, ""
 FROM
   games_tracker
 WHERE
-  status = 2
+  status = %d
+  AND %s
 ORDER BY
   CASE
-    WHEN priority = 1 THEN 1
-    WHEN priority = 2 THEN 2
-    WHEN priority = 3 THEN 3
-  END;`,
+    WHEN priority = %d THEN 1
+    WHEN priority = %d THEN 2
+    WHEN priority = %d THEN 3
+  END;`, StatusNotStarted, userCondition, PriorityHigh, PriorityMedium, PriorityLow,
 	)
 
-	games, err := getGamesFromQuery(sqlQuery)
+	games, err := getGamesFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -164,21 +200,28 @@ ORDER BY
 }
 
 func GetFinishedGames(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, cover_img, release_date, tags, developers, publishers, priority,
-  status, stars, purchased_or_gamepass, started_date, finished_dropped_date, ""
+  user_id, url, name, cover_img, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, ""
 FROM
   games_tracker
 WHERE
-  status = 4
+  status = %d
+  AND %s
 ORDER BY
-  finished_dropped_date DESC;`,
+  finished_dropped_date DESC;`, StatusFinished, userCondition,
 	)
 
-	games, err := getGamesFromQuery(sqlQuery)
+	games, err := getGamesFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -199,21 +242,28 @@ ORDER BY
 }
 
 func GetDroppedGames(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, cover_img, release_date, tags, developers, publishers, priority,
-  status, stars, purchased_or_gamepass, started_date, finished_dropped_date, ""
+  user_id, url, name, cover_img, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, ""
 FROM
   games_tracker
 WHERE
-  status = 5
+  status = %d
+  AND %s
 ORDER BY
-  finished_dropped_date DESC;`,
+  finished_dropped_date DESC;`, StatusDropped, userCondition,
 	)
 
-	games, err := getGamesFromQuery(sqlQuery)
+	games, err := getGamesFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -233,39 +283,104 @@ ORDER BY
 	c.JSON(http.StatusOK, gin.H{"games": games})
 }
 
-func getGamesFromQuery(sqlQuery string) ([]*GetGameProperties, error) {
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs/")
+var gamesEntityFilters = []entityFilter{
+	{"tag", gamesTagsTable},
+	{"developer", gamesDevelopersTable},
+	{"publisher", gamesPublishersTable},
+}
+
+// GetFilteredGames returns the games linked to all tags, developers, and publishers
+// in the query parameters, like ?tag=Open World&tag=Western&developer=Rockstar Games
+func GetFilteredGames(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, userArgs := scope.condition("user_id")
+
+	condition, args := getEntityFiltersCondition(c, gamesEntityFilters, "user_id, name")
+	if condition == "" {
+		apierror.Respond(c, apierror.Validation("at least one tag, developer, or publisher query parameter is required"))
+		return
+	}
+
+	sqlQuery := fmt.Sprintf(`
+SELECT
+  user_id, url, name, cover_img, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, ""
+FROM
+  games_tracker
+WHERE
+  %s
+  AND %s
+ORDER BY
+  name;`, condition, userCondition,
+	)
+
+	games, err := getGamesFromQuery(sqlQuery, append(args, userArgs...)...)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"games": games})
+}
+
+func getGamesFromQuery(sqlQuery string, args ...interface{}) ([]*GetGameProperties, error) {
+	var gamesProperties []*GetGameProperties
+	err := forEachGameFromQuery(func(gameProperties *GetGameProperties) error {
+		gamesProperties = append(gamesProperties, gameProperties)
+		return nil
+	}, sqlQuery, args...)
 	if err != nil {
 		return nil, err
 	}
-	dbPath := filepath.Join(configs.Database.FolderPath, "trackers.db")
 
-	db, err := sql.Open("sqlite3", dbPath)
+	return gamesProperties, nil
+}
+
+// forEachGameFromQuery calls fn with each game of the query as it's read from the DB, with its tags, developers, and publishers.
+// The games are not kept in memory, so fn can stream them, like to the response.
+func forEachGameFromQuery(fn func(gameProperties *GetGameProperties) error, sqlQuery string, args ...interface{}) error {
+	configs, err := util.GetConfigs()
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return err
 	}
 	defer db.Close()
 
-	rows, err := db.Query(sqlQuery)
+	// Get the tags, developers, and publishers of the games before the games, to look them up by game
+	tags, err := getEntities(db, gamesTagsTable, sqlQuery, args...)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	developers, err := getEntities(db, gamesDevelopersTable, sqlQuery, args...)
+	if err != nil {
+		return err
+	}
+	publishers, err := getEntities(db, gamesPublishersTable, sqlQuery, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Query(sqlQuery, args...)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
-	var gamesProperties []*GetGameProperties
 	for rows.Next() {
 		gameProperties := GetGameProperties{}
-		var tagsStr string
-		var developersStr string
-		var publishersStr string
 		err = rows.Scan(
+			&gameProperties.UserID,
 			&gameProperties.URL,
 			&gameProperties.Name,
 			&gameProperties.CoverImg,
 			&gameProperties.ReleaseDate,
-			&tagsStr,
-			&developersStr,
-			&publishersStr,
 			&gameProperties.Priority,
 			&gameProperties.Status,
 			&gameProperties.Stars,
@@ -274,19 +389,24 @@ func getGamesFromQuery(sqlQuery string) ([]*GetGameProperties, error) {
 			&gameProperties.FinishedDroppedDate,
 			&gameProperties.Commentary)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		gameProperties.Tags = strings.Split(tagsStr, ",")
-		gameProperties.Developers = strings.Split(developersStr, ",")
-		gameProperties.Publishers = strings.Split(publishersStr, ",")
 
-		gamesProperties = append(gamesProperties, &gameProperties)
+		key := entryKey{gameProperties.UserID, gameProperties.Name}
+		gameProperties.Tags = nonNilSlice(tags[key])
+		gameProperties.Developers = nonNilSlice(developers[key])
+		gameProperties.Publishers = nonNilSlice(publishers[key])
+		if err = fn(&gameProperties); err != nil {
+			return err
+		}
 	}
 
-	return gamesProperties, nil
+	return rows.Err()
 }
 
 type GetGameProperties struct {
+	// ID of the user that owns the game
+	UserID              int64
 	URL                 string
 	Name                string
 	CoverImg            []byte
@@ -294,8 +414,8 @@ type GetGameProperties struct {
 	Tags                []string
 	Developers          []string
 	Publishers          []string
-	Priority            int
-	Status              int
+	Priority            Priority
+	Status              Status
 	Stars               int
 	PurchasedOrGamePass bool
 	StartedDate         time.Time
@@ -198,6 +198,34 @@ func TestDroppedGamesRoute(t *testing.T) {
 	}
 }
 
+func TestGetFilteredGamesRoute(t *testing.T) {
+	router := api.SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/v1/trackers/games_tracker/get_filtered_games?tag=Western&developer=Rockstar+Games", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	router.ServeHTTP(w, req)
+
+	var res getGamesResponse
+	jsonBytes := w.Body.Bytes()
+	if err := json.Unmarshal(jsonBytes, &res); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if http.StatusOK != w.Code {
+		t.Errorf("expected status code: %d, actual status code: %d", http.StatusOK, w.Code)
+	}
+
+	games := res.Games
+	for _, game := range games {
+		t.Log(fmt.Sprintf("Game: %s", game.Name))
+	}
+}
+
 type getGamesResponse struct {
 	Games []trackers.GameProperties `json:"games"`
 }
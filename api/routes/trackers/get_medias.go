@@ -1,44 +1,52 @@
 package trackers
 
 import (
-	"database/sql"
 	"fmt"
-	"github.com/diogovalentte/dashboard/api/util"
-	"github.com/gin-gonic/gin"
 	"net/http"
-	"path/filepath"
 	"sort"
-	"strings"
 	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
 )
 
 func GetMedia(c *gin.Context) {
 	// Validate request
 	var mediaRequest GetMediaRequest
 	if err := c.ShouldBindJSON(&mediaRequest); err != nil {
-		err = fmt.Errorf("invalid JSON fields, refer to the API documentation")
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		apierror.Respond(c, getBindingError(err, &mediaRequest))
 		return
 	}
 
-	// Get game
+	// Only admins can get the medias of other users, with the user query parameter
+	scope, ok := getUserScope(c, false)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
+	// Get media
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, media_type, cover_img, release_date, genres, staff,
-  priority, status, stars, started_date, finished_dropped_date, commentary
+  user_id, url, name, media_type, cover_img, release_date, priority,
+  status, stars, started_date, finished_dropped_date, commentary
 FROM
   medias_tracker
 WHERE
-  name = '%s';`, mediaRequest.Name,
+  name = ?
+  AND %s;`, userCondition,
 	)
 
-	medias, err := getMediasFromQuery(sqlQuery)
+	medias, err := getMediasFromQuery(sqlQuery, append([]interface{}{mediaRequest.Name}, args...)...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 	if len(medias) < 1 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "media do not exists"})
+		apierror.Respond(c, apierror.NotFound("media do not exists"))
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"media": medias[0]})
@@ -49,17 +57,25 @@ type GetMediaRequest struct {
 }
 
 func GetAllMedias(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, media_type, cover_img, release_date, genres, staff,
-  priority, status, stars, started_date, finished_dropped_date, commentary
+  user_id, url, name, media_type, cover_img, release_date, priority,
+  status, stars, started_date, finished_dropped_date, commentary
 FROM
-  medias_tracker;`,
+  medias_tracker
+WHERE
+  %s;`, userCondition,
 	)
 
-	medias, err := getMediasFromQuery(sqlQuery)
+	medias, err := getMediasFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -67,21 +83,28 @@ FROM
 }
 
 func GetWatchingReadingMedias(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, media_type, cover_img, release_date, genres, staff,
-  priority, status, stars, started_date, finished_dropped_date, ""
+  user_id, url, name, media_type, cover_img, release_date, priority,
+  status, stars, started_date, finished_dropped_date, ""
 FROM
   medias_tracker
 WHERE
-  status = 3
+  status = %d
+  AND %s
 ORDER BY
-  started_date DESC;`,
+  started_date DESC;`, StatusInProgress, userCondition,
 	)
 
-	medias, err := getMediasFromQuery(sqlQuery)
+	medias, err := getMediasFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -102,21 +125,28 @@ ORDER BY
 }
 
 func GetToBeReleasedMedias(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, media_type, cover_img, release_date, genres, staff,
-  priority, status, stars, started_date, finished_dropped_date, ""
+  user_id, url, name, media_type, cover_img, release_date, priority,
+  status, stars, started_date, finished_dropped_date, ""
 FROM
   medias_tracker
 WHERE
-  status = 1
+  status = %d
+  AND %s
 ORDER BY
-  release_date;`,
+  release_date;`, StatusToBeReleased, userCondition,
 	)
 
-	medias, err := getMediasFromQuery(sqlQuery)
+	medias, err := getMediasFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -137,25 +167,32 @@ ORDER BY
 }
 
 func GetNotStartedMedias(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, media_type, cover_img, release_date, genres, staff,
-  priority, status, stars, started_date, finished_dropped_date, ""
+  user_id, url, name, media_type, cover_img, release_date, priority,
+  status, stars, started_date, finished_dropped_date, ""
 FROM
   medias_tracker
 WHERE
-  status = 2
+  status = %d
+  AND %s
 ORDER BY
   CASE
-    WHEN priority = 1 THEN 1
-    WHEN priority = 2 THEN 2
-    WHEN priority = 3 THEN 3
-  END;`,
+    WHEN priority = %d THEN 1
+    WHEN priority = %d THEN 2
+    WHEN priority = %d THEN 3
+  END;`, StatusNotStarted, userCondition, PriorityHigh, PriorityMedium, PriorityLow,
 	)
 
-	medias, err := getMediasFromQuery(sqlQuery)
+	medias, err := getMediasFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -163,21 +200,28 @@ ORDER BY
 }
 
 func GetFinishedMedias(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, media_type, cover_img, release_date, genres, staff,
-  priority, status, stars, started_date, finished_dropped_date, ""
+  user_id, url, name, media_type, cover_img, release_date, priority,
+  status, stars, started_date, finished_dropped_date, ""
 FROM
   medias_tracker
 WHERE
-  status = 4
+  status = %d
+  AND %s
 ORDER BY
-  finished_dropped_date DESC;`,
+  finished_dropped_date DESC;`, StatusFinished, userCondition,
 	)
 
-	medias, err := getMediasFromQuery(sqlQuery)
+	medias, err := getMediasFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -198,21 +242,28 @@ ORDER BY
 }
 
 func GetDroppedMedias(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
 	sqlQuery := fmt.Sprintf(`
 SELECT
-  url, name, media_type, cover_img, release_date, genres, staff,
-  priority, status, stars, started_date, finished_dropped_date, ""
+  user_id, url, name, media_type, cover_img, release_date, priority,
+  status, stars, started_date, finished_dropped_date, ""
 FROM
   medias_tracker
 WHERE
-  status = 5
+  status = %d
+  AND %s
 ORDER BY
-  finished_dropped_date DESC;`,
+  finished_dropped_date DESC;`, StatusDropped, userCondition,
 	)
 
-	medias, err := getMediasFromQuery(sqlQuery)
+	medias, err := getMediasFromQuery(sqlQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -232,38 +283,100 @@ ORDER BY
 	c.JSON(http.StatusOK, gin.H{"medias": medias})
 }
 
-func getMediasFromQuery(sqlQuery string) ([]*GetMediaProperties, error) {
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs/")
+var mediasEntityFilters = []entityFilter{
+	{"genre", mediasGenresTable},
+	{"staff", mediasStaffTable},
+}
+
+// GetFilteredMedias returns the medias linked to all genres and staff members
+// in the query parameters, like ?genre=Action&genre=Crime&staff=Christopher Nolan
+func GetFilteredMedias(c *gin.Context) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+	userCondition, userArgs := scope.condition("user_id")
+
+	condition, args := getEntityFiltersCondition(c, mediasEntityFilters, "user_id, name")
+	if condition == "" {
+		apierror.Respond(c, apierror.Validation("at least one genre or staff query parameter is required"))
+		return
+	}
+
+	sqlQuery := fmt.Sprintf(`
+SELECT
+  user_id, url, name, media_type, cover_img, release_date, priority,
+  status, stars, started_date, finished_dropped_date, ""
+FROM
+  medias_tracker
+WHERE
+  %s
+  AND %s
+ORDER BY
+  name;`, condition, userCondition,
+	)
+
+	medias, err := getMediasFromQuery(sqlQuery, append(args, userArgs...)...)
 	if err != nil {
-		return nil, err
+		apierror.Respond(c, err)
+		return
 	}
-	dbPath := filepath.Join(configs.Database.FolderPath, "trackers.db")
 
-	db, err := sql.Open("sqlite3", dbPath)
+	c.JSON(http.StatusOK, gin.H{"medias": medias})
+}
+
+func getMediasFromQuery(sqlQuery string, args ...interface{}) ([]*GetMediaProperties, error) {
+	var mediasProperties []*GetMediaProperties
+	err := forEachMediaFromQuery(func(mediaProperties *GetMediaProperties) error {
+		mediasProperties = append(mediasProperties, mediaProperties)
+		return nil
+	}, sqlQuery, args...)
 	if err != nil {
 		return nil, err
 	}
+
+	return mediasProperties, nil
+}
+
+// forEachMediaFromQuery calls fn with each media of the query as it's read from the DB, with its genres and staff.
+// The medias are not kept in memory, so fn can stream them, like to the response.
+func forEachMediaFromQuery(fn func(mediaProperties *GetMediaProperties) error, sqlQuery string, args ...interface{}) error {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		return err
+	}
+
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
-	rows, err := db.Query(sqlQuery)
+	// Get the genres and staff of the medias before the medias, to look them up by media
+	genres, err := getEntities(db, mediasGenresTable, sqlQuery, args...)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	staff, err := getEntities(db, mediasStaffTable, sqlQuery, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Query(sqlQuery, args...)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
-	var mediasProperties []*GetMediaProperties
 	for rows.Next() {
 		mediaProperties := GetMediaProperties{}
-		var genresStr string
-		var staffStr string
 		err = rows.Scan(
+			&mediaProperties.UserID,
 			&mediaProperties.URL,
 			&mediaProperties.Name,
 			&mediaProperties.MediaType,
 			&mediaProperties.CoverImg,
 			&mediaProperties.ReleaseDate,
-			&genresStr,
-			&staffStr,
 			&mediaProperties.Priority,
 			&mediaProperties.Status,
 			&mediaProperties.Stars,
@@ -271,27 +384,32 @@ func getMediasFromQuery(sqlQuery string) ([]*GetMediaProperties, error) {
 			&mediaProperties.FinishedDroppedDate,
 			&mediaProperties.Commentary)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		mediaProperties.Genres = strings.Split(genresStr, ",")
-		mediaProperties.Staff = strings.Split(staffStr, ",")
 
-		mediasProperties = append(mediasProperties, &mediaProperties)
+		key := entryKey{mediaProperties.UserID, mediaProperties.Name}
+		mediaProperties.Genres = nonNilSlice(genres[key])
+		mediaProperties.Staff = nonNilSlice(staff[key])
+		if err = fn(&mediaProperties); err != nil {
+			return err
+		}
 	}
 
-	return mediasProperties, nil
+	return rows.Err()
 }
 
 type GetMediaProperties struct {
+	// ID of the user that owns the media
+	UserID              int64
 	URL                 string
 	Name                string
-	MediaType           int
+	MediaType           MediaType
 	CoverImg            []byte
 	ReleaseDate         time.Time
 	Genres              []string
 	Staff               []string
-	Priority            int
-	Status              int
+	Priority            Priority
+	Status              Status
 	Stars               int
 	StartedDate         time.Time
 	FinishedDroppedDate time.Time
@@ -190,6 +190,34 @@ func TestGetDroppedMediasRoute(t *testing.T) {
 	}
 }
 
+func TestGetFilteredMediasRoute(t *testing.T) {
+	router := api.SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/v1/trackers/medias_tracker/get_filtered_medias?genre=Action&staff=Christopher+Nolan", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	router.ServeHTTP(w, req)
+
+	var res getMediasResponse
+	jsonBytes := w.Body.Bytes()
+	if err := json.Unmarshal(jsonBytes, &res); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if http.StatusOK != w.Code {
+		t.Errorf("expected status code: %d, actual status code: %d", http.StatusOK, w.Code)
+	}
+
+	medias := res.Medias
+	for _, media := range medias {
+		t.Log(fmt.Sprintf("Media: %s", media.Name))
+	}
+}
+
 type getMediasResponse struct {
 	Medias []trackers.MediaProperties `json:"medias"`
 }
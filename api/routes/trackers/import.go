@@ -0,0 +1,775 @@
+package trackers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// Import formats:
+// csv, json, ndjson - the formats of the export, only the name column is required
+// imdb - the ratings CSV exported from IMDB
+// letterboxd - the diary CSV exported from Letterboxd, also works with the ratings and watched CSVs
+var importFormats = []string{"csv", "json", "ndjson", "imdb", "letterboxd"}
+
+// Fields of the entries that the updates keep when the import file doesn't have them.
+// The empty dates are kept too.
+var importKeptFields = []string{"stars", "purchased_or_gamepass", "commentary"}
+
+// Actions of the import entries
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionSkip   = "skip"
+	ImportActionFailed = "failed"
+)
+
+// An ImportRow is an entry read from an import file, or the error that made it unreadable
+type ImportRow struct {
+	// Line of the CSV file or position of the JSON entry, starting from 1
+	Line  int
+	Entry *ExportEntry
+	Err   error
+}
+
+// ReadImportRows reads the entries of an import file in one of the import formats.
+// An error is only returned when the whole file can't be read, errors of single entries are in the rows.
+func ReadImportRows(r io.Reader, format string) ([]*ImportRow, error) {
+	switch format {
+	case "csv":
+		return readCSVImportRows(r, exportCSVToEntry)
+	case "json":
+		return readJSONImportRows(r)
+	case "ndjson":
+		return readNDJSONImportRows(r)
+	case "imdb":
+		return readCSVImportRows(r, imdbCSVToEntry)
+	case "letterboxd":
+		return readCSVImportRows(r, letterboxdCSVToEntry)
+	default:
+		return nil, fmt.Errorf("invalid format %q, it should be one of %s", format, strings.Join(importFormats, ", "))
+	}
+}
+
+// csvRow gets the values of a CSV record by the column names of the header
+type csvRow struct {
+	columns map[string]int
+	record  []string
+}
+
+func (row *csvRow) get(column string) string {
+	i, ok := row.columns[column]
+	if !ok || i >= len(row.record) {
+		return ""
+	}
+
+	return strings.TrimSpace(row.record[i])
+}
+
+func readCSVImportRows(r io.Reader, toEntry func(row *csvRow) (*ExportEntry, error)) ([]*ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the CSV header: %s", err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))] = i
+	}
+
+	rows := []*ImportRow{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the CSV line %d: %s", line, err)
+		}
+		entry, err := toEntry(&csvRow{columns: columns, record: record})
+		rows = append(rows, &ImportRow{Line: line, Entry: entry, Err: err})
+	}
+
+	return rows, nil
+}
+
+func splitImportList(s, separator string) []string {
+	list := []string{}
+	for _, value := range strings.Split(s, separator) {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			list = append(list, value)
+		}
+	}
+
+	return list
+}
+
+func exportCSVToEntry(row *csvRow) (*ExportEntry, error) {
+	entry := &ExportEntry{
+		Tracker:             row.get("tracker"),
+		Name:                row.get("name"),
+		URL:                 row.get("url"),
+		MediaType:           row.get("media_type"),
+		ReleaseDate:         row.get("release_date"),
+		StartedDate:         row.get("started_date"),
+		FinishedDroppedDate: row.get("finished_dropped_date"),
+		Tags:                splitImportList(row.get("tags"), ExportListSeparator),
+		Developers:          splitImportList(row.get("developers"), ExportListSeparator),
+		Publishers:          splitImportList(row.get("publishers"), ExportListSeparator),
+		Genres:              splitImportList(row.get("genres"), ExportListSeparator),
+		Staff:               splitImportList(row.get("staff"), ExportListSeparator),
+		Commentary:          row.get("commentary"),
+		CoverImg:            row.get("cover_img"),
+		missing:             map[string]bool{},
+	}
+	for _, field := range importKeptFields {
+		entry.missing[field] = row.get(field) == ""
+	}
+
+	var err error
+	if status := row.get("status"); status != "" {
+		entry.Status, err = ParseStatus(status)
+		if err != nil {
+			return entry, err
+		}
+	}
+	if priority := row.get("priority"); priority != "" {
+		entry.Priority, err = ParsePriority(priority)
+		if err != nil {
+			return entry, err
+		}
+	}
+	if stars := row.get("stars"); stars != "" {
+		entry.Stars, err = strconv.Atoi(stars)
+		if err != nil {
+			return entry, fmt.Errorf("invalid stars %q", stars)
+		}
+	}
+	if purchased := row.get("purchased_or_gamepass"); purchased != "" {
+		entry.PurchasedOrGamePass, err = strconv.ParseBool(purchased)
+		if err != nil {
+			return entry, fmt.Errorf("invalid purchased_or_gamepass %q", purchased)
+		}
+	}
+
+	return entry, nil
+}
+
+func readJSONImportRows(r io.Reader) ([]*ImportRow, error) {
+	var messages []json.RawMessage
+	err := json.NewDecoder(r).Decode(&messages)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the JSON array: %s", err)
+	}
+
+	rows := make([]*ImportRow, 0, len(messages))
+	for i, message := range messages {
+		var entry ExportEntry
+		err := json.Unmarshal(message, &entry)
+		entry.missing = getJSONEntryMissingFields(message)
+		rows = append(rows, &ImportRow{Line: i + 1, Entry: &entry, Err: getEntryDecodeError(err)})
+	}
+
+	return rows, nil
+}
+
+// getEntryDecodeError returns the error of decoding an entry, with the message of a field error for the values of the wrong type
+func getEntryDecodeError(err error) error {
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		field := typeError.Field
+		if field == "" {
+			field = findJSONField(reflect.TypeOf(ExportEntry{}), typeError.Type, "")
+		}
+		return errors.New(getTypeFieldError(typeError, field).Message)
+	}
+
+	return err
+}
+
+// getJSONEntryMissingFields returns which of the importKeptFields the JSON entry doesn't have or has as null
+func getJSONEntryMissingFields(message []byte) map[string]bool {
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(message, &fields)
+
+	missing := map[string]bool{}
+	for _, field := range importKeptFields {
+		value, ok := fields[field]
+		missing[field] = !ok || string(value) == "null"
+	}
+
+	return missing
+}
+
+func readNDJSONImportRows(r io.Reader) ([]*ImportRow, error) {
+	rows := []*ImportRow{}
+	scanner := bufio.NewScanner(r)
+	// Lines with cover images can be bigger than the default limit
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		message := bytes.TrimSpace(scanner.Bytes())
+		if len(message) == 0 {
+			continue
+		}
+		var entry ExportEntry
+		err := json.Unmarshal(message, &entry)
+		entry.missing = getJSONEntryMissingFields(message)
+		rows = append(rows, &ImportRow{Line: line, Entry: &entry, Err: getEntryDecodeError(err)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read the NDJSON lines: %s", err)
+	}
+
+	return rows, nil
+}
+
+// imdbCSVToEntry maps a row of the IMDB ratings CSV to a finished media, or to a finished game for video games.
+// The 1-10 rating is converted to 1-5 stars.
+func imdbCSVToEntry(row *csvRow) (*ExportEntry, error) {
+	entry := &ExportEntry{
+		Name:                row.get("Title"),
+		URL:                 row.get("URL"),
+		Status:              StatusFinished,
+		ReleaseDate:         row.get("Release Date"),
+		FinishedDroppedDate: row.get("Date Rated"),
+		Genres:              splitImportList(row.get("Genres"), ","),
+		Staff:               splitImportList(row.get("Directors"), ","),
+		missing: map[string]bool{
+			"stars":                 row.get("Your Rating") == "",
+			"purchased_or_gamepass": true,
+			"commentary":            true,
+		},
+	}
+	if entry.URL == "" && row.get("Const") != "" {
+		entry.URL = fmt.Sprintf("https://www.imdb.com/title/%s/", row.get("Const"))
+	}
+
+	titleType := strings.ToLower(strings.ReplaceAll(row.get("Title Type"), " ", ""))
+	switch titleType {
+	case "movie", "tvmovie", "short", "tvshort", "tvspecial", "video":
+		entry.Tracker = "media"
+		entry.MediaType = MediaTypeMovie.String()
+	case "tvseries", "tvminiseries":
+		entry.Tracker = "media"
+		entry.MediaType = MediaTypeSeries.String()
+	case "videogame":
+		entry.Tracker = "game"
+		entry.Genres = []string{}
+		entry.Staff = []string{}
+	default:
+		return entry, fmt.Errorf("unsupported IMDB title type %q", row.get("Title Type"))
+	}
+
+	if rating := row.get("Your Rating"); rating != "" {
+		stars, err := strconv.Atoi(rating)
+		if err != nil || stars < 1 || stars > 10 {
+			return entry, fmt.Errorf("invalid IMDB rating %q", rating)
+		}
+		entry.Stars = (stars + 1) / 2
+	}
+
+	return entry, nil
+}
+
+// letterboxdCSVToEntry maps a row of the Letterboxd diary CSV to a finished movie.
+// Half stars are rounded up.
+func letterboxdCSVToEntry(row *csvRow) (*ExportEntry, error) {
+	entry := &ExportEntry{
+		Tracker:             "media",
+		Name:                row.get("Name"),
+		URL:                 row.get("Letterboxd URI"),
+		MediaType:           MediaTypeMovie.String(),
+		Status:              StatusFinished,
+		FinishedDroppedDate: row.get("Watched Date"),
+		Genres:              []string{},
+		Staff:               []string{},
+		missing: map[string]bool{
+			"stars":                 row.get("Rating") == "",
+			"purchased_or_gamepass": true,
+			"commentary":            true,
+		},
+	}
+	if entry.FinishedDroppedDate == "" {
+		entry.FinishedDroppedDate = row.get("Date")
+	}
+
+	if rating := row.get("Rating"); rating != "" {
+		stars, err := strconv.ParseFloat(rating, 64)
+		if err != nil || stars < 0 || stars > 5 {
+			return entry, fmt.Errorf("invalid Letterboxd rating %q", rating)
+		}
+		entry.Stars = int(math.Round(stars))
+	}
+
+	return entry, nil
+}
+
+// An ImportItem is what the import does, or would do in a dry run, with an entry
+type ImportItem struct {
+	Line    int    `json:"line"`
+	Tracker string `json:"tracker"`
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+	// Why the entry was skipped or failed
+	Reason string `json:"reason,omitempty"`
+	game   *GameProperties
+	media  *MediaProperties
+}
+
+// ImportReport sums up the import items
+type ImportReport struct {
+	DryRun  bool          `json:"dry_run"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Skipped int           `json:"skipped"`
+	Failed  int           `json:"failed"`
+	Items   []*ImportItem `json:"items"`
+}
+
+func newImportReport(items []*ImportItem, dryRun bool) *ImportReport {
+	report := ImportReport{DryRun: dryRun, Items: items}
+	for _, item := range items {
+		switch item.Action {
+		case ImportActionCreate:
+			report.Created++
+		case ImportActionUpdate:
+			report.Updated++
+		case ImportActionSkip:
+			report.Skipped++
+		case ImportActionFailed:
+			report.Failed++
+		}
+	}
+
+	return &report
+}
+
+func (report *ImportReport) summary() string {
+	return fmt.Sprintf("%d created, %d updated, %d skipped, %d failed", report.Created, report.Updated, report.Skipped, report.Failed)
+}
+
+// An ExistingEntry has the properties of an entry already in a tracker that the updates keep when the import file doesn't have them
+type ExistingEntry struct {
+	Status              Status
+	Priority            Priority
+	Stars               int
+	PurchasedOrGamePass bool
+	Commentary          string
+}
+
+// PlanImport decides what to do with each import row.
+// Entries whose name already exists in their tracker are updated if update is true, else skipped.
+// Only the first entry of a name is imported, the next ones are skipped.
+// Entries without a tracker use the default tracker ("games" or "medias"), if any.
+// New entries without a status or priority are not started and have medium priority,
+// updated entries keep their current status, priority, and the importKeptFields the import file doesn't have.
+func PlanImport(rows []*ImportRow, existingGames, existingMedias map[string]*ExistingEntry, update bool, defaultTracker string) []*ImportItem {
+	seen := map[string]map[string]bool{"game": {}, "media": {}}
+	items := make([]*ImportItem, 0, len(rows))
+	for _, row := range rows {
+		item := &ImportItem{Line: row.Line, Action: ImportActionSkip}
+		items = append(items, item)
+		if row.Entry != nil {
+			item.Name = row.Entry.Name
+			item.Tracker = getImportEntryTracker(row.Entry.Tracker, defaultTracker)
+		}
+		if row.Err != nil {
+			item.Reason = row.Err.Error()
+			continue
+		}
+		if item.Name == "" {
+			item.Reason = "missing name"
+			continue
+		}
+
+		var existing map[string]*ExistingEntry
+		switch item.Tracker {
+		case "game":
+			existing = existingGames
+		case "media":
+			existing = existingMedias
+		default:
+			item.Reason = fmt.Sprintf("unknown tracker %q, it should be game or media", row.Entry.Tracker)
+			continue
+		}
+
+		entry := *row.Entry
+		existingEntry, exists := existing[item.Name]
+		if exists && update {
+			if entry.Status == 0 {
+				entry.Status = existingEntry.Status
+			}
+			if entry.Priority == 0 {
+				entry.Priority = existingEntry.Priority
+			}
+			if entry.missing["stars"] {
+				entry.Stars = existingEntry.Stars
+			}
+			if entry.missing["purchased_or_gamepass"] {
+				entry.PurchasedOrGamePass = existingEntry.PurchasedOrGamePass
+			}
+			if entry.missing["commentary"] {
+				entry.Commentary = existingEntry.Commentary
+			}
+		}
+
+		var err error
+		if item.Tracker == "game" {
+			item.game, err = importEntryToGameProperties(&entry)
+		} else {
+			item.media, err = importEntryToMediaProperties(&entry)
+		}
+		if err != nil {
+			item.Reason = err.Error()
+			continue
+		}
+
+		if seen[item.Tracker][item.Name] {
+			item.Reason = "duplicated in the import"
+			continue
+		}
+		seen[item.Tracker][item.Name] = true
+
+		if exists {
+			if !update {
+				item.Reason = "already exists"
+				continue
+			}
+			item.Action = ImportActionUpdate
+		} else {
+			item.Action = ImportActionCreate
+		}
+	}
+
+	return items
+}
+
+func getImportEntryTracker(tracker, defaultTracker string) string {
+	if tracker == "" {
+		tracker = defaultTracker
+	}
+	switch tracker {
+	case "game", "games":
+		return "game"
+	case "media", "medias":
+		return "media"
+	default:
+		return tracker
+	}
+}
+
+func validateImportEntry(entry *ExportEntry) (Status, Priority, []byte, error) {
+	status, priority := entry.Status, entry.Priority
+	if status == 0 {
+		status = StatusNotStarted
+	}
+	if priority == 0 {
+		priority = PriorityMedium
+	}
+	if !status.IsValid() {
+		return 0, 0, nil, fmt.Errorf("invalid status %d", status)
+	}
+	if !priority.IsValid() {
+		return 0, 0, nil, fmt.Errorf("invalid priority %d", priority)
+	}
+	if entry.Stars < 0 || entry.Stars > 5 {
+		return 0, 0, nil, fmt.Errorf("invalid stars %d, it should be between 0 and 5", entry.Stars)
+	}
+
+	var coverImg []byte
+	if entry.CoverImg != "" {
+		var err error
+		coverImg, err = base64.StdEncoding.DecodeString(entry.CoverImg)
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("invalid base64 cover image")
+		}
+	}
+
+	return status, priority, coverImg, nil
+}
+
+func importEntryToGameProperties(entry *ExportEntry) (*GameProperties, error) {
+	status, priority, coverImg, err := validateImportEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	gp := &GameProperties{
+		URL:                    entry.URL,
+		Priority:               priority,
+		Status:                 status,
+		Stars:                  entry.Stars,
+		PurchasedOrGamePass:    entry.PurchasedOrGamePass,
+		Name:                   entry.Name,
+		CoverImg:               coverImg,
+		Tags:                   entry.Tags,
+		Developers:             entry.Developers,
+		Publishers:             entry.Publishers,
+		ReleaseDateStr:         entry.ReleaseDate,
+		StartedDateStr:         entry.StartedDate,
+		FinishedDroppedDateStr: entry.FinishedDroppedDate,
+		Commentary:             entry.Commentary,
+	}
+	err = SetStructDateFields(gp)
+	if err != nil {
+		return nil, err
+	}
+
+	return gp, nil
+}
+
+func importEntryToMediaProperties(entry *ExportEntry) (*MediaProperties, error) {
+	status, priority, coverImg, err := validateImportEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+	if entry.MediaType == "" {
+		return nil, fmt.Errorf("missing media type")
+	}
+	mediaType, err := ParseMediaType(entry.MediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	mp := &MediaProperties{
+		URL:                    entry.URL,
+		Priority:               priority,
+		Status:                 status,
+		Stars:                  entry.Stars,
+		MediaType:              mediaType,
+		Name:                   entry.Name,
+		CoverImg:               coverImg,
+		Genres:                 entry.Genres,
+		Staff:                  entry.Staff,
+		ReleaseDateStr:         entry.ReleaseDate,
+		StartedDateStr:         entry.StartedDate,
+		FinishedDroppedDateStr: entry.FinishedDroppedDate,
+		Commentary:             entry.Commentary,
+	}
+	err = SetStructDateFields(mp)
+	if err != nil {
+		return nil, err
+	}
+
+	return mp, nil
+}
+
+// getExistingEntries returns the user's entries of a tracker by their names
+func getExistingEntries(configs *util.Configs, tt trackerTable, userID int64) (map[string]*ExistingEntry, error) {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// The medias don't have the purchased_or_gamepass column
+	purchasedColumn := "FALSE"
+	if tt.table == gamesTrackerTable.table {
+		purchasedColumn = "COALESCE(purchased_or_gamepass, FALSE)"
+	}
+	rows, err := db.Query(fmt.Sprintf("SELECT name, status, priority, COALESCE(stars, 0), %s, COALESCE(commentary, '') FROM %s WHERE user_id = ?;", purchasedColumn, tt.table), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := map[string]*ExistingEntry{}
+	for rows.Next() {
+		var name string
+		var entry ExistingEntry
+		if err = rows.Scan(&name, &entry.Status, &entry.Priority, &entry.Stars, &entry.PurchasedOrGamePass, &entry.Commentary); err != nil {
+			return nil, err
+		}
+		entries[name] = &entry
+	}
+
+	return entries, rows.Err()
+}
+
+// executeImport creates and updates the entries of the planned items.
+// Updates change the status, priority, stars, dates, and commentary, keeping the other fields.
+// The fields the import file doesn't have were already set to the current values by PlanImport.
+func executeImport(currentJob *job.Job, items []*ImportItem, configs *util.Configs) *ImportReport {
+	for i, item := range items {
+		if item.Action != ImportActionCreate && item.Action != ImportActionUpdate {
+			continue
+		}
+		currentJob.SetExecutingStateWithValue(fmt.Sprintf("Importing entry %d of %d", i+1, len(items)), item.Name)
+
+		var err error
+		switch {
+		case item.game != nil && item.Action == ImportActionCreate:
+			err = insertGameIntoDB(currentJob.UserID, item.game)
+		case item.game != nil:
+			err = updateGame(currentJob.UserID, &UpdateGameRequest{
+				Name:                   item.game.Name,
+				Priority:               item.game.Priority,
+				Status:                 item.game.Status,
+				Stars:                  item.game.Stars,
+				PurchasedGamePass:      item.game.PurchasedOrGamePass,
+				StartedDateStr:         importDateStr(item.game.StartedDateStr),
+				StartedDate:            item.game.StartedDate,
+				FinishedDroppedDateStr: importDateStr(item.game.FinishedDroppedDateStr),
+				FinishedDroppedDate:    item.game.FinishedDroppedDate,
+				ReleaseDateStr:         importDateStr(item.game.ReleaseDateStr),
+				ReleaseDate:            item.game.ReleaseDate,
+				Commentary:             item.game.Commentary,
+			}, configs)
+		case item.media != nil && item.Action == ImportActionCreate:
+			err = insertMediaIntoDB(currentJob.UserID, item.media)
+		case item.media != nil:
+			err = updateMedia(currentJob.UserID, &UpdateMediaRequest{
+				Name:                   item.media.Name,
+				MediaType:              item.media.MediaType,
+				Priority:               item.media.Priority,
+				Status:                 item.media.Status,
+				Stars:                  item.media.Stars,
+				StartedDateStr:         importDateStr(item.media.StartedDateStr),
+				StartedDate:            item.media.StartedDate,
+				FinishedDroppedDateStr: importDateStr(item.media.FinishedDroppedDateStr),
+				FinishedDroppedDate:    item.media.FinishedDroppedDate,
+				ReleaseDateStr:         importDateStr(item.media.ReleaseDateStr),
+				ReleaseDate:            item.media.ReleaseDate,
+				Commentary:             item.media.Commentary,
+			}, configs)
+		}
+		if err != nil {
+			item.Action = ImportActionFailed
+			item.Reason = err.Error()
+		}
+	}
+
+	return newImportReport(items, false)
+}
+
+// importDateStr returns the date of an imported entry to update, nil to keep the current date if the entry has none
+func importDateStr(date string) *string {
+	if date == "" {
+		return nil
+	}
+
+	return &date
+}
+
+// getImportFile returns the "file" field of a multipart form, or else the request body
+func getImportFile(c *gin.Context) (io.ReadCloser, error) {
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get the file field of the form: %s", err)
+		}
+		return fileHeader.Open()
+	}
+
+	return c.Request.Body, nil
+}
+
+// ImportTrackers imports games and medias from a file sent in the request body
+// or in the "file" field of a multipart form.
+//
+// Query parameters:
+// format - csv (default), json, ndjson, imdb, or letterboxd
+// tracker - games or medias, the tracker of the entries without one
+// update - true to update the entries that already exist, else they're skipped
+// dry_run - true to only report what would be created, updated, or skipped
+// wait - true to wait for the import to be done before responding
+func ImportTrackers(c *gin.Context) {
+	// Create job
+	currentJob := job.Job{
+		Task:      "Import games and medias into the trackers database",
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
+	}
+
+	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
+	if !ok {
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
+		return
+	}
+	jobsList.AddJob(&currentJob)
+	currentJob.SetStartingState("Reading import file")
+
+	file, err := getImportFile(c)
+	if err != nil {
+		currentJob.SetFailedState(err)
+		apierror.Respond(c, apierror.Validation(err.Error()))
+		return
+	}
+	defer file.Close()
+
+	rows, err := ReadImportRows(file, c.DefaultQuery("format", "csv"))
+	if err != nil {
+		currentJob.SetFailedState(err)
+		apierror.Respond(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		currentJob.SetFailedState(err)
+		apierror.Respond(c, err)
+		return
+	}
+	existingGames, err := getExistingEntries(configs, gamesTrackerTable, currentJob.UserID)
+	if err != nil {
+		currentJob.SetFailedState(err)
+		apierror.Respond(c, err)
+		return
+	}
+	existingMedias, err := getExistingEntries(configs, mediasTrackerTable, currentJob.UserID)
+	if err != nil {
+		currentJob.SetFailedState(err)
+		apierror.Respond(c, err)
+		return
+	}
+
+	items := PlanImport(rows, existingGames, existingMedias, c.Query("update") == "true", c.Query("tracker"))
+
+	if c.Query("dry_run") == "true" {
+		report := newImportReport(items, true)
+		currentJob.SetCompletedStateWithValue("Dry run done", report.summary())
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	if c.Query("wait") != "true" {
+		go importTask(&currentJob, items, configs)
+		c.JSON(http.StatusOK, gin.H{"message": "Job created with success"})
+	} else {
+		report := importTask(&currentJob, items, configs)
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func importTask(currentJob *job.Job, items []*ImportItem, configs *util.Configs) *ImportReport {
+	report := executeImport(currentJob, items, configs)
+	if report.Failed > 0 {
+		currentJob.SetFailedState(fmt.Errorf("couldn't import some entries: %s", report.summary()))
+	} else {
+		currentJob.SetCompletedStateWithValue("Import done", report.summary())
+	}
+
+	return report
+}
@@ -0,0 +1,182 @@
+package trackers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+)
+
+var readImportRowsTestTable = []struct {
+	format         string
+	file           string
+	expectedRows   int
+	expectedErrors int
+}{
+	{"csv", "tracker,name,status,priority,tags\ngame,Terraria,finished,high,Sandbox|2D\nmedia,Dune,playing,low,\n", 2, 1},
+	{"json", `[{"tracker": "game", "name": "Terraria", "status": "finished"}, {"name": "Dune", "priority": 7}]`, 2, 0},
+	{"ndjson", "{\"tracker\": \"media\", \"name\": \"Dune\"}\n\n{\"name\": \"Terraria\", \"status\": \"playing\"}\n", 2, 1},
+	{"imdb", "Const,Your Rating,Date Rated,Title,URL,Title Type,IMDb Rating,Runtime (mins),Year,Genres,Num Votes,Release Date,Directors\ntt0468569,10,2023-01-05,The Dark Knight,https://www.imdb.com/title/tt0468569/,Movie,9.0,152,2008,\"Action, Crime, Drama\",2700000,2008-07-18,Christopher Nolan\ntt0000001,5,2023-01-06,Some Episode,,TV Episode,7.0,20,2010,Drama,10,2010-01-01,\n", 2, 1},
+	{"letterboxd", "Date,Name,Year,Letterboxd URI,Rating,Rewatch,Tags,Watched Date\n2023-02-01,Dune,2021,https://boxd.it/abc,4.5,,,2023-01-31\n", 1, 0},
+}
+
+func TestReadImportRows(t *testing.T) {
+	for _, test := range readImportRowsTestTable {
+		rows, err := trackers.ReadImportRows(strings.NewReader(test.file), test.format)
+		if err != nil {
+			t.Errorf("%s: %s", test.format, err)
+			continue
+		}
+		if len(rows) != test.expectedRows {
+			t.Errorf("%s: expected rows: %d, actual rows: %d", test.format, test.expectedRows, len(rows))
+		}
+
+		var errors int
+		for _, row := range rows {
+			if row.Err != nil {
+				errors++
+			}
+		}
+		if errors != test.expectedErrors {
+			t.Errorf("%s: expected rows with errors: %d, actual rows with errors: %d", test.format, test.expectedErrors, errors)
+		}
+	}
+
+	rows, _ := trackers.ReadImportRows(strings.NewReader(readImportRowsTestTable[3].file), "imdb")
+	if entry := rows[0].Entry; entry.MediaType != "movie" || entry.Stars != 5 || entry.FinishedDroppedDate != "2023-01-05" || len(entry.Genres) != 3 {
+		t.Errorf("unexpected IMDB entry: %+v", entry)
+	}
+	rows, _ = trackers.ReadImportRows(strings.NewReader(readImportRowsTestTable[4].file), "letterboxd")
+	if entry := rows[0].Entry; entry.Stars != 5 || entry.FinishedDroppedDate != "2023-01-31" {
+		t.Errorf("unexpected Letterboxd entry: %+v", entry)
+	}
+
+	if _, err := trackers.ReadImportRows(strings.NewReader(""), "xlsx"); err == nil {
+		t.Errorf("expected an error for an invalid format")
+	}
+}
+
+func TestPlanImport(t *testing.T) {
+	rows, err := trackers.ReadImportRows(strings.NewReader(`tracker,name,media_type,status,stars
+game,Terraria,,finished,5
+game,Terraria,,finished,4
+game,Valheim,,in_progress,
+media,Dune,movie,not_started,
+media,The Dark Knight,,finished,
+,Remnant II,,,
+game,Hades,,finished,9
+`), "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	existingGames := map[string]*trackers.ExistingEntry{"Valheim": {Status: trackers.StatusFinished, Priority: trackers.PriorityHigh}}
+	existingMedias := map[string]*trackers.ExistingEntry{}
+
+	expectedActions := []string{
+		trackers.ImportActionCreate,
+		trackers.ImportActionSkip, // duplicated
+		trackers.ImportActionSkip, // already exists
+		trackers.ImportActionCreate,
+		trackers.ImportActionSkip, // missing media type
+		trackers.ImportActionSkip, // no tracker
+		trackers.ImportActionSkip, // invalid stars
+	}
+	items := trackers.PlanImport(rows, existingGames, existingMedias, false, "")
+	for i, item := range items {
+		if item.Action != expectedActions[i] {
+			t.Errorf("line %d: expected action: %s, actual action: %s (%s)", item.Line, expectedActions[i], item.Action, item.Reason)
+		}
+	}
+
+	items = trackers.PlanImport(rows, existingGames, existingMedias, true, "games")
+	if items[2].Action != trackers.ImportActionUpdate {
+		t.Errorf("expected Valheim to be updated, actual action: %s", items[2].Action)
+	}
+	if items[5].Action != trackers.ImportActionCreate {
+		t.Errorf("expected Remnant II to be created in the default tracker, actual action: %s (%s)", items[5].Action, items[5].Reason)
+	}
+}
+
+func TestImportTrackersRoute(t *testing.T) {
+	router := api.SetupRouter()
+
+	w := httptest.NewRecorder()
+	body := strings.NewReader("tracker,name,status\ngame,Terraria,finished\nmedia,Dune,finished\n")
+	req, err := http.NewRequest(http.MethodPost, "/v1/trackers/import?format=csv&dry_run=true", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(w, req)
+
+	if http.StatusOK != w.Code {
+		t.Fatalf("expected status code: %d, actual status code: %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var report trackers.ImportReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || len(report.Items) != 2 {
+		t.Errorf("expected a dry run report with 2 items, actual report: %+v", report)
+	}
+
+	// The imported entries are checked like the requests
+	w = httptest.NewRecorder()
+	body = strings.NewReader("tracker,name,status,stars\ngame,Import Rules Test Game,not_started,3\n")
+	req, err = http.NewRequest(http.MethodPost, "/v1/trackers/import?format=csv&wait=true", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(w, req)
+	defer router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/trackers/games_tracker/delete_game", strings.NewReader(`{"name": "Import Rules Test Game"}`)))
+
+	report = trackers.ImportReport{}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("%s: %s", err, w.Body.String())
+	}
+	if len(report.Items) != 1 || report.Items[0].Action != trackers.ImportActionFailed || !strings.Contains(report.Items[0].Reason, "stars can only be set for finished or dropped entries") {
+		t.Errorf("expected the entry with stars to fail, actual report: %s", w.Body.String())
+	}
+
+	// The updates keep the fields that the import file doesn't have
+	imports := []struct {
+		query string
+		file  string
+	}{
+		{"format=csv", "tracker,name,status,stars,purchased_or_gamepass,commentary\ngame,Import Kept Fields Game,finished,4,true,Great\n"},
+		{"format=imdb&update=true", "Const,Your Rating,Date Rated,Title,URL,Title Type,Release Date\ntt0000002,,2024-01-05,Import Kept Fields Game,,Video Game,\n"},
+	}
+	defer router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/trackers/games_tracker/delete_game", strings.NewReader(`{"name": "Import Kept Fields Game"}`)))
+	for _, test := range imports {
+		w = httptest.NewRecorder()
+		req, err = http.NewRequest(http.MethodPost, "/v1/trackers/import?wait=true&"+test.query, strings.NewReader(test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.ServeHTTP(w, req)
+
+		report = trackers.ImportReport{}
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("%s: %s", err, w.Body.String())
+		}
+		if len(report.Items) != 1 || report.Failed+report.Skipped != 0 {
+			t.Fatalf("%s: expected the entry to be imported, actual report: %s", test.query, w.Body.String())
+		}
+	}
+
+	games, err := getGamesByName(router, "Import Kept Fields Game")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := games["Import Kept Fields Game"]
+	if game == nil {
+		t.Fatalf("expected the imported game")
+	}
+	if game.Stars != 4 || !game.PurchasedOrGamePass || game.Commentary != "Great" || formatTestDate(game.FinishedDroppedDate) != "2024-01-05" {
+		t.Errorf("expected the stars, purchased_or_gamepass, and commentary to be kept and the finished date to be updated, actual game: %+v", game)
+	}
+}
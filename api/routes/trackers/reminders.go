@@ -0,0 +1,293 @@
+package trackers
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/notify"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/diogovalentte/dashboard/api/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// A ReleaseEntry is a to be released entry with a known release date
+type ReleaseEntry struct {
+	// ID of the user that owns the entry
+	UserID int64 `json:"user_id"`
+	// games or medias
+	Tracker     string `json:"tracker"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	ReleaseDate string `json:"release_date"`
+	// Days until the release date, 0 on the release day and negative after it
+	DaysLeft int `json:"days_left"`
+}
+
+// A ReleasesCheck is the result of a check of the to be released entries
+type ReleasesCheck struct {
+	// Entries with reminders sent by the check
+	Reminders []*ReleaseEntry `json:"reminders"`
+	// Entries moved to not started because their release date passed
+	Moved []*ReleaseEntry `json:"moved"`
+	// Errors of the channels that couldn't send a reminder
+	Errors []string `json:"errors"`
+}
+
+// StartReleaseReminders checks the releases once, and then daily at the reminders check time.
+// The configs are read before each check, so the reminders can be enabled or changed without a restart.
+func StartReleaseReminders() {
+	go func() {
+		for {
+			configs, err := util.GetConfigs()
+			if err != nil {
+				slog.Error("couldn't check the releases", "error", err)
+			} else if configs.Reminders.Enabled {
+				check, err := CheckReleases(configs, time.Now())
+				if err != nil {
+					slog.Error("couldn't check the releases", "error", err)
+				} else {
+					slog.Info("releases checked", "reminders", len(check.Reminders), "moved", len(check.Moved), "errors", check.Errors)
+				}
+			}
+
+			checkTime := ""
+			if configs != nil {
+				checkTime = configs.Reminders.CheckTime
+			}
+			time.Sleep(time.Until(getNextReleasesCheck(time.Now(), checkTime)))
+		}
+	}()
+}
+
+// getNextReleasesCheck returns the next time of the day of the check time after now, or a day after now if the
+// check time is invalid
+func getNextReleasesCheck(now time.Time, checkTime string) time.Time {
+	t, err := time.Parse("15:04", checkTime)
+	if err != nil {
+		return now.Add(24 * time.Hour)
+	}
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
+
+// CheckReleases sends the reminders of the to be released entries due on the now date, to the channels in the configs.
+// A reminder is due when the days left to the release are at most one of the reminders days before, or it's
+// the release day. Each reminder is sent once, and due reminders not sent before are sent together, like when the API
+// was stopped on their day. If enabled in the configs, the entries with a past release date are moved to not started.
+func CheckReleases(configs *util.Configs, now time.Time) (*ReleasesCheck, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	reminderDays := append([]int{0}, configs.Reminders.DaysBefore...)
+	channels := notify.GetChannels(configs)
+
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	check := &ReleasesCheck{Reminders: []*ReleaseEntry{}, Moved: []*ReleaseEntry{}, Errors: []string{}}
+	for _, tracker := range namedTrackers {
+		if err = deleteStaleReminders(db, tracker); err != nil {
+			return nil, err
+		}
+		entries, err := getReleaseEntries(db, tracker, today)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.DaysLeft < 0 {
+				if configs.Reminders.MoveReleasedToNotStarted {
+					if err = moveReleasedEntry(db, tracker.tt, entry, now); err != nil {
+						return nil, err
+					}
+					check.Moved = append(check.Moved, entry)
+				}
+				continue
+			}
+
+			dueDays, err := getUnsentReminderDays(db, entry, reminderDays)
+			if err != nil {
+				return nil, err
+			}
+			if len(dueDays) == 0 {
+				continue
+			}
+
+			errs := notify.SendAll(channels, getReleaseNotification(entry, tracker.title))
+			for name, err := range errs {
+				check.Errors = append(check.Errors, fmt.Sprintf("%s: %s", name, err))
+			}
+			// The reminder is sent again on the next check if all channels failed
+			if len(errs) == len(channels) {
+				continue
+			}
+			if err = recordReminders(db, entry, dueDays, now); err != nil {
+				return nil, err
+			}
+			check.Reminders = append(check.Reminders, entry)
+		}
+	}
+	sort.Strings(check.Errors)
+
+	return check, nil
+}
+
+// getReleaseEntries returns the to be released entries of all users with a release date
+func getReleaseEntries(db *sql.DB, tracker namedTracker, today time.Time) ([]*ReleaseEntry, error) {
+	rows, err := db.Query(
+		fmt.Sprintf("SELECT user_id, name, url, release_date FROM %s WHERE status = ? ORDER BY release_date, user_id, name;", tracker.tt.table),
+		StatusToBeReleased,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*ReleaseEntry{}
+	for rows.Next() {
+		entry := ReleaseEntry{Tracker: tracker.name}
+		var url sql.NullString
+		var releaseDate sql.NullTime
+		if err = rows.Scan(&entry.UserID, &entry.Name, &url, &releaseDate); err != nil {
+			return nil, err
+		}
+		if !releaseDate.Valid || releaseDate.Time.IsZero() {
+			continue
+		}
+		date := releaseDate.Time.UTC()
+		date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		entry.URL = url.String
+		entry.ReleaseDate = date.Format("2006-01-02")
+		entry.DaysLeft = int(date.Sub(today).Hours() / 24)
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// deleteStaleReminders deletes the sent reminders of the entries deleted or not to be released anymore,
+// so their reminders are sent again if they're added back
+func deleteStaleReminders(db *sql.DB, tracker namedTracker) error {
+	_, err := db.Exec(fmt.Sprintf(`
+DELETE FROM
+  release_reminders
+WHERE
+  tracker = ?
+  AND NOT EXISTS (
+    SELECT 1 FROM %s t WHERE t.user_id = release_reminders.user_id AND t.name = release_reminders.name AND t.status = ?
+  );`, tracker.tt.table),
+		tracker.name, StatusToBeReleased,
+	)
+
+	return err
+}
+
+// getUnsentReminderDays returns the reminder days due for the entry that weren't sent yet
+func getUnsentReminderDays(db *sql.DB, entry *ReleaseEntry, reminderDays []int) ([]int, error) {
+	var unsent []int
+	for _, days := range reminderDays {
+		if entry.DaysLeft > days {
+			continue
+		}
+		var sent bool
+		err := db.QueryRow(
+			"SELECT EXISTS (SELECT 1 FROM release_reminders WHERE user_id = ? AND tracker = ? AND name = ? AND release_date = ? AND days_before = ?);",
+			entry.UserID, entry.Tracker, entry.Name, entry.ReleaseDate, days,
+		).Scan(&sent)
+		if err != nil {
+			return nil, err
+		}
+		if !sent {
+			unsent = append(unsent, days)
+		}
+	}
+
+	return unsent, nil
+}
+
+func recordReminders(db *sql.DB, entry *ReleaseEntry, days []int, now time.Time) error {
+	for _, d := range days {
+		_, err := db.Exec(
+			"INSERT OR IGNORE INTO release_reminders (user_id, tracker, name, release_date, days_before, sent_at) VALUES (?, ?, ?, ?, ?, ?);",
+			entry.UserID, entry.Tracker, entry.Name, entry.ReleaseDate, d, now.Format("2006-01-02 15:04:05"),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getReleaseNotification(entry *ReleaseEntry, trackerTitle string) notify.Notification {
+	notification := notify.Notification{
+		Message: fmt.Sprintf("%s, of the %s, is released on %s.", entry.Name, trackerTitle, entry.ReleaseDate),
+		URL:     entry.URL,
+		Event:   webhook.EventReleaseUpcoming,
+		Data:    entry,
+	}
+	switch entry.DaysLeft {
+	case 0:
+		notification.Title = fmt.Sprintf("%s is released today", entry.Name)
+		notification.Event = webhook.EventReleaseToday
+	case 1:
+		notification.Title = fmt.Sprintf("%s is released tomorrow", entry.Name)
+	default:
+		notification.Title = fmt.Sprintf("%s is released in %d days", entry.Name, entry.DaysLeft)
+	}
+
+	return notification
+}
+
+// moveReleasedEntry changes the status of a released entry to not started
+func moveReleasedEntry(db *sql.DB, tt trackerTable, entry *ReleaseEntry, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		fmt.Sprintf("UPDATE %s SET status = ? WHERE user_id = ? AND name = ? AND status = ?;", tt.table),
+		StatusNotStarted, entry.UserID, entry.Name, StatusToBeReleased,
+	)
+	if err != nil {
+		return err
+	}
+	err = recordStatusChange(tx, tt, entry.UserID, entry.Name, StatusToBeReleased, StatusNotStarted, now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CheckReleasesNow checks the releases without waiting for the daily check, even if the reminders are disabled.
+// The reminders already sent are not sent again.
+func CheckReleasesNow(c *gin.Context) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	check, err := CheckReleases(configs, time.Now())
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Releases checked", "check": check})
+}
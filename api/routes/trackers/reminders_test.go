@@ -0,0 +1,122 @@
+package trackers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+func TestCheckReleases(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("PNG"))
+	}))
+	defer imageServer.Close()
+	var titles []string
+	var titlesMutex sync.Mutex
+	ntfyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		titlesMutex.Lock()
+		defer titlesMutex.Unlock()
+		titles = append(titles, r.Header.Get("Title"))
+	}))
+	defer ntfyServer.Close()
+
+	router := api.SetupRouter()
+	serve := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testConfigs := *configs
+	testConfigs.Reminders = util.RemindersConfigs{DaysBefore: []int{3}, MoveReleasedToNotStarted: true, Ntfy: util.NtfyConfigs{URL: ntfyServer.URL}}
+	now := time.Date(2030, 1, 10, 9, 0, 0, 0, time.Local)
+	// Clear the reminders sent to the entries of previous runs, deleted after them
+	if _, err = trackers.CheckReleases(&testConfigs, now); err != nil {
+		t.Fatal(err)
+	}
+	titles = nil
+
+	entries := []struct{ path, deletePath, body string }{
+		{"/v1/trackers/games_tracker/add_game_manually", "/v1/trackers/games_tracker/delete_game", `{"wait": true, "name": "Reminder Test Game", "url": "https://example.com/reminder", "cover_img_url": "%s", "priority": "high", "status": "to_be_released", "release_date": "2030-01-11"}`},
+		{"/v1/trackers/games_tracker/add_game_manually", "/v1/trackers/games_tracker/delete_game", `{"wait": true, "name": "Released Test Game", "url": "https://example.com/released", "cover_img_url": "%s", "priority": "high", "status": "to_be_released", "release_date": "2030-01-05"}`},
+		{"/v1/trackers/medias_tracker/add_media_manually", "/v1/trackers/medias_tracker/delete_media", `{"wait": true, "name": "Reminder Test Media", "url": "https://example.com/reminder", "cover_img_url": "%s", "media_type": "movie", "priority": "high", "status": "to_be_released", "release_date": "2030-01-10"}`},
+	}
+	for _, entry := range entries {
+		w := serve(entry.path, fmt.Sprintf(entry.body, imageServer.URL))
+		if w.Code != http.StatusOK {
+			t.Fatalf("couldn't add the entry: %s", w.Body.String())
+		}
+		var name struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal([]byte(entry.body), &name)
+		defer serve(entry.deletePath, fmt.Sprintf(`{"name": %q}`, name.Name))
+	}
+
+	check, err := trackers.CheckReleases(&testConfigs, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTitles := []string{"Reminder Test Game is released tomorrow", "Reminder Test Media is released today"}
+	for _, expected := range expectedTitles {
+		if !containsString(titles, expected) {
+			t.Errorf("expected the notification %q, got %v", expected, titles)
+		}
+	}
+	if !hasReleaseEntry(check.Reminders, "Reminder Test Game", 1) || !hasReleaseEntry(check.Reminders, "Reminder Test Media", 0) {
+		t.Errorf("unexpected reminders: %+v", check.Reminders)
+	}
+	if !hasReleaseEntry(check.Moved, "Released Test Game", -5) {
+		t.Errorf("expected Released Test Game to be moved to not started, moved: %+v", check.Moved)
+	}
+
+	// The reminders are sent once
+	check, err = trackers.CheckReleases(&testConfigs, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasReleaseEntry(check.Reminders, "Reminder Test Game", 1) || hasReleaseEntry(check.Reminders, "Reminder Test Media", 0) {
+		t.Errorf("expected the reminders to not be sent again: %+v", check.Reminders)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/trackers/games_tracker/get_not_started_games", nil)
+	router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"Name":"Released Test Game"`) {
+		t.Errorf("expected Released Test Game in the not started games: %s", w.Body.String())
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasReleaseEntry(entries []*trackers.ReleaseEntry, name string, daysLeft int) bool {
+	for _, entry := range entries {
+		if entry.Name == name && entry.DaysLeft == daysLeft {
+			return true
+		}
+	}
+
+	return false
+}
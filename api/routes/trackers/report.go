@@ -0,0 +1,366 @@
+package trackers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	htmlTemplate "html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// How many entries are in the highest-rated and longest-running lists of a report
+const reportTopEntriesLimit = 5
+
+// A YearReport summarizes the games and medias finished and dropped in a year
+type YearReport struct {
+	Year          int `json:"year"`
+	Total         int `json:"total"`
+	FinishedCount int `json:"finished_count"`
+	DroppedCount  int `json:"dropped_count"`
+	// Sorted by the finished date
+	Finished []*ReportEntry `json:"finished"`
+	// Sorted by the dropped date
+	Dropped []*ReportEntry `json:"dropped"`
+	// Finished entries with the most stars
+	HighestRated []*ReportEntry `json:"highest_rated"`
+	// Finished entries with the most days between the started and finished dates
+	LongestRunning []*ReportEntry `json:"longest_running"`
+	FirstFinished  *ReportEntry   `json:"first_finished"`
+	LastFinished   *ReportEntry   `json:"last_finished"`
+}
+
+// A ReportEntry is a game or media in a report
+type ReportEntry struct {
+	// "games" or "medias", like the tracker parameter of the other routes
+	Tracker string `json:"tracker"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	// Only for medias
+	MediaType           string    `json:"media_type,omitempty"`
+	Status              Status    `json:"status"`
+	Stars               int       `json:"stars"`
+	StartedDate         time.Time `json:"started_date"`
+	FinishedDroppedDate time.Time `json:"finished_dropped_date"`
+	// Days between the started and finished/dropped dates, 0 if the started date is unknown
+	Days     int    `json:"days"`
+	CoverImg []byte `json:"-"`
+}
+
+// CoverImgDataURI returns the cover image as a data URI to embed it in HTML
+func (re *ReportEntry) CoverImgDataURI() htmlTemplate.URL {
+	if len(re.CoverImg) == 0 {
+		return ""
+	}
+	contentType := strings.SplitN(http.DetectContentType(re.CoverImg), ";", 2)[0]
+
+	return htmlTemplate.URL(fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(re.CoverImg)))
+}
+
+func newReportEntry(tracker, name, url, mediaType string, status Status, stars int, startedDate, finishedDroppedDate time.Time, coverImg []byte) *ReportEntry {
+	entry := ReportEntry{
+		Tracker:             tracker,
+		Name:                name,
+		URL:                 url,
+		MediaType:           mediaType,
+		Status:              status,
+		Stars:               stars,
+		StartedDate:         startedDate,
+		FinishedDroppedDate: finishedDroppedDate,
+		CoverImg:            coverImg,
+	}
+	if !startedDate.IsZero() && !finishedDroppedDate.Before(startedDate) {
+		entry.Days = int(finishedDroppedDate.Sub(startedDate).Hours() / 24)
+	}
+
+	return &entry
+}
+
+// GenerateYearReport creates the report of a year from the finished and dropped games and medias
+func GenerateYearReport(year int, games []*GetGameProperties, medias []*GetMediaProperties) *YearReport {
+	var entries []*ReportEntry
+	for _, game := range games {
+		entries = append(entries, newReportEntry("games", game.Name, game.URL, "", game.Status, game.Stars, game.StartedDate, game.FinishedDroppedDate, game.CoverImg))
+	}
+	for _, media := range medias {
+		entries = append(entries, newReportEntry("medias", media.Name, media.URL, media.MediaType.String(), media.Status, media.Stars, media.StartedDate, media.FinishedDroppedDate, media.CoverImg))
+	}
+
+	report := YearReport{
+		Year:           year,
+		Finished:       []*ReportEntry{},
+		Dropped:        []*ReportEntry{},
+		HighestRated:   []*ReportEntry{},
+		LongestRunning: []*ReportEntry{},
+	}
+	for _, entry := range entries {
+		if entry.FinishedDroppedDate.Year() != year {
+			continue
+		}
+		switch entry.Status {
+		case StatusFinished:
+			report.Finished = append(report.Finished, entry)
+		case StatusDropped:
+			report.Dropped = append(report.Dropped, entry)
+		}
+	}
+	for _, list := range [][]*ReportEntry{report.Finished, report.Dropped} {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].FinishedDroppedDate.Before(list[j].FinishedDroppedDate)
+		})
+	}
+
+	report.FinishedCount = len(report.Finished)
+	report.DroppedCount = len(report.Dropped)
+	report.Total = report.FinishedCount + report.DroppedCount
+	if report.FinishedCount > 0 {
+		report.FirstFinished = report.Finished[0]
+		report.LastFinished = report.Finished[report.FinishedCount-1]
+	}
+
+	for _, entry := range report.Finished {
+		if entry.Stars > 0 {
+			report.HighestRated = append(report.HighestRated, entry)
+		}
+		if entry.Days > 0 {
+			report.LongestRunning = append(report.LongestRunning, entry)
+		}
+	}
+	sort.SliceStable(report.HighestRated, func(i, j int) bool {
+		return report.HighestRated[i].Stars > report.HighestRated[j].Stars
+	})
+	sort.SliceStable(report.LongestRunning, func(i, j int) bool {
+		return report.LongestRunning[i].Days > report.LongestRunning[j].Days
+	})
+	if len(report.HighestRated) > reportTopEntriesLimit {
+		report.HighestRated = report.HighestRated[:reportTopEntriesLimit]
+	}
+	if len(report.LongestRunning) > reportTopEntriesLimit {
+		report.LongestRunning = report.LongestRunning[:reportTopEntriesLimit]
+	}
+
+	return &report
+}
+
+var reportTemplateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return "?"
+		}
+		return t.Format("2006-01-02")
+	},
+	"stars": func(stars int) string {
+		return strings.Repeat("★", stars) + strings.Repeat("☆", 5-stars)
+	},
+	// Escapes the characters that would change the Markdown formatting
+	"md": func(s string) string {
+		return markdownEscaper.Replace(s)
+	},
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "#", `\#`, "|", `\|`, "<", `\<`, ">", `\>`,
+)
+
+var reportMarkdownTemplate = template.Must(template.New("report.md").Funcs(reportTemplateFuncs).Parse(`# {{ .Year }} wrapped
+
+**{{ .Total }}** entries: **{{ .FinishedCount }}** finished and **{{ .DroppedCount }}** dropped.
+{{ with .FirstFinished }}
+- First finish: **{{ md .Name }}** on {{ date .FinishedDroppedDate }}
+{{- end }}
+{{- with .LastFinished }}
+- Last finish: **{{ md .Name }}** on {{ date .FinishedDroppedDate }}
+{{- end }}
+{{ if .HighestRated }}
+## Highest rated
+{{ range .HighestRated }}
+- {{ stars .Stars }} [{{ md .Name }}]({{ .URL }})
+{{- end }}
+{{ end }}
+{{- if .LongestRunning }}
+## Longest running
+{{ range .LongestRunning }}
+- [{{ md .Name }}]({{ .URL }}): {{ .Days }} days ({{ date .StartedDate }} to {{ date .FinishedDroppedDate }})
+{{- end }}
+{{ end }}
+## Finished
+{{ range .Finished }}
+- {{ date .FinishedDroppedDate }}: [{{ md .Name }}]({{ .URL }}) ({{ .Tracker }}{{ with .MediaType }}, {{ . }}{{ end }})
+{{- else }}
+Nothing finished this year.
+{{- end }}
+
+## Dropped
+{{ range .Dropped }}
+- {{ date .FinishedDroppedDate }}: [{{ md .Name }}]({{ .URL }}) ({{ .Tracker }}{{ with .MediaType }}, {{ . }}{{ end }})
+{{- else }}
+Nothing dropped this year.
+{{- end }}
+`))
+
+var reportHTMLTemplate = htmlTemplate.Must(htmlTemplate.New("report.html").Funcs(reportTemplateFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Year }} wrapped</title>
+<style>
+  body { font-family: sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; color: #222; }
+  .entries { display: flex; flex-wrap: wrap; gap: 1em; }
+  .entry { width: 180px; }
+  .entry img { width: 180px; height: auto; border-radius: 4px; }
+  .entry p { margin: 0.2em 0; font-size: 0.9em; }
+  .stars { color: #e0a800; }
+</style>
+</head>
+<body>
+<h1>{{ .Year }} wrapped</h1>
+<p><strong>{{ .Total }}</strong> entries: <strong>{{ .FinishedCount }}</strong> finished and <strong>{{ .DroppedCount }}</strong> dropped.</p>
+<ul>
+{{- with .FirstFinished }}
+  <li>First finish: <strong>{{ .Name }}</strong> on {{ date .FinishedDroppedDate }}</li>
+{{- end }}
+{{- with .LastFinished }}
+  <li>Last finish: <strong>{{ .Name }}</strong> on {{ date .FinishedDroppedDate }}</li>
+{{- end }}
+</ul>
+{{- define "entries" }}
+<div class="entries">
+{{- range . }}
+  <div class="entry">
+    {{- with .CoverImgDataURI }}<img src="{{ . }}" alt="">{{ end }}
+    <p><a href="{{ .URL }}">{{ .Name }}</a></p>
+    {{- if .Stars }}<p class="stars">{{ stars .Stars }}</p>{{ end }}
+    <p>{{ date .StartedDate }} to {{ date .FinishedDroppedDate }}{{ if .Days }} ({{ .Days }} days){{ end }}</p>
+  </div>
+{{- else }}
+  <p>Nothing here.</p>
+{{- end }}
+</div>
+{{- end }}
+{{- if .HighestRated }}
+<h2>Highest rated</h2>
+{{ template "entries" .HighestRated }}
+{{- end }}
+{{- if .LongestRunning }}
+<h2>Longest running</h2>
+{{ template "entries" .LongestRunning }}
+{{- end }}
+<h2>Finished</h2>
+{{ template "entries" .Finished }}
+<h2>Dropped</h2>
+{{ template "entries" .Dropped }}
+</body>
+</html>
+`))
+
+// RenderMarkdown renders the report as a Markdown document
+func (yr *YearReport) RenderMarkdown() ([]byte, error) {
+	var buf bytes.Buffer
+	err := reportMarkdownTemplate.Execute(&buf, yr)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderHTML renders the report as a standalone HTML page with the cover images embedded
+func (yr *YearReport) RenderHTML() ([]byte, error) {
+	var buf bytes.Buffer
+	err := reportHTMLTemplate.Execute(&buf, yr)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GetYearReport returns the report of the year in the path.
+// The format query parameter can be json (default), markdown, or html.
+func GetYearReport(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil || year < 1 || year > 9999 {
+		apierror.Respond(c, apierror.Validation(fmt.Sprintf("invalid year %q", c.Param("year"))))
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "markdown" && format != "html" {
+		apierror.Respond(c, apierror.Validation(fmt.Sprintf("invalid format %q, it should be json, markdown, or html", format)))
+		return
+	}
+
+	// The report is of the user's own entries, admins can get the report of other users with the user query parameter
+	scope, ok := getUserScope(c, false)
+	if !ok {
+		return
+	}
+	userCondition, userArgs := scope.condition("user_id")
+	// The dates are stored as text starting with the YYYY-MM-DD date
+	args := append([]interface{}{fmt.Sprintf("%04d-01-01", year), fmt.Sprintf("%04d-01-01", year+1)}, userArgs...)
+	// Only the HTML report shows the cover images
+	coverImgColumn := `""`
+	if format == "html" {
+		coverImgColumn = "cover_img"
+	}
+
+	games, err := getGamesFromQuery(fmt.Sprintf(`
+SELECT
+  user_id, url, name, %s, release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, ""
+FROM
+  games_tracker
+WHERE
+  status IN (%d, %d)
+  AND finished_dropped_date >= ?
+  AND finished_dropped_date < ?
+  AND %s;`, coverImgColumn, StatusFinished, StatusDropped, userCondition,
+	), args...)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	medias, err := getMediasFromQuery(fmt.Sprintf(`
+SELECT
+  user_id, url, name, media_type, %s, release_date, priority,
+  status, stars, started_date, finished_dropped_date, ""
+FROM
+  medias_tracker
+WHERE
+  status IN (%d, %d)
+  AND finished_dropped_date >= ?
+  AND finished_dropped_date < ?
+  AND %s;`, coverImgColumn, StatusFinished, StatusDropped, userCondition,
+	), args...)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	report := GenerateYearReport(year, games, medias)
+
+	switch format {
+	case "markdown":
+		body, err := report.RenderMarkdown()
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", body)
+	case "html":
+		body, err := report.RenderHTML()
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+	default:
+		c.JSON(http.StatusOK, gin.H{"report": report})
+	}
+}
@@ -0,0 +1,134 @@
+package trackers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+)
+
+func TestGenerateYearReport(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	games := []*trackers.GetGameProperties{
+		{Name: "Terraria", Status: trackers.StatusFinished, Stars: 3, StartedDate: date("2023-01-01"), FinishedDroppedDate: date("2023-03-01")},
+		{Name: "Red Dead Redemption 2", Status: trackers.StatusDropped, StartedDate: date("2022-12-01"), FinishedDroppedDate: date("2023-01-05")},
+		{Name: "Remnant II", Status: trackers.StatusFinished, Stars: 5, StartedDate: date("2023-07-29"), FinishedDroppedDate: date("2023-08-12")},
+		{Name: "Valheim", Status: trackers.StatusFinished, Stars: 4, FinishedDroppedDate: date("2022-05-01")},
+	}
+	medias := []*trackers.GetMediaProperties{
+		{Name: "The Dark Knight", MediaType: trackers.MediaTypeMovie, Status: trackers.StatusFinished, FinishedDroppedDate: date("2023-02-01")},
+	}
+
+	report := trackers.GenerateYearReport(2023, games, medias)
+
+	if report.Total != 4 || report.FinishedCount != 3 || report.DroppedCount != 1 {
+		t.Errorf("expected 4 entries (3 finished, 1 dropped), actual: %d (%d finished, %d dropped)", report.Total, report.FinishedCount, report.DroppedCount)
+	}
+	if report.FirstFinished == nil || report.FirstFinished.Name != "The Dark Knight" || report.FirstFinished.Tracker != "medias" {
+		t.Errorf("expected first finished: The Dark Knight of the medias tracker, actual: %v", report.FirstFinished)
+	}
+	if report.LastFinished == nil || report.LastFinished.Name != "Remnant II" || report.LastFinished.Tracker != "games" {
+		t.Errorf("expected last finished: Remnant II of the games tracker, actual: %v", report.LastFinished)
+	}
+	if len(report.HighestRated) != 2 || report.HighestRated[0].Name != "Remnant II" {
+		t.Errorf("expected highest rated: [Remnant II, Terraria], actual: %v", report.HighestRated)
+	}
+	if len(report.LongestRunning) != 2 || report.LongestRunning[0].Name != "Terraria" || report.LongestRunning[0].Days != 59 {
+		t.Errorf("expected longest running: Terraria with 59 days, actual: %v", report.LongestRunning)
+	}
+
+	markdown, err := report.RenderMarkdown()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.Contains(string(markdown), "# 2023 wrapped") {
+		t.Errorf("unexpected Markdown report: %s", markdown)
+	}
+
+	html, err := report.RenderHTML()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.Contains(string(html), "<h1>2023 wrapped</h1>") {
+		t.Errorf("unexpected HTML report: %s", html)
+	}
+}
+
+var getYearReportRouteTestTable = []struct {
+	path                string
+	expectedStatusCode  int
+	expectedContentType string
+}{
+	{"/v1/trackers/reports/2023", http.StatusOK, "application/json; charset=utf-8"},
+	{"/v1/trackers/reports/2023?format=markdown", http.StatusOK, "text/markdown; charset=utf-8"},
+	{"/v1/trackers/reports/2023?format=html", http.StatusOK, "text/html; charset=utf-8"},
+	{"/v1/trackers/reports/2023?format=pdf", http.StatusBadRequest, "application/json; charset=utf-8"},
+	{"/v1/trackers/reports/last_year", http.StatusBadRequest, "application/json; charset=utf-8"},
+}
+
+func TestGetYearReportRoute(t *testing.T) {
+	router := api.SetupRouter()
+
+	for _, test := range getYearReportRouteTestTable {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, test.path, nil)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		router.ServeHTTP(w, req)
+
+		if test.expectedStatusCode != w.Code {
+			t.Errorf("%s: expected status code: %d, actual status code: %d", test.path, test.expectedStatusCode, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != test.expectedContentType {
+			t.Errorf("%s: expected content type: %s, actual content type: %s", test.path, test.expectedContentType, contentType)
+		}
+		if test.expectedContentType == "application/json; charset=utf-8" && !json.Valid(w.Body.Bytes()) {
+			t.Errorf("%s: invalid JSON response", test.path)
+		}
+	}
+
+	// Only the entries finished or dropped in the year are in the report
+	w := httptest.NewRecorder()
+	body := strings.NewReader("tracker,name,status,finished_dropped_date\ngame,Report Test Game,finished,2023-12-31\ngame,Report Test Next Year Game,finished,2024-01-01\n")
+	req, err := http.NewRequest(http.MethodPost, "/v1/trackers/import?format=csv&wait=true", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(w, req)
+	for _, name := range []string{"Report Test Game", "Report Test Next Year Game"} {
+		defer router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/trackers/games_tracker/delete_game", strings.NewReader(`{"name": "`+name+`"}`)))
+	}
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/v1/trackers/reports/2023", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(w, req)
+
+	var res struct {
+		Report trackers.YearReport `json:"report"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("%s: %s", err, w.Body.String())
+	}
+	var names []string
+	for _, entry := range res.Report.Finished {
+		names = append(names, entry.Name)
+	}
+	if strings.Join(names, ", ") != "Report Test Game" {
+		t.Errorf("expected the finished entries: [Report Test Game], actual finished entries: %v", names)
+	}
+}
@@ -0,0 +1,279 @@
+package trackers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// How many entities are returned in each top list of the stats
+const statsTopEntitiesLimit = 10
+
+// The weight of each priority in the backlog size
+var backlogPriorityWeights = map[Priority]int{
+	PriorityHigh:   3,
+	PriorityMedium: 2,
+	PriorityLow:    1,
+}
+
+// TrackerStats are aggregated values of the entries of a tracker
+type TrackerStats struct {
+	Total         int            `json:"total"`
+	CountByStatus map[string]int `json:"count_by_status"`
+	// Only for the Medias Tracker
+	CountByType map[string]int `json:"count_by_type,omitempty"`
+	// Finished entries by the month ("2006-01") and year ("2006") of their finished date, only the finished dates in the range
+	FinishedByMonth map[string]int `json:"finished_by_month"`
+	FinishedByYear  map[string]int `json:"finished_by_year"`
+	// Average stars of the entries with stars, 0 means no entry has stars
+	AverageStars float64 `json:"average_stars"`
+	// Average days between the started and finished dates of the finished entries with both dates, finished in the range
+	AverageDaysToFinish float64 `json:"average_days_to_finish"`
+	// The most common tags/developers/publishers or genres/staff, by the entity name
+	TopEntities map[string][]*EntityCount `json:"top_entities"`
+	Backlog     BacklogStats              `json:"backlog"`
+}
+
+// BacklogStats are the not started entries. The weighted size sums each entry's priority weight:
+// 3 for high, 2 for medium, and 1 for low priority.
+type BacklogStats struct {
+	Size           int            `json:"size"`
+	WeightedSize   int            `json:"weighted_size"`
+	SizeByPriority map[string]int `json:"size_by_priority"`
+}
+
+// statsEntry is what the stats use from a game or media
+type statsEntry struct {
+	Status              Status
+	Priority            Priority
+	Type                string
+	Stars               int
+	StartedDate         time.Time
+	FinishedDroppedDate time.Time
+	Entities            map[string][]string
+}
+
+// inDateRange returns whether the entry was started, finished, or dropped in the range.
+// Zero from and to dates don't limit the range.
+func (se *statsEntry) inDateRange(from, to time.Time) bool {
+	if from.IsZero() && to.IsZero() {
+		return true
+	}
+
+	return isDateInRange(se.StartedDate, from, to) || isDateInRange(se.FinishedDroppedDate, from, to)
+}
+
+// isDateInRange returns whether a non-zero date is in the range. Zero from and to dates don't limit the range.
+func isDateInRange(date, from, to time.Time) bool {
+	if date.IsZero() {
+		return false
+	}
+
+	return (from.IsZero() || !date.Before(from)) && (to.IsZero() || !date.After(to))
+}
+
+// computeStats aggregates the entries in the date range.
+// The backlog is computed from all entries, as not started entries have no dates.
+func computeStats(entries []*statsEntry, from, to time.Time) *TrackerStats {
+	stats := TrackerStats{
+		CountByStatus:   map[string]int{},
+		FinishedByMonth: map[string]int{},
+		FinishedByYear:  map[string]int{},
+		TopEntities:     map[string][]*EntityCount{},
+		Backlog:         BacklogStats{SizeByPriority: map[string]int{}},
+	}
+
+	var starsSum, starsCount int
+	var daysToFinishSum float64
+	var daysToFinishCount int
+	entitiesCount := map[string]map[string]int{}
+	for _, entry := range entries {
+		if entry.Status == StatusNotStarted {
+			stats.Backlog.Size++
+			stats.Backlog.WeightedSize += backlogPriorityWeights[entry.Priority]
+			stats.Backlog.SizeByPriority[entry.Priority.String()]++
+		}
+
+		if !entry.inDateRange(from, to) {
+			continue
+		}
+
+		stats.Total++
+		stats.CountByStatus[entry.Status.String()]++
+		if entry.Type != "" {
+			if stats.CountByType == nil {
+				stats.CountByType = map[string]int{}
+			}
+			stats.CountByType[entry.Type]++
+		}
+
+		if entry.Stars > 0 {
+			starsSum += entry.Stars
+			starsCount++
+		}
+
+		// An entry started in the range can be finished after it
+		if entry.Status == StatusFinished && isDateInRange(entry.FinishedDroppedDate, from, to) {
+			stats.FinishedByMonth[entry.FinishedDroppedDate.Format("2006-01")]++
+			stats.FinishedByYear[entry.FinishedDroppedDate.Format("2006")]++
+
+			if !entry.StartedDate.IsZero() && !entry.FinishedDroppedDate.Before(entry.StartedDate) {
+				daysToFinishSum += entry.FinishedDroppedDate.Sub(entry.StartedDate).Hours() / 24
+				daysToFinishCount++
+			}
+		}
+
+		for entityName, names := range entry.Entities {
+			if entitiesCount[entityName] == nil {
+				entitiesCount[entityName] = map[string]int{}
+			}
+			for _, name := range names {
+				entitiesCount[entityName][name]++
+			}
+		}
+	}
+
+	if starsCount > 0 {
+		stats.AverageStars = float64(starsSum) / float64(starsCount)
+	}
+	if daysToFinishCount > 0 {
+		stats.AverageDaysToFinish = daysToFinishSum / float64(daysToFinishCount)
+	}
+	for entityName, counts := range entitiesCount {
+		stats.TopEntities[entityName] = getTopEntities(counts, statsTopEntitiesLimit)
+	}
+
+	return &stats
+}
+
+// getTopEntities returns the entities with the highest counts, ties sorted by name
+func getTopEntities(counts map[string]int, limit int) []*EntityCount {
+	top := make([]*EntityCount, 0, len(counts))
+	for name, count := range counts {
+		top = append(top, &EntityCount{Name: name, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Name < top[j].Name
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+
+	return top
+}
+
+func gamesToStatsEntries(games []*GetGameProperties) []*statsEntry {
+	entries := make([]*statsEntry, 0, len(games))
+	for _, game := range games {
+		entries = append(entries, &statsEntry{
+			Status:              game.Status,
+			Priority:            game.Priority,
+			Stars:               game.Stars,
+			StartedDate:         game.StartedDate,
+			FinishedDroppedDate: game.FinishedDroppedDate,
+			Entities: map[string][]string{
+				"tags":       game.Tags,
+				"developers": game.Developers,
+				"publishers": game.Publishers,
+			},
+		})
+	}
+
+	return entries
+}
+
+func mediasToStatsEntries(medias []*GetMediaProperties) []*statsEntry {
+	entries := make([]*statsEntry, 0, len(medias))
+	for _, media := range medias {
+		entries = append(entries, &statsEntry{
+			Status:              media.Status,
+			Priority:            media.Priority,
+			Type:                media.MediaType.String(),
+			Stars:               media.Stars,
+			StartedDate:         media.StartedDate,
+			FinishedDroppedDate: media.FinishedDroppedDate,
+			Entities: map[string][]string{
+				"genres": media.Genres,
+				"staff":  media.Staff,
+			},
+		})
+	}
+
+	return entries
+}
+
+// getDateRange returns the from and to query parameters as dates, zero if not set
+func getDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	var dates [2]time.Time
+	for i, param := range []string{"from", "to"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid %s date %q, it should have the format YYYY-MM-DD", param, value)
+		}
+		dates[i] = date
+	}
+
+	return dates[0], dates[1], nil
+}
+
+// GetStats returns aggregated values of the Games and Medias Trackers.
+// The optional from and to query parameters (YYYY-MM-DD) limit the stats to the entries
+// started, finished, or dropped in the range. The backlog is never limited by the range.
+func GetStats(c *gin.Context) {
+	from, to, err := getDateRange(c)
+	if err != nil {
+		apierror.Respond(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	// The stats are of the user's own entries, admins can get the stats of other users with the user query parameter
+	scope, ok := getUserScope(c, false)
+	if !ok {
+		return
+	}
+	userCondition, args := scope.condition("user_id")
+
+	games, err := getGamesFromQuery(fmt.Sprintf(`
+SELECT
+  user_id, url, name, "", release_date, priority, status, stars,
+  purchased_or_gamepass, started_date, finished_dropped_date, ""
+FROM
+  games_tracker
+WHERE
+  %s;`, userCondition,
+	), args...)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	medias, err := getMediasFromQuery(fmt.Sprintf(`
+SELECT
+  user_id, url, name, media_type, "", release_date, priority,
+  status, stars, started_date, finished_dropped_date, ""
+FROM
+  medias_tracker
+WHERE
+  %s;`, userCondition,
+	), args...)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"games":  computeStats(gamesToStatsEntries(games), from, to),
+		"medias": computeStats(mediasToStatsEntries(medias), from, to),
+	})
+}
@@ -0,0 +1,74 @@
+package trackers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestComputeStats(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	entries := []*statsEntry{
+		{Status: StatusFinished, Priority: PriorityHigh, Stars: 4, StartedDate: date("2023-01-01"), FinishedDroppedDate: date("2023-01-11"), Entities: map[string][]string{"tags": {"RPG", "Open World"}}},
+		{Status: StatusFinished, Priority: PriorityMedium, Stars: 2, StartedDate: date("2023-03-01"), FinishedDroppedDate: date("2023-03-21"), Entities: map[string][]string{"tags": {"RPG"}}},
+		// Started in 2023 and finished in 2024
+		{Status: StatusFinished, Priority: PriorityLow, Stars: 5, StartedDate: date("2023-12-01"), FinishedDroppedDate: date("2024-01-15"), Entities: map[string][]string{"tags": {"Roguelike"}}},
+		// Started in 2022 and dropped in 2023
+		{Status: StatusDropped, Priority: PriorityLow, StartedDate: date("2022-12-01"), FinishedDroppedDate: date("2023-02-01"), Entities: map[string][]string{"tags": {"Open World"}}},
+		{Status: StatusFinished, Priority: PriorityHigh, Stars: 3, StartedDate: date("2022-04-01"), FinishedDroppedDate: date("2022-05-01"), Entities: map[string][]string{"tags": {"Sandbox"}}},
+		{Status: StatusNotStarted, Priority: PriorityHigh},
+		{Status: StatusNotStarted, Priority: PriorityMedium},
+		{Status: StatusNotStarted, Priority: PriorityLow},
+	}
+	expectedBacklog := BacklogStats{
+		Size:           3,
+		WeightedSize:   6,
+		SizeByPriority: map[string]int{PriorityHigh.String(): 1, PriorityMedium.String(): 1, PriorityLow.String(): 1},
+	}
+
+	// The entries started, finished, or dropped in 2023, but only the ones finished in 2023 in the finished stats
+	stats := computeStats(entries, date("2023-01-01"), date("2023-12-31"))
+	if stats.Total != 4 {
+		t.Errorf("expected total: 4, actual total: %d", stats.Total)
+	}
+	if expected := map[string]int{StatusFinished.String(): 3, StatusDropped.String(): 1}; !reflect.DeepEqual(stats.CountByStatus, expected) {
+		t.Errorf("expected count by status: %v, actual count by status: %v", expected, stats.CountByStatus)
+	}
+	if expected := map[string]int{"2023-01": 1, "2023-03": 1}; !reflect.DeepEqual(stats.FinishedByMonth, expected) {
+		t.Errorf("expected finished by month: %v, actual finished by month: %v", expected, stats.FinishedByMonth)
+	}
+	if expected := map[string]int{"2023": 2}; !reflect.DeepEqual(stats.FinishedByYear, expected) {
+		t.Errorf("expected finished by year: %v, actual finished by year: %v", expected, stats.FinishedByYear)
+	}
+	if stats.AverageStars != 11.0/3 {
+		t.Errorf("expected average stars: %f, actual average stars: %f", 11.0/3, stats.AverageStars)
+	}
+	if stats.AverageDaysToFinish != 15 {
+		t.Errorf("expected average days to finish: 15, actual average days to finish: %f", stats.AverageDaysToFinish)
+	}
+	expectedTopTags := []*EntityCount{{Name: "Open World", Count: 2}, {Name: "RPG", Count: 2}, {Name: "Roguelike", Count: 1}}
+	if !reflect.DeepEqual(stats.TopEntities["tags"], expectedTopTags) {
+		t.Errorf("expected top tags: %v, actual top tags: %v", expectedTopTags, stats.TopEntities["tags"])
+	}
+	if !reflect.DeepEqual(stats.Backlog, expectedBacklog) {
+		t.Errorf("expected backlog: %+v, actual backlog: %+v", expectedBacklog, stats.Backlog)
+	}
+
+	// All entries without a range
+	stats = computeStats(entries, time.Time{}, time.Time{})
+	if stats.Total != len(entries) {
+		t.Errorf("expected total: %d, actual total: %d", len(entries), stats.Total)
+	}
+	if expected := map[string]int{"2022": 1, "2023": 2, "2024": 1}; !reflect.DeepEqual(stats.FinishedByYear, expected) {
+		t.Errorf("expected finished by year: %v, actual finished by year: %v", expected, stats.FinishedByYear)
+	}
+	if stats.AverageDaysToFinish != 26.25 {
+		t.Errorf("expected average days to finish: 26.25, actual average days to finish: %f", stats.AverageDaysToFinish)
+	}
+	if !reflect.DeepEqual(stats.Backlog, expectedBacklog) {
+		t.Errorf("expected backlog: %+v, actual backlog: %+v", expectedBacklog, stats.Backlog)
+	}
+}
@@ -0,0 +1,69 @@
+package trackers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+)
+
+var getStatsRouteTestTable = []struct {
+	query              string
+	expectedStatusCode int
+}{
+	{"", http.StatusOK},
+	{"?from=2023-01-01&to=2023-12-31", http.StatusOK},
+	{"?from=2023-01-01", http.StatusOK},
+	{"?to=01/01/2023", http.StatusBadRequest},
+}
+
+func TestGetStatsRoute(t *testing.T) {
+	router := api.SetupRouter()
+
+	for _, test := range getStatsRouteTestTable {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/v1/trackers/stats"+test.query, nil)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		router.ServeHTTP(w, req)
+
+		if test.expectedStatusCode != w.Code {
+			t.Errorf("expected status code: %d, actual status code: %d", test.expectedStatusCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var res getStatsResponse
+		jsonBytes := w.Body.Bytes()
+		if err := json.Unmarshal(jsonBytes, &res); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		for tracker, stats := range map[string]trackers.TrackerStats{"games": res.Games, "medias": res.Medias} {
+			statusTotal := 0
+			for _, count := range stats.CountByStatus {
+				statusTotal += count
+			}
+			if statusTotal != stats.Total {
+				t.Errorf("%s: expected the status counts to sum %d, actual sum: %d", tracker, stats.Total, statusTotal)
+			}
+			if stats.AverageStars < 0 || stats.AverageStars > 5 {
+				t.Errorf("%s: invalid average stars: %f", tracker, stats.AverageStars)
+			}
+			t.Logf("%s: %d entries, backlog weighted size: %d", tracker, stats.Total, stats.Backlog.WeightedSize)
+		}
+	}
+}
+
+type getStatsResponse struct {
+	Games  trackers.TrackerStats `json:"games"`
+	Medias trackers.TrackerStats `json:"medias"`
+}
@@ -0,0 +1,192 @@
+package trackers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// A trackerTable has the names of a tracker table and of its status and stars history tables
+type trackerTable struct {
+	table             string
+	historyTable      string
+	starsHistoryTable string
+	// Column of the history tables with the tracker entry name
+	ownerColumn string
+}
+
+var (
+	gamesTrackerTable  = trackerTable{"games_tracker", "games_status_history", "games_stars_history", "game_name"}
+	mediasTrackerTable = trackerTable{"medias_tracker", "medias_status_history", "medias_stars_history", "media_name"}
+)
+
+// A namedTracker is a tracker with its names in the API, like in the reminders and feeds
+type namedTracker struct {
+	tt trackerTable
+	// Name of the tracker in the query parameters and responses, like games
+	name  string
+	title string
+}
+
+var namedTrackers = []namedTracker{
+	{gamesTrackerTable, "games", "Games Tracker"},
+	{mediasTrackerTable, "medias", "Medias Tracker"},
+}
+
+// stampStatusDates sets the started date when an entry starts being played/watched/read,
+// and the finished/dropped date when it's finished or dropped.
+// Dates already set are not changed. A from status of 0 means the entry is new.
+func stampStatusDates(from, to Status, startedDate, finishedDroppedDate *time.Time, now time.Time) {
+	if from == to {
+		return
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch to {
+	case StatusInProgress:
+		if startedDate.IsZero() {
+			*startedDate = today
+		}
+	case StatusFinished, StatusDropped:
+		if finishedDroppedDate.IsZero() {
+			*finishedDroppedDate = today
+		}
+	}
+}
+
+// recordStatusChange adds a status change to the history of the user's entry. A from status of 0 means the entry is new.
+func recordStatusChange(tx *sql.Tx, tt trackerTable, userID int64, name string, from, to Status, changedAt time.Time) error {
+	var fromStatus interface{}
+	if from != 0 {
+		fromStatus = from
+	}
+
+	_, err := tx.Exec(
+		fmt.Sprintf("INSERT INTO %s (user_id, %s, from_status, to_status, changed_at) VALUES (?, ?, ?, ?, ?);", tt.historyTable, tt.ownerColumn),
+		userID, name, fromStatus, to, changedAt,
+	)
+
+	return err
+}
+
+// recordStarsChange adds a stars change to the history of the user's entry. A from stars of 0 means the entry wasn't rated.
+func recordStarsChange(tx *sql.Tx, tt trackerTable, userID int64, name string, from, to int, changedAt time.Time) error {
+	_, err := tx.Exec(
+		fmt.Sprintf("INSERT INTO %s (user_id, %s, from_stars, to_stars, changed_at) VALUES (?, ?, ?, ?, ?);", tt.starsHistoryTable, tt.ownerColumn),
+		userID, name, from, to, changedAt,
+	)
+
+	return err
+}
+
+// entryStatus is the current status, dates, and stars of a tracker entry
+type entryStatus struct {
+	Status              Status
+	StartedDate         time.Time
+	FinishedDroppedDate time.Time
+	ReleaseDate         time.Time
+	Stars               int
+}
+
+func getEntryStatus(tx *sql.Tx, tt trackerTable, userID int64, name string) (*entryStatus, error) {
+	var es entryStatus
+	err := tx.QueryRow(
+		fmt.Sprintf("SELECT status, started_date, finished_dropped_date, release_date, stars FROM %s WHERE user_id = ? AND name = ?;", tt.table),
+		userID, name,
+	).Scan(&es.Status, &es.StartedDate, &es.FinishedDroppedDate, &es.ReleaseDate, &es.Stars)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apierror.NotFound(fmt.Sprintf("%s does not exist", name))
+		}
+		return nil, err
+	}
+
+	return &es, nil
+}
+
+// A StatusChange is an entry of a game or media status history.
+// FromStatus is null when the entry was added to the tracker.
+type StatusChange struct {
+	// ID of the user that owns the entry
+	UserID     int64
+	Name       string
+	FromStatus *Status
+	ToStatus   Status
+	ChangedAt  time.Time
+}
+
+func getStatusHistory(tt trackerTable, scope userScope, name string) ([]*StatusChange, error) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		return nil, err
+	}
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	userCondition, args := scope.condition("user_id")
+	sqlQuery := fmt.Sprintf(`
+SELECT
+  user_id, %s, from_status, to_status, changed_at
+FROM
+  %s
+WHERE
+  (? = '' OR %s = ?)
+  AND %s
+ORDER BY
+  changed_at, id;`,
+		tt.ownerColumn, tt.historyTable, tt.ownerColumn, userCondition,
+	)
+	rows, err := db.Query(sqlQuery, append([]interface{}{name, name}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []*StatusChange{}
+	for rows.Next() {
+		statusChange := StatusChange{}
+		err = rows.Scan(&statusChange.UserID, &statusChange.Name, &statusChange.FromStatus, &statusChange.ToStatus, &statusChange.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, &statusChange)
+	}
+
+	return history, rows.Err()
+}
+
+func respondStatusHistory(c *gin.Context, tt trackerTable) {
+	scope, ok := getUserScope(c, true)
+	if !ok {
+		return
+	}
+
+	history, err := getStatusHistory(tt, scope, c.Query("name"))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"timeline": history})
+}
+
+// GetGamesTimeline returns the status changes of the games, oldest first.
+// The name query parameter limits the timeline to one game.
+func GetGamesTimeline(c *gin.Context) {
+	respondStatusHistory(c, gamesTrackerTable)
+}
+
+// GetMediasTimeline returns the status changes of the medias, oldest first.
+// The name query parameter limits the timeline to one media.
+func GetMediasTimeline(c *gin.Context) {
+	respondStatusHistory(c, mediasTrackerTable)
+}
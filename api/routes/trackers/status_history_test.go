@@ -0,0 +1,61 @@
+package trackers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+)
+
+var getTimelineRouteTestTable = []struct {
+	path string
+	name string
+}{
+	{"/v1/trackers/games_tracker/get_timeline", ""},
+	{"/v1/trackers/games_tracker/get_timeline", "Terraria"},
+	{"/v1/trackers/medias_tracker/get_timeline", ""},
+	{"/v1/trackers/medias_tracker/get_timeline", "The Dark Knight"},
+}
+
+func TestGetTimelineRoutes(t *testing.T) {
+	router := api.SetupRouter()
+
+	for _, test := range getTimelineRouteTestTable {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, test.path+"?name="+url.QueryEscape(test.name), nil)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		router.ServeHTTP(w, req)
+
+		var res getTimelineResponse
+		jsonBytes := w.Body.Bytes()
+		if err := json.Unmarshal(jsonBytes, &res); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if http.StatusOK != w.Code {
+			t.Errorf("expected status code: %d, actual status code: %d", http.StatusOK, w.Code)
+		}
+
+		for i, statusChange := range res.Timeline {
+			if test.name != "" && statusChange.Name != test.name {
+				t.Errorf("expected only status changes of %s, got one of %s", test.name, statusChange.Name)
+			}
+			if i > 0 && statusChange.ChangedAt.Before(res.Timeline[i-1].ChangedAt) {
+				t.Errorf("expected the timeline to be sorted by date")
+			}
+			t.Logf("%s: %v -> %s at %s", statusChange.Name, statusChange.FromStatus, statusChange.ToStatus, statusChange.ChangedAt)
+		}
+	}
+}
+
+type getTimelineResponse struct {
+	Timeline []trackers.StatusChange `json:"timeline"`
+}
@@ -0,0 +1,542 @@
+package trackers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSteamAPIURL   = "https://api.steampowered.com"
+	defaultSteamStoreURL = "https://store.steampowered.com"
+)
+
+// Release date formats of the Steam store, dates like "Q1 2024" or "Coming soon" are not parsed
+var steamReleaseDateLayouts = []string{"2 Jan, 2006", "Jan 2, 2006", "2 January, 2006", "January 2, 2006"}
+
+// Matches the app ID of Steam store URLs
+var steamAppURLRegex = regexp.MustCompile(`/app/(\d+)`)
+
+// steamClient gets data from the Steam Web API and the Steam store.
+// The store requests are spaced by the store request interval, and the requests rate limited by Steam are retried.
+type steamClient struct {
+	apiURL   string
+	storeURL string
+	apiKey   string
+	client   *http.Client
+	// Wait between the store requests and time of the last one
+	storeInterval    time.Duration
+	lastStoreRequest time.Time
+	maxAttempts      int
+	initialBackoff   time.Duration
+}
+
+func newSteamClient(configs *util.Configs) *steamClient {
+	sc := steamClient{
+		apiURL:   strings.TrimSuffix(configs.Steam.APIURL, "/"),
+		storeURL: strings.TrimSuffix(configs.Steam.StoreURL, "/"),
+		apiKey:   configs.Steam.APIKey,
+		client:   &http.Client{Timeout: 30 * time.Second},
+
+		storeInterval:  time.Duration(configs.Steam.StoreRequestIntervalMS) * time.Millisecond,
+		maxAttempts:    max(configs.Steam.MaxAttempts, 1),
+		initialBackoff: time.Duration(configs.Steam.InitialBackoffMS) * time.Millisecond,
+	}
+	if sc.apiURL == "" {
+		sc.apiURL = defaultSteamAPIURL
+	}
+	if sc.storeURL == "" {
+		sc.storeURL = defaultSteamStoreURL
+	}
+
+	return &sc
+}
+
+// getJSON decodes the response of the URL into v. If Steam responds with 429 Too Many Requests, the request is
+// retried after the Retry-After header wait, or else after the backoff, which doubles on each retry.
+func (sc *steamClient) getJSON(requestURL string, v interface{}) error {
+	backoff := sc.initialBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := sc.client.Get(requestURL)
+		if err != nil {
+			// The error message has the request URL, which can have the API key
+			if urlErr, ok := err.(*url.Error); ok {
+				return urlErr.Err
+			}
+			return err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < sc.maxAttempts {
+			resp.Body.Close()
+			wait := backoff
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+				wait = time.Duration(seconds) * time.Second
+			}
+			time.Sleep(wait)
+			backoff *= 2
+			continue
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("Steam responded with status code %d", resp.StatusCode)
+		}
+
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+}
+
+// waitForStore waits until the store request interval has passed since the last store request
+func (sc *steamClient) waitForStore() {
+	if wait := time.Until(sc.lastStoreRequest.Add(sc.storeInterval)); wait > 0 {
+		time.Sleep(wait)
+	}
+	sc.lastStoreRequest = time.Now()
+}
+
+type steamApp struct {
+	AppID int    `json:"appid"`
+	Name  string `json:"name"`
+}
+
+// getOwnedGames returns the games in the library of a Steam account. It needs an API key.
+func (sc *steamClient) getOwnedGames(steamID string) ([]*steamApp, error) {
+	if sc.apiKey == "" {
+		return nil, fmt.Errorf("a Steam API key is needed to get the owned games, set it in the steam.api_key config")
+	}
+
+	query := url.Values{}
+	query.Set("key", sc.apiKey)
+	query.Set("steamid", steamID)
+	query.Set("include_appinfo", "1")
+	query.Set("include_played_free_games", "1")
+	query.Set("format", "json")
+
+	var res struct {
+		Response struct {
+			Games []*steamApp `json:"games"`
+		} `json:"response"`
+	}
+	err := sc.getJSON(fmt.Sprintf("%s/IPlayerService/GetOwnedGames/v1/?%s", sc.apiURL, query.Encode()), &res)
+	if err != nil {
+		return nil, apierror.UpstreamScrape(fmt.Sprintf("couldn't get the owned games: %s", err))
+	}
+
+	return res.Response.Games, nil
+}
+
+// getWishlist returns the app IDs in the wishlist of a Steam account
+func (sc *steamClient) getWishlist(steamID string) ([]int, error) {
+	query := url.Values{}
+	query.Set("steamid", steamID)
+	if sc.apiKey != "" {
+		query.Set("key", sc.apiKey)
+	}
+
+	var res struct {
+		Response struct {
+			Items []*steamApp `json:"items"`
+		} `json:"response"`
+	}
+	err := sc.getJSON(fmt.Sprintf("%s/IWishlistService/GetWishlist/v1/?%s", sc.apiURL, query.Encode()), &res)
+	if err != nil {
+		return nil, apierror.UpstreamScrape(fmt.Sprintf("couldn't get the wishlist: %s", err))
+	}
+
+	appIDs := make([]int, 0, len(res.Response.Items))
+	for _, item := range res.Response.Items {
+		appIDs = append(appIDs, item.AppID)
+	}
+
+	return appIDs, nil
+}
+
+type steamAppDetails struct {
+	Name        string   `json:"name"`
+	HeaderImage string   `json:"header_image"`
+	Developers  []string `json:"developers"`
+	Publishers  []string `json:"publishers"`
+	Genres      []struct {
+		Description string `json:"description"`
+	} `json:"genres"`
+	ReleaseDate struct {
+		ComingSoon bool   `json:"coming_soon"`
+		Date       string `json:"date"`
+	} `json:"release_date"`
+}
+
+// getAppDetails returns the store data of a Steam app
+func (sc *steamClient) getAppDetails(appID int) (*steamAppDetails, error) {
+	var res map[string]struct {
+		Success bool             `json:"success"`
+		Data    *steamAppDetails `json:"data"`
+	}
+	sc.waitForStore()
+	err := sc.getJSON(fmt.Sprintf("%s/api/appdetails?appids=%d&l=english", sc.storeURL, appID), &res)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get the app details: %s", err)
+	}
+
+	details, ok := res[strconv.Itoa(appID)]
+	if !ok || !details.Success || details.Data == nil {
+		return nil, fmt.Errorf("app %d not found in the Steam store", appID)
+	}
+
+	return details.Data, nil
+}
+
+func (sc *steamClient) appURL(appID int) string {
+	return fmt.Sprintf("%s/app/%d/", sc.storeURL, appID)
+}
+
+func parseSteamReleaseDate(date string) time.Time {
+	for _, layout := range steamReleaseDateLayouts {
+		releaseDate, err := time.Parse(layout, date)
+		if err == nil {
+			return releaseDate
+		}
+	}
+
+	return time.Time{}
+}
+
+// getSteamAppID returns the app ID of a Steam store URL, 0 if it's not one
+func getSteamAppID(storeURL string) int {
+	match := steamAppURLRegex.FindStringSubmatch(storeURL)
+	if match == nil {
+		return 0
+	}
+	appID, _ := strconv.Atoi(match[1])
+
+	return appID
+}
+
+type SteamSyncRequest struct {
+	Wait    bool   `json:"wait" binding:"-"` // Whether the requester wants to wait for the task to be done before responding
+	SteamID string `json:"steam_id" binding:"required,numeric"`
+	// Whether to sync the owned games and the wishlist
+	Owned    bool `json:"owned" binding:"-"`
+	Wishlist bool `json:"wishlist" binding:"-"`
+	// Priority of the added games, medium by default
+	Priority Priority `json:"priority,omitempty" binding:"omitempty,IsValidEnum"`
+}
+
+// A SteamSyncItem is what the sync did with a game of the library or wishlist
+type SteamSyncItem struct {
+	AppID int    `json:"appid"`
+	Name  string `json:"name"`
+	// "owned" or "wishlist"
+	List   string `json:"list"`
+	Action string `json:"action"`
+	// Why the game was skipped or failed
+	Reason string `json:"reason,omitempty"`
+}
+
+type SteamSyncReport struct {
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Skipped int              `json:"skipped"`
+	Failed  int              `json:"failed"`
+	Items   []*SteamSyncItem `json:"items"`
+}
+
+func (report *SteamSyncReport) add(item *SteamSyncItem) {
+	report.Items = append(report.Items, item)
+	switch item.Action {
+	case ImportActionCreate:
+		report.Created++
+	case ImportActionUpdate:
+		report.Updated++
+	case ImportActionSkip:
+		report.Skipped++
+	case ImportActionFailed:
+		report.Failed++
+	}
+}
+
+func (report *SteamSyncReport) summary() string {
+	return fmt.Sprintf("%d created, %d updated, %d skipped, %d failed", report.Created, report.Updated, report.Skipped, report.Failed)
+}
+
+// trackedSteamGame is a game of the Games Tracker that may be a Steam app
+type trackedSteamGame struct {
+	Name                string
+	PurchasedOrGamePass bool
+}
+
+// getTrackedSteamGames returns the user's games of the Games Tracker by their Steam app ID and by their names
+func getTrackedSteamGames(configs *util.Configs, userID int64) (map[int]*trackedSteamGame, map[string]*trackedSteamGame, error) {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT url, name, purchased_or_gamepass FROM games_tracker WHERE user_id = ?;", userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	byAppID := map[int]*trackedSteamGame{}
+	byName := map[string]*trackedSteamGame{}
+	for rows.Next() {
+		var gameURL string
+		var game trackedSteamGame
+		if err = rows.Scan(&gameURL, &game.Name, &game.PurchasedOrGamePass); err != nil {
+			return nil, nil, err
+		}
+		if appID := getSteamAppID(gameURL); appID != 0 {
+			byAppID[appID] = &game
+		}
+		byName[game.Name] = &game
+	}
+
+	return byAppID, byName, rows.Err()
+}
+
+func setGamePurchased(configs *util.Configs, userID int64, name string) error {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec("UPDATE games_tracker SET purchased_or_gamepass = 1 WHERE user_id = ? AND name = ?;", userID, name)
+
+	return err
+}
+
+// addSteamGame adds a Steam app to the user's Games Tracker with its store details
+func addSteamGame(sc *steamClient, userID int64, appID int, details *steamAppDetails, priority Priority, purchased, wishlist bool) error {
+	var coverImg []byte
+	if details.HeaderImage != "" {
+		var err error
+		coverImg, err = util.GetImageFromURL(details.HeaderImage)
+		if err != nil {
+			return err
+		}
+	}
+	tags := make([]string, 0, len(details.Genres))
+	for _, genre := range details.Genres {
+		tags = append(tags, genre.Description)
+	}
+
+	// Owned games and wishlisted games already released are not started. The to be released games need
+	// a release date, so the coming soon games without an exact date, like "Q1 2025", are not started too.
+	releaseDate := parseSteamReleaseDate(details.ReleaseDate.Date)
+	status := StatusNotStarted
+	if wishlist && details.ReleaseDate.ComingSoon && !releaseDate.IsZero() {
+		status = StatusToBeReleased
+	}
+
+	return insertGameIntoDB(userID, &GameProperties{
+		URL:                 sc.appURL(appID),
+		Name:                details.Name,
+		CoverImg:            coverImg,
+		Priority:            priority,
+		Status:              status,
+		PurchasedOrGamePass: purchased,
+		Tags:                tags,
+		Developers:          nonNilSlice(details.Developers),
+		Publishers:          nonNilSlice(details.Publishers),
+		ReleaseDate:         releaseDate,
+	})
+}
+
+// syncSteam adds the owned and wishlisted games of a Steam account to the Games Tracker.
+// Owned games already in the tracker are marked as purchased, wishlisted games already in the tracker are skipped.
+// Games are matched by the app ID of their Steam store URL, or else by their names.
+func syncSteam(currentJob *job.Job, syncRequest *SteamSyncRequest, configs *util.Configs) (*SteamSyncReport, error) {
+	sc := newSteamClient(configs)
+	priority := syncRequest.Priority
+	if priority == 0 {
+		priority = PriorityMedium
+	}
+
+	var owned []*steamApp
+	var wishlist []int
+	var err error
+	if syncRequest.Owned {
+		currentJob.SetExecutingState("Getting the owned games")
+		owned, err = sc.getOwnedGames(syncRequest.SteamID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if syncRequest.Wishlist {
+		currentJob.SetExecutingState("Getting the wishlist")
+		wishlist, err = sc.getWishlist(syncRequest.SteamID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	byAppID, byName, err := getTrackedSteamGames(configs, currentJob.UserID)
+	if err != nil {
+		return nil, err
+	}
+	findTracked := func(appID int, name string) *trackedSteamGame {
+		if game, ok := byAppID[appID]; ok {
+			return game
+		}
+		return byName[name]
+	}
+
+	report := SteamSyncReport{Items: []*SteamSyncItem{}}
+	total := len(owned) + len(wishlist)
+	synced := map[int]bool{}
+	for i, app := range owned {
+		currentJob.SetExecutingStateWithValue(fmt.Sprintf("Syncing game %d of %d", i+1, total), app.Name)
+		item := &SteamSyncItem{AppID: app.AppID, Name: app.Name, List: "owned"}
+		synced[app.AppID] = true
+
+		if game := findTracked(app.AppID, app.Name); game != nil {
+			item.Name = game.Name
+			if game.PurchasedOrGamePass {
+				item.Action, item.Reason = ImportActionSkip, "already purchased"
+			} else if err := setGamePurchased(configs, currentJob.UserID, game.Name); err != nil {
+				item.Action, item.Reason = ImportActionFailed, err.Error()
+			} else {
+				item.Action = ImportActionUpdate
+			}
+			report.add(item)
+			continue
+		}
+
+		details, err := sc.getAppDetails(app.AppID)
+		if err != nil {
+			item.Action, item.Reason = ImportActionFailed, err.Error()
+			report.add(item)
+			continue
+		}
+		item.Name = details.Name
+		err = addSteamGame(sc, currentJob.UserID, app.AppID, details, priority, true, false)
+		if err != nil {
+			item.Action, item.Reason = ImportActionFailed, err.Error()
+		} else {
+			item.Action = ImportActionCreate
+		}
+		report.add(item)
+	}
+
+	for i, appID := range wishlist {
+		currentJob.SetExecutingStateWithValue(fmt.Sprintf("Syncing game %d of %d", len(owned)+i+1, total), strconv.Itoa(appID))
+		item := &SteamSyncItem{AppID: appID, List: "wishlist"}
+
+		if synced[appID] {
+			item.Action, item.Reason = ImportActionSkip, "owned"
+			report.add(item)
+			continue
+		}
+		synced[appID] = true
+		if game, ok := byAppID[appID]; ok {
+			item.Name = game.Name
+			item.Action, item.Reason = ImportActionSkip, "already in the tracker"
+			report.add(item)
+			continue
+		}
+
+		// The wishlist has no names, so the name match needs the store data
+		details, err := sc.getAppDetails(appID)
+		if err != nil {
+			item.Action, item.Reason = ImportActionFailed, err.Error()
+			report.add(item)
+			continue
+		}
+		item.Name = details.Name
+		if _, ok := byName[details.Name]; ok {
+			item.Action, item.Reason = ImportActionSkip, "already in the tracker"
+			report.add(item)
+			continue
+		}
+
+		err = addSteamGame(sc, currentJob.UserID, appID, details, priority, false, true)
+		if err != nil {
+			item.Action, item.Reason = ImportActionFailed, err.Error()
+		} else {
+			item.Action = ImportActionCreate
+		}
+		report.add(item)
+	}
+
+	return &report, nil
+}
+
+// SyncSteam adds the games owned and wishlisted by a Steam account to the Games Tracker.
+// Owned games are marked as purchased, wishlisted games are not started or to be released.
+func SyncSteam(c *gin.Context) {
+	// Create job
+	currentJob := job.Job{
+		Task:      "Sync Steam games with the Games Tracker database",
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
+	}
+
+	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
+	if !ok {
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
+		return
+	}
+	jobsList.AddJob(&currentJob)
+	currentJob.SetStartingState("Processing sync request")
+
+	// Validate request
+	var syncRequest SteamSyncRequest
+	if err := c.ShouldBindJSON(&syncRequest); err != nil {
+		bindingErr := getBindingError(err, &syncRequest)
+		currentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
+		return
+	}
+	if !syncRequest.Owned && !syncRequest.Wishlist {
+		err := fmt.Errorf("nothing to sync, set owned and/or wishlist to true")
+		currentJob.SetFailedState(err)
+		apierror.Respond(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		currentJob.SetFailedState(err)
+		apierror.Respond(c, err)
+		return
+	}
+
+	if !syncRequest.Wait {
+		go syncSteamTask(&currentJob, nil, &syncRequest, configs)
+		c.JSON(http.StatusOK, gin.H{"message": "Job created with success"})
+	} else {
+		syncSteamTask(&currentJob, c, &syncRequest, configs)
+	}
+}
+
+func syncSteamTask(currentJob *job.Job, context *gin.Context, syncRequest *SteamSyncRequest, configs *util.Configs) {
+	report, err := syncSteam(currentJob, syncRequest, configs)
+	if err != nil {
+		currentJob.SetFailedState(err)
+		if context != nil {
+			apierror.Respond(context, err)
+		}
+		return
+	}
+
+	currentJob.SetCompletedStateWithValue("Steam games synced", report.summary())
+	if context != nil {
+		context.JSON(http.StatusOK, report)
+	}
+}
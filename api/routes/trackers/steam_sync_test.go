@@ -0,0 +1,195 @@
+package trackers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+// newSteamStandIn returns a local server with the Steam endpoints used by the sync, and the number of app details
+// requests by app ID. The first app details request of the wishlist game is rate limited.
+func newSteamStandIn(t *testing.T) (*httptest.Server, func() map[string]int) {
+	var mu sync.Mutex
+	appDetailsRequests := map[string]int{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/IPlayerService/GetOwnedGames/v1/":
+			if r.URL.Query().Get("key") != "test-key" || r.URL.Query().Get("steamid") != "76561197960287930" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"response": {"game_count": 1, "games": [{"appid": 9000001, "name": "Steam Sync Owned Game"}]}}`)
+		case "/IWishlistService/GetWishlist/v1/":
+			fmt.Fprint(w, `{"response": {"items": [{"appid": 9000001}, {"appid": 9000002}, {"appid": 9000003}, {"appid": 9000004}]}}`)
+		case "/api/appdetails":
+			mu.Lock()
+			appDetailsRequests[r.URL.Query().Get("appids")]++
+			requests := appDetailsRequests[r.URL.Query().Get("appids")]
+			mu.Unlock()
+			if r.URL.Query().Get("appids") == "9000002" && requests == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			switch r.URL.Query().Get("appids") {
+			case "9000001":
+				fmt.Fprintf(w, `{"9000001": {"success": true, "data": {"name": "Steam Sync Owned Game", "header_image": "%s/header.jpg", "developers": ["Dev"], "publishers": ["Pub"], "genres": [{"description": "Action"}], "release_date": {"coming_soon": false, "date": "5 Dec, 2019"}}}}`, server.URL)
+			case "9000002":
+				fmt.Fprintf(w, `{"9000002": {"success": true, "data": {"name": "Steam Sync Wishlist Game", "header_image": "%s/header.jpg", "release_date": {"coming_soon": true, "date": "4 Dec, 2099"}}}}`, server.URL)
+			case "9000004":
+				fmt.Fprintf(w, `{"9000004": {"success": true, "data": {"name": "Steam Sync Undated Game", "header_image": "%s/header.jpg", "release_date": {"coming_soon": true, "date": "Q4 2099"}}}}`, server.URL)
+			default:
+				fmt.Fprintf(w, `{"%s": {"success": false}}`, r.URL.Query().Get("appids"))
+			}
+		case "/header.jpg":
+			w.Write([]byte("JPEG"))
+		default:
+			t.Errorf("unexpected request to the Steam stand-in: %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return server, func() map[string]int {
+		mu.Lock()
+		defer mu.Unlock()
+		requests := map[string]int{}
+		for appID, count := range appDetailsRequests {
+			requests[appID] = count
+		}
+		return requests
+	}
+}
+
+func TestSyncSteamRoute(t *testing.T) {
+	server, getAppDetailsRequests := newSteamStandIn(t)
+	defer server.Close()
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	steamConfigs := configs.Steam
+	configs.Steam = util.SteamConfigs{APIKey: "test-key", APIURL: server.URL, StoreURL: server.URL, StoreRequestIntervalMS: 1, MaxAttempts: 2, InitialBackoffMS: 1}
+	defer func() { configs.Steam = steamConfigs }()
+
+	router := api.SetupRouter()
+	defer func() {
+		for _, name := range []string{"Steam Sync Owned Game", "Steam Sync Wishlist Game", "Steam Sync Undated Game"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/v1/trackers/games_tracker/delete_game", strings.NewReader(fmt.Sprintf(`{"name": %q}`, name)))
+			router.ServeHTTP(w, req)
+		}
+	}()
+
+	requestBody, err := json.Marshal(trackers.SteamSyncRequest{Wait: true, SteamID: "76561197960287930", Owned: true, Wishlist: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/v1/trackers/games_tracker/sync_steam", bytes.NewBuffer(requestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(w, req)
+
+	if http.StatusOK != w.Code {
+		t.Fatalf("expected status code: %d, actual status code: %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var report trackers.SteamSyncReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	// Owned game created, owned game in the wishlist skipped, wishlist games created, unknown app failed
+	if report.Created != 3 || report.Skipped != 1 || report.Failed != 1 {
+		t.Errorf("unexpected report: %s", w.Body.String())
+	}
+
+	// The store details are requested once by game, plus the retry of the rate limited request
+	expectedRequests := map[string]int{"9000001": 1, "9000002": 2, "9000003": 1, "9000004": 1}
+	if requests := getAppDetailsRequests(); !reflect.DeepEqual(requests, expectedRequests) {
+		t.Errorf("expected the app details requests %v, got %v", expectedRequests, requests)
+	}
+
+	games, err := getGamesByName(router, "Steam Sync Owned Game", "Steam Sync Wishlist Game", "Steam Sync Undated Game")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owned := games["Steam Sync Owned Game"]; owned == nil || !owned.PurchasedOrGamePass || owned.Status != trackers.StatusNotStarted {
+		t.Errorf("expected the owned game to be purchased and not started, actual: %+v", owned)
+	}
+	if wishlisted := games["Steam Sync Wishlist Game"]; wishlisted == nil || wishlisted.PurchasedOrGamePass || wishlisted.Status != trackers.StatusToBeReleased {
+		t.Errorf("expected the wishlisted game to be to be released, actual: %+v", wishlisted)
+	}
+	// The to be released games need a release date
+	if undated := games["Steam Sync Undated Game"]; undated == nil || undated.Status != trackers.StatusNotStarted {
+		t.Errorf("expected the wishlisted game without a release date to be not started, actual: %+v", undated)
+	}
+}
+
+func TestSyncSteamRouteUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	steamConfigs := configs.Steam
+	configs.Steam = util.SteamConfigs{APIKey: "secret-test-key", APIURL: server.URL, StoreURL: server.URL, MaxAttempts: 1}
+	defer func() { configs.Steam = steamConfigs }()
+
+	router := api.SetupRouter()
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/v1/trackers/games_tracker/sync_steam", strings.NewReader(`{"wait": true, "steam_id": "76561197960287930", "owned": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected status code: %d, actual status code: %d, body: %s", http.StatusBadGateway, w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "secret-test-key") {
+		t.Errorf("expected no Steam API key in the error: %s", w.Body.String())
+	}
+}
+
+func getGamesByName(router http.Handler, names ...string) (map[string]*trackers.GetGameProperties, error) {
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/v1/trackers/games_tracker/get_all_games", nil)
+	if err != nil {
+		return nil, err
+	}
+	router.ServeHTTP(w, req)
+
+	var res struct {
+		Games []*trackers.GetGameProperties `json:"games"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		return nil, err
+	}
+
+	games := map[string]*trackers.GetGameProperties{}
+	for _, game := range res.Games {
+		for _, name := range names {
+			if game.Name == name {
+				games[name] = game
+			}
+		}
+	}
+
+	return games, nil
+}
@@ -5,14 +5,44 @@ import (
 
 	"github.com/tebeka/selenium"
 
+	"github.com/diogovalentte/dashboard/api/auth"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
+func TrackersRoutes(group *gin.RouterGroup) {
+	{
+		group.GET("/get_enums", GetEnums)
+		group.GET("/stats", GetStats)
+		group.GET("/reports/:year", GetYearReport)
+		group.GET("/export", GetExport)
+		group.POST("/import", ImportTrackers)
+		group.POST("/check_releases", auth.RequireAdmin(), CheckReleasesNow)
+	}
+}
+
+// FeedsRoutes are the routes read by calendar and feed readers apps
+func FeedsRoutes(group *gin.RouterGroup) {
+	{
+		group.GET("/calendar.ics", GetCalendar)
+		group.GET("/activity.atom", GetActivityAtom)
+		group.GET("/activity.rss", GetActivityRSS)
+	}
+}
+
+// PublicRoutes are the routes that don't need an API key, like the signed URLs linked by the feeds
+func PublicRoutes(group *gin.RouterGroup) {
+	{
+		group.GET("/cover_thumbnail", GetCoverThumbnail)
+	}
+}
+
 func GamesTrackerRoutes(group *gin.RouterGroup) {
 	games_tracker_group := group.Group("/games_tracker")
 	{
 		games_tracker_group.POST("/add_game", AddGame)
+		games_tracker_group.POST("/add_games", AddGames)
+		games_tracker_group.POST("/sync_steam", SyncSteam)
 		games_tracker_group.POST("/add_game_manually", AddGameManually)
 		games_tracker_group.POST("/update_game", UpdateGame)
 		games_tracker_group.POST("/delete_game", DeleteGame)
@@ -23,6 +53,11 @@ func GamesTrackerRoutes(group *gin.RouterGroup) {
 		games_tracker_group.GET("/get_not_started_games", GetNotStartedGames)
 		games_tracker_group.GET("/get_finished_games", GetFinishedGames)
 		games_tracker_group.GET("/get_dropped_games", GetDroppedGames)
+		games_tracker_group.GET("/get_filtered_games", GetFilteredGames)
+		games_tracker_group.GET("/get_tags", GetGamesTags)
+		games_tracker_group.GET("/get_developers", GetGamesDevelopers)
+		games_tracker_group.GET("/get_publishers", GetGamesPublishers)
+		games_tracker_group.GET("/get_timeline", GetGamesTimeline)
 	}
 }
 
@@ -30,6 +65,7 @@ func MediasTrackerRoutes(group *gin.RouterGroup) {
 	medias_tracker_group := group.Group("/medias_tracker")
 	{
 		medias_tracker_group.POST("/add_media", AddMedia)
+		medias_tracker_group.POST("/add_medias", AddMedias)
 		medias_tracker_group.POST("/add_media_manually", AddMediaManually)
 		medias_tracker_group.POST("/update_media", UpdateMedia)
 		medias_tracker_group.POST("/delete_media", DeleteMedia)
@@ -40,16 +76,54 @@ func MediasTrackerRoutes(group *gin.RouterGroup) {
 		medias_tracker_group.GET("/get_not_started_medias", GetNotStartedMedias)
 		medias_tracker_group.GET("/get_finished_medias", GetFinishedMedias)
 		medias_tracker_group.GET("/get_dropped_medias", GetDroppedMedias)
+		medias_tracker_group.GET("/get_filtered_medias", GetFilteredMedias)
+		medias_tracker_group.GET("/get_genres", GetMediasGenres)
+		medias_tracker_group.GET("/get_staff", GetMediasStaff)
+		medias_tracker_group.GET("/get_timeline", GetMediasTimeline)
 	}
 }
 
+// RegisterValidations registers the custom validations used by the trackers requests,
+// and makes the validation errors use the JSON names of the fields.
+// It must be called once before serving requests, as the validator isn't safe to change while validating.
+func RegisterValidations(v *validator.Validate) error {
+	v.RegisterTagNameFunc(jsonFieldName)
+	err := v.RegisterValidation("IsValidDate", IsValidDate)
+	if err != nil {
+		return err
+	}
+	err = v.RegisterValidation("IsValidEnum", IsValidEnum)
+	if err != nil {
+		return err
+	}
+	v.RegisterStructValidation(validateEntryRules,
+		AddGameRequest{}, GameProperties{}, UpdateGameRequest{},
+		AddMediaRequest{}, MediaProperties{}, UpdateMediaRequest{},
+	)
+
+	return nil
+}
+
+// IsValidDate validates the YYYY-MM-DD dates. The empty dates are valid, they clear the dates of the update requests.
 func IsValidDate(fl validator.FieldLevel) bool {
+	if fl.Field().String() == "" {
+		return true
+	}
 	layout := "2006-01-02"
 	_, err := time.Parse(layout, fl.Field().String())
 
 	return err == nil
 }
 
+// derefDateStr returns the date of an optional date field, empty if the field is not set
+func derefDateStr(date *string) string {
+	if date == nil {
+		return ""
+	}
+
+	return *date
+}
+
 type StructDateFields interface {
 	GetStartedDateStr() string
 	GetFinishedDroppedDateStr() string
@@ -59,6 +133,7 @@ type StructDateFields interface {
 	SetReleaseDate(time.Time)
 }
 
+// SetStructDateFields parses the dates of the input strings, it returns a validation error with the field of an invalid date
 func SetStructDateFields(input StructDateFields) error {
 	layout := "2006-01-02"
 
@@ -66,7 +141,7 @@ func SetStructDateFields(input StructDateFields) error {
 	if startedDateStr != "" {
 		startedDate, err := time.Parse(layout, startedDateStr)
 		if err != nil {
-			return err
+			return getDateFieldError("started_date", startedDateStr)
 		}
 		input.SetStartedDate(startedDate)
 	}
@@ -74,7 +149,7 @@ func SetStructDateFields(input StructDateFields) error {
 	if finishedDroppedDateStr != "" {
 		finishedDroppedDate, err := time.Parse(layout, finishedDroppedDateStr)
 		if err != nil {
-			return err
+			return getDateFieldError("finished_dropped_date", finishedDroppedDateStr)
 		}
 		input.SetFinishedDroppedDate(finishedDroppedDate)
 	}
@@ -82,7 +157,7 @@ func SetStructDateFields(input StructDateFields) error {
 	if releaseDateStr != "" {
 		releaseDate, err := time.Parse(layout, releaseDateStr)
 		if err != nil {
-			return err
+			return getDateFieldError("release_date", releaseDateStr)
 		}
 		input.SetReleaseDate(releaseDate)
 	}
@@ -9,7 +9,7 @@ import (
 )
 
 func setup() (*scraping.GeckoDriverPool, error) {
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+	configs, err := util.GetConfigs()
 	if err != nil {
 		return nil, err
 	}
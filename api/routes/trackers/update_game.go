@@ -1,16 +1,16 @@
 package trackers
 
 import (
-	"database/sql"
-	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
 	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
 	"github.com/diogovalentte/dashboard/api/util"
 	"github.com/gin-gonic/gin"
-	"github.com/gin-gonic/gin/binding"
-	"github.com/go-playground/validator/v10"
-	"net/http"
-	"path/filepath"
-	"time"
 )
 
 func UpdateGame(c *gin.Context) {
@@ -18,46 +18,39 @@ func UpdateGame(c *gin.Context) {
 	currentJob := job.Job{
 		Task:      "Update game in Games Tracker database",
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
 	}
 
 	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "couldn't create the task's job"})
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
 		return
 	}
 	jobsList.AddJob(&currentJob)
 	currentJob.SetStartingState("Processing game request")
 
 	// Validate request
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		err := v.RegisterValidation("IsValidDate", IsValidDate)
-		if err != nil {
-			currentJob.SetFailedState(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-			return
-		}
-	}
-
 	var gameRequest UpdateGameRequest
 	if err := c.ShouldBindJSON(&gameRequest); err != nil {
-		err = fmt.Errorf("invalid JSON fields, refer to the API documentation")
-		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		bindingErr := getBindingError(err, &gameRequest)
+		currentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
 		return
 	}
 
 	err := SetStructDateFields(&gameRequest)
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 
 	// Update media on DB
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+	configs, err := util.GetConfigs()
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -71,11 +64,11 @@ func UpdateGame(c *gin.Context) {
 
 func updateGameTask(currentJob *job.Job, gameRequest *UpdateGameRequest, configs *util.Configs, c *gin.Context, wait bool) {
 	currentJob.SetExecutingStateWithValue("Updating game on the DB", gameRequest.Name)
-	err := updateGame(gameRequest, configs)
+	err := updateGame(currentJob.UserID, gameRequest, configs)
 	if err != nil {
 		currentJob.SetFailedState(err)
 		if wait {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			apierror.Respond(c, err)
 		}
 		return
 	}
@@ -86,14 +79,42 @@ func updateGameTask(currentJob *job.Job, gameRequest *UpdateGameRequest, configs
 	}
 }
 
-func updateGame(gameRequest *UpdateGameRequest, configs *util.Configs) error {
-	dbPath := filepath.Join(configs.Database.FolderPath, "trackers.db")
-	db, err := sql.Open("sqlite3", dbPath)
+func updateGame(userID int64, gameRequest *UpdateGameRequest, configs *util.Configs) error {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	stm, err := db.Prepare(`
+	// Keep the current dates not sent in the request and set the dates of the new status.
+	// The dates sent empty are cleared.
+	current, err := getEntryStatus(tx, gamesTrackerTable, userID, gameRequest.Name)
+	if err != nil {
+		return err
+	}
+	if gameRequest.StartedDateStr == nil {
+		gameRequest.StartedDate = current.StartedDate
+	}
+	if gameRequest.FinishedDroppedDateStr == nil {
+		gameRequest.FinishedDroppedDate = current.FinishedDroppedDate
+	}
+	if gameRequest.ReleaseDateStr == nil {
+		gameRequest.ReleaseDate = current.ReleaseDate
+	}
+	now := time.Now()
+	stampStatusDates(current.Status, gameRequest.Status, &gameRequest.StartedDate, &gameRequest.FinishedDroppedDate, now)
+	err = checkEntryRules(gameRequest.Status, gameRequest.Stars, gameRequest.StartedDate, gameRequest.FinishedDroppedDate, gameRequest.ReleaseDate)
+	if err != nil {
+		return err
+	}
+
+	stm, err := tx.Prepare(`
 UPDATE
 	games_tracker
 SET
@@ -106,7 +127,8 @@ SET
 	commentary = ?,
 	release_date = ?
 WHERE
-   name = ?
+   user_id = ?
+   AND name = ?
 `)
 	if err != nil {
 		return err
@@ -122,41 +144,56 @@ WHERE
 		gameRequest.FinishedDroppedDate,
 		gameRequest.Commentary,
 		gameRequest.ReleaseDate,
+		userID,
 		gameRequest.Name,
 	)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if current.Status != gameRequest.Status {
+		err = recordStatusChange(tx, gamesTrackerTable, userID, gameRequest.Name, current.Status, gameRequest.Status, now)
+		if err != nil {
+			return err
+		}
+	}
+	if current.Stars != gameRequest.Stars {
+		err = recordStarsChange(tx, gamesTrackerTable, userID, gameRequest.Name, current.Stars, gameRequest.Stars, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
+// UpdateGameRequest has the new properties of an entry. The dates not sent are kept, and the dates sent empty are cleared.
 type UpdateGameRequest struct {
 	Wait                   bool      `json:"wait" binding:"-"` // Whether the requester wants to wait for the task to be done before responding
 	Name                   string    `json:"name" binding:"required"`
-	Priority               int       `json:"priority" binding:"required"`
-	Status                 int       `json:"status" binding:"required"`
+	Priority               Priority  `json:"priority" binding:"required,IsValidEnum"`
+	Status                 Status    `json:"status" binding:"required,IsValidEnum"`
 	Stars                  int       `json:"stars" binding:"omitempty,gte=0,lte=5"`
 	PurchasedGamePass      bool      `json:"purchased_or_gamepass" binding:"-"`
-	StartedDateStr         string    `json:"started_date" binding:"omitempty,IsValidDate"`
+	StartedDateStr         *string   `json:"started_date,omitempty" binding:"omitempty,IsValidDate"`
 	StartedDate            time.Time `binding:"-"`
-	FinishedDroppedDateStr string    `json:"finished_dropped_date" binding:"omitempty,IsValidDate"`
+	FinishedDroppedDateStr *string   `json:"finished_dropped_date,omitempty" binding:"omitempty,IsValidDate"`
 	FinishedDroppedDate    time.Time `binding:"-"`
-	ReleaseDateStr         string    `json:"release_date" binding:"omitempty,IsValidDate"`
+	ReleaseDateStr         *string   `json:"release_date,omitempty" binding:"omitempty,IsValidDate"`
 	ReleaseDate            time.Time `binding:"-"`
 	Commentary             string    `json:"commentary" binding:"-"`
 }
 
 func (gr *UpdateGameRequest) GetStartedDateStr() string {
-	return gr.StartedDateStr
+	return derefDateStr(gr.StartedDateStr)
 }
 
 func (gr *UpdateGameRequest) GetFinishedDroppedDateStr() string {
-	return gr.FinishedDroppedDateStr
+	return derefDateStr(gr.FinishedDroppedDateStr)
 }
 
 func (gr *UpdateGameRequest) GetReleaseDateStr() string {
-	return gr.ReleaseDateStr
+	return derefDateStr(gr.ReleaseDateStr)
 }
 
 func (gr *UpdateGameRequest) SetStartedDate(startedDate time.Time) {
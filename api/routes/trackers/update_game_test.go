@@ -3,11 +3,16 @@ package trackers_test
 import (
 	"bytes"
 	"encoding/json"
-	"github.com/diogovalentte/dashboard/api"
-	"github.com/diogovalentte/dashboard/api/routes/trackers"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
 )
 
 var updateGameRouteTestTable = []*trackers.UpdateGameRequest{
@@ -17,10 +22,10 @@ var updateGameRouteTestTable = []*trackers.UpdateGameRequest{
 		Priority:               1,
 		Status:                 1,
 		PurchasedGamePass:      true,
-		Stars:                  1,
-		StartedDateStr:         "2023-01-01",
-		FinishedDroppedDateStr: "2023-01-02",
-		ReleaseDateStr:         "2023-01-03",
+		Stars:                  0,
+		StartedDateStr:         dateStr("2023-01-01"),
+		FinishedDroppedDateStr: dateStr("2023-01-02"),
+		ReleaseDateStr:         dateStr("2023-01-03"),
 		Commentary:             "Totally my type.",
 	},
 	{
@@ -29,10 +34,10 @@ var updateGameRouteTestTable = []*trackers.UpdateGameRequest{
 		Priority:               1,
 		Status:                 1,
 		PurchasedGamePass:      false,
-		Stars:                  1,
-		StartedDateStr:         "2022-12-01",
-		FinishedDroppedDateStr: "2023-01-05",
-		ReleaseDateStr:         "2023-08-09",
+		Stars:                  0,
+		StartedDateStr:         dateStr("2022-12-01"),
+		FinishedDroppedDateStr: dateStr("2023-01-05"),
+		ReleaseDateStr:         dateStr("2023-08-09"),
 		Commentary:             "One of the worst games of all time.",
 	},
 	{
@@ -42,9 +47,9 @@ var updateGameRouteTestTable = []*trackers.UpdateGameRequest{
 		Status:                 1,
 		PurchasedGamePass:      true,
 		Stars:                  0,
-		StartedDateStr:         "2023-07-29",
-		FinishedDroppedDateStr: "2023-08-12",
-		ReleaseDateStr:         "2023-02-13",
+		StartedDateStr:         dateStr("2023-07-29"),
+		FinishedDroppedDateStr: dateStr("2023-08-12"),
+		ReleaseDateStr:         dateStr("2023-02-13"),
 		Commentary:             "The lowest surprise of 2023.",
 	},
 }
@@ -90,3 +95,75 @@ func TestUpdateGameRoute(t *testing.T) {
 		t.Log(actualMessage)
 	}
 }
+
+func TestUpdateGameDates(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("PNG"))
+	}))
+	defer imageServer.Close()
+
+	router := api.SetupRouter()
+	serve := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/v1/trackers/games_tracker/add_game_manually", fmt.Sprintf(`{"wait": true, "name": "Update Dates Test Game", "url": "https://example.com/update-dates", "cover_img_url": "%s", "priority": "high", "status": "finished", "stars": 4, "release_date": "2020-01-01", "started_date": "2021-01-01", "finished_dropped_date": "2021-02-01"}`, imageServer.URL))
+	if w.Code != http.StatusOK {
+		t.Fatalf("couldn't add the game: %s", w.Body.String())
+	}
+	defer serve("/v1/trackers/games_tracker/delete_game", `{"name": "Update Dates Test Game"}`)
+
+	testTable := []struct {
+		body                                               string
+		expectedStarted, expectedFinished, expectedRelease string
+	}{
+		// The dates not sent are kept
+		{`{"wait": true, "name": "Update Dates Test Game", "priority": "high", "status": "finished", "stars": 5}`, "2021-01-01", "2021-02-01", "2020-01-01"},
+		// The dates sent empty are cleared
+		{`{"wait": true, "name": "Update Dates Test Game", "priority": "high", "status": "in_progress", "finished_dropped_date": "", "release_date": ""}`, "2021-01-01", "", ""},
+		{`{"wait": true, "name": "Update Dates Test Game", "priority": "high", "status": "in_progress", "started_date": "2022-03-04", "release_date": "2019-05-06"}`, "2022-03-04", "", "2019-05-06"},
+	}
+	for _, test := range testTable {
+		if w := serve("/v1/trackers/games_tracker/update_game", test.body); w.Code != http.StatusOK {
+			t.Fatalf("%s: couldn't update the game: %s", test.body, w.Body.String())
+		}
+
+		games, err := getGamesByName(router, "Update Dates Test Game")
+		if err != nil {
+			t.Fatal(err)
+		}
+		game := games["Update Dates Test Game"]
+		if game == nil {
+			t.Fatal("expected the game to exist")
+		}
+		dates := []string{formatTestDate(game.StartedDate), formatTestDate(game.FinishedDroppedDate), formatTestDate(game.ReleaseDate)}
+		if expected := []string{test.expectedStarted, test.expectedFinished, test.expectedRelease}; !reflect.DeepEqual(dates, expected) {
+			t.Errorf("%s: expected the started, finished, and release dates %q, got %q", test.body, expected, dates)
+		}
+	}
+	// The rules are checked with the dates kept from the stored game
+	w = serve("/v1/trackers/games_tracker/update_game", `{"wait": true, "name": "Update Dates Test Game", "priority": "high", "status": "finished", "started_date": "2022-03-04", "finished_dropped_date": "2022-05-06"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("couldn't update the game: %s", w.Body.String())
+	}
+	w = serve("/v1/trackers/games_tracker/update_game", `{"wait": true, "name": "Update Dates Test Game", "priority": "high", "status": "finished", "started_date": "2022-06-01"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "finished_dropped_date should not be before the started_date 2022-06-01") {
+		t.Errorf("expected the finished date to not be before the started date, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func dateStr(date string) *string {
+	return &date
+}
+
+// formatTestDate returns the YYYY-MM-DD date, empty for the zero time
+func formatTestDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.Format("2006-01-02")
+}
@@ -1,16 +1,16 @@
 package trackers
 
 import (
-	"database/sql"
-	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
 	"github.com/diogovalentte/dashboard/api/job"
+	"github.com/diogovalentte/dashboard/api/logging"
 	"github.com/diogovalentte/dashboard/api/util"
 	"github.com/gin-gonic/gin"
-	"github.com/gin-gonic/gin/binding"
-	"github.com/go-playground/validator/v10"
-	"net/http"
-	"path/filepath"
-	"time"
 )
 
 func UpdateMedia(c *gin.Context) {
@@ -18,46 +18,39 @@ func UpdateMedia(c *gin.Context) {
 	currentJob := job.Job{
 		Task:      "Update media in Medias Tracker database",
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UserID:    auth.GetUser(c).ID,
+		RequestID: logging.GetRequestID(c),
 	}
 
 	jobsList, ok := c.MustGet("JobsList").(*job.Jobs)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "couldn't create the task's job"})
+		apierror.Respond(c, apierror.Internal("couldn't create the task's job"))
 		return
 	}
 	jobsList.AddJob(&currentJob)
 	currentJob.SetStartingState("Processing media request")
 
 	// Validate request
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		err := v.RegisterValidation("IsValidDate", IsValidDate)
-		if err != nil {
-			currentJob.SetFailedState(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-			return
-		}
-	}
-
 	var mediaRequest UpdateMediaRequest
 	if err := c.ShouldBindJSON(&mediaRequest); err != nil {
-		err = fmt.Errorf("invalid JSON fields, refer to the API documentation")
-		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		bindingErr := getBindingError(err, &mediaRequest)
+		currentJob.SetFailedState(bindingErr)
+		apierror.Respond(c, bindingErr)
 		return
 	}
 
 	err := SetStructDateFields(&mediaRequest)
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 
 	// Update media on DB
-	configs, err := util.GetConfigsWithoutDefaults("../../../configs")
+	configs, err := util.GetConfigs()
 	if err != nil {
 		currentJob.SetFailedState(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -71,11 +64,11 @@ func UpdateMedia(c *gin.Context) {
 
 func updateMediaTask(currentJob *job.Job, mediaRequest *UpdateMediaRequest, configs *util.Configs, c *gin.Context, wait bool) {
 	currentJob.SetExecutingStateWithValue("Updating media on the DB", mediaRequest.Name)
-	err := updateMedia(mediaRequest, configs)
+	err := updateMedia(currentJob.UserID, mediaRequest, configs)
 	if err != nil {
 		currentJob.SetFailedState(err)
 		if wait {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			apierror.Respond(c, err)
 		}
 		return
 	}
@@ -86,14 +79,42 @@ func updateMediaTask(currentJob *job.Job, mediaRequest *UpdateMediaRequest, conf
 	}
 }
 
-func updateMedia(mediaRequest *UpdateMediaRequest, configs *util.Configs) error {
-	dbPath := filepath.Join(configs.Database.FolderPath, "trackers.db")
-	db, err := sql.Open("sqlite3", dbPath)
+func updateMedia(userID int64, mediaRequest *UpdateMediaRequest, configs *util.Configs) error {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	stm, err := db.Prepare(`
+	// Keep the current dates not sent in the request and set the dates of the new status.
+	// The dates sent empty are cleared.
+	current, err := getEntryStatus(tx, mediasTrackerTable, userID, mediaRequest.Name)
+	if err != nil {
+		return err
+	}
+	if mediaRequest.StartedDateStr == nil {
+		mediaRequest.StartedDate = current.StartedDate
+	}
+	if mediaRequest.FinishedDroppedDateStr == nil {
+		mediaRequest.FinishedDroppedDate = current.FinishedDroppedDate
+	}
+	if mediaRequest.ReleaseDateStr == nil {
+		mediaRequest.ReleaseDate = current.ReleaseDate
+	}
+	now := time.Now()
+	stampStatusDates(current.Status, mediaRequest.Status, &mediaRequest.StartedDate, &mediaRequest.FinishedDroppedDate, now)
+	err = checkEntryRules(mediaRequest.Status, mediaRequest.Stars, mediaRequest.StartedDate, mediaRequest.FinishedDroppedDate, mediaRequest.ReleaseDate)
+	if err != nil {
+		return err
+	}
+
+	stm, err := tx.Prepare(`
 UPDATE
 	medias_tracker
 SET
@@ -106,7 +127,8 @@ SET
 	commentary = ?,
 	release_date = ?
 WHERE
-   name = ?
+   user_id = ?
+   AND name = ?
 `)
 	if err != nil {
 		return err
@@ -122,41 +144,56 @@ WHERE
 		mediaRequest.FinishedDroppedDate,
 		mediaRequest.Commentary,
 		mediaRequest.ReleaseDate,
+		userID,
 		mediaRequest.Name,
 	)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if current.Status != mediaRequest.Status {
+		err = recordStatusChange(tx, mediasTrackerTable, userID, mediaRequest.Name, current.Status, mediaRequest.Status, now)
+		if err != nil {
+			return err
+		}
+	}
+	if current.Stars != mediaRequest.Stars {
+		err = recordStarsChange(tx, mediasTrackerTable, userID, mediaRequest.Name, current.Stars, mediaRequest.Stars, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
+// UpdateMediaRequest has the new properties of an entry. The dates not sent are kept, and the dates sent empty are cleared.
 type UpdateMediaRequest struct {
 	Wait                   bool      `json:"wait" binding:"-"` // Whether the requester wants to wait for the task to be done before responding
 	Name                   string    `json:"name" binding:"required"`
-	MediaType              int       `json:"media_type" binding:"required"`
-	Priority               int       `json:"priority" binding:"required"`
-	Status                 int       `json:"status" binding:"required"`
+	MediaType              MediaType `json:"media_type" binding:"required,IsValidEnum"`
+	Priority               Priority  `json:"priority" binding:"required,IsValidEnum"`
+	Status                 Status    `json:"status" binding:"required,IsValidEnum"`
 	Stars                  int       `json:"stars" binding:"omitempty,gte=0,lte=5"`
-	StartedDateStr         string    `json:"started_date" binding:"omitempty,IsValidDate"`
+	StartedDateStr         *string   `json:"started_date,omitempty" binding:"omitempty,IsValidDate"`
 	StartedDate            time.Time `binding:"-"`
-	FinishedDroppedDateStr string    `json:"finished_dropped_date" binding:"omitempty,IsValidDate"`
+	FinishedDroppedDateStr *string   `json:"finished_dropped_date,omitempty" binding:"omitempty,IsValidDate"`
 	FinishedDroppedDate    time.Time `binding:"-"`
-	ReleaseDateStr         string    `json:"release_date" binding:"omitempty,IsValidDate"`
+	ReleaseDateStr         *string   `json:"release_date,omitempty" binding:"omitempty,IsValidDate"`
 	ReleaseDate            time.Time `binding:"-"`
 	Commentary             string    `json:"commentary" binding:"-"`
 }
 
 func (mr *UpdateMediaRequest) GetStartedDateStr() string {
-	return mr.StartedDateStr
+	return derefDateStr(mr.StartedDateStr)
 }
 
 func (mr *UpdateMediaRequest) GetFinishedDroppedDateStr() string {
-	return mr.FinishedDroppedDateStr
+	return derefDateStr(mr.FinishedDroppedDateStr)
 }
 
 func (mr *UpdateMediaRequest) GetReleaseDateStr() string {
-	return mr.ReleaseDateStr
+	return derefDateStr(mr.ReleaseDateStr)
 }
 
 func (mr *UpdateMediaRequest) SetStartedDate(startedDate time.Time) {
@@ -18,8 +18,9 @@ var updateMediaRouteTestTable = []*trackers.UpdateMediaRequest{
 		Priority:               1,
 		Status:                 1,
 		Stars:                  0,
-		StartedDateStr:         "2022-12-01",
-		FinishedDroppedDateStr: "2023-01-05",
+		StartedDateStr:         dateStr("2022-12-01"),
+		FinishedDroppedDateStr: dateStr("2023-01-05"),
+		ReleaseDateStr:         dateStr("2024-02-01"),
 		Commentary:             "Gravity Up",
 	},
 	{
@@ -29,8 +30,9 @@ var updateMediaRouteTestTable = []*trackers.UpdateMediaRequest{
 		Priority:               1,
 		Status:                 1,
 		Stars:                  0,
-		StartedDateStr:         "2022-02-01",
-		FinishedDroppedDateStr: "2023-12-05",
+		StartedDateStr:         dateStr("2022-02-01"),
+		FinishedDroppedDateStr: dateStr("2023-12-05"),
+		ReleaseDateStr:         dateStr("2024-03-15"),
 		Commentary:             "Shamefull",
 	},
 	{
@@ -40,8 +42,9 @@ var updateMediaRouteTestTable = []*trackers.UpdateMediaRequest{
 		Priority:               1,
 		Status:                 1,
 		Stars:                  0,
-		StartedDateStr:         "2022-01-15",
-		FinishedDroppedDateStr: "2023-08-23",
+		StartedDateStr:         dateStr("2022-01-15"),
+		FinishedDroppedDateStr: dateStr("2023-08-23"),
+		ReleaseDateStr:         dateStr("2024-04-30"),
 		Commentary:             "The Shiny Knight",
 	},
 }
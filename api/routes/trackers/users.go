@@ -0,0 +1,70 @@
+package trackers
+
+import (
+	"fmt"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// A userScope is the user whose entries a request can see
+type userScope struct {
+	// Zero means all users
+	userID int64
+}
+
+// allUsersScope can see the entries of all users
+var allUsersScope = userScope{}
+
+// condition returns a SQL condition matching the entries in the scope, and its arguments
+func (s userScope) condition(userIDColumn string) (string, []interface{}) {
+	return fmt.Sprintf("(? = 0 OR %s = ?)", userIDColumn), []interface{}{s.userID, s.userID}
+}
+
+// getUserScope returns the scope of the entries the request can see.
+// The "user" query parameter limits the scope to the user with the name, only admins can set it to other users.
+// Without it, admins see the entries of all users if allUsers is true, and the other users see only their own entries.
+// On errors, it responds with the error and returns false.
+func getUserScope(c *gin.Context, allUsers bool) (userScope, bool) {
+	user := auth.GetUser(c)
+
+	userName := c.Query("user")
+	if userName == "" {
+		if allUsers && user.IsAdmin() {
+			return allUsersScope, true
+		}
+		return userScope{user.ID}, true
+	}
+	if userName == user.Name {
+		return userScope{user.ID}, true
+	}
+
+	if !user.IsAdmin() {
+		apierror.Respond(c, apierror.Forbidden("only admins can see the entries of other users"))
+		return userScope{}, false
+	}
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return userScope{}, false
+	}
+	scopeUser, err := auth.GetUserByName(configs, userName)
+	if err != nil {
+		apierror.Respond(c, err)
+		return userScope{}, false
+	}
+	if scopeUser == nil {
+		apierror.Respond(c, apierror.NotFound(fmt.Sprintf("user %s does not exist", userName)))
+		return userScope{}, false
+	}
+
+	return userScope{scopeUser.ID}, true
+}
+
+// An entryKey identifies a tracker entry, the entries names are unique by user
+type entryKey struct {
+	UserID int64
+	Name   string
+}
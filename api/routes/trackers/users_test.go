@@ -0,0 +1,122 @@
+package trackers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+func TestUsersEntries(t *testing.T) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authConfigs := configs.Auth
+	configs.Auth.Enabled = true
+	defer func() { configs.Auth = authConfigs }()
+
+	// The user's entries and keys are deleted with it
+	user, err := auth.CreateUser(configs, "Test Entries User", auth.RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auth.DeleteUser(configs, user.Name)
+	userKey, err := auth.CreateAPIKey(configs, user.ID, "Test Entries Key", auth.ScopeWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminKey, err := auth.CreateAPIKey(configs, database.DefaultUserID, "Test Entries Admin Key", auth.ScopeWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auth.DeleteAPIKey(configs, database.DefaultUserID, "Test Entries Admin Key")
+
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("PNG"))
+	}))
+	defer imageServer.Close()
+
+	router := api.SetupRouter()
+	serve := func(method, path, key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Both users track a game with the same name
+	addBody := fmt.Sprintf(`{"wait": true, "name": "Users Test Game", "url": "https://example.com/users_test_game", "cover_img_url": "%s", "priority": "low", "status": "not_started"}`, imageServer.URL)
+	for _, key := range []string{adminKey, userKey} {
+		w := serve(http.MethodPost, "/v1/trackers/games_tracker/add_game_manually", key, addBody)
+		if w.Code != http.StatusOK {
+			t.Fatalf("couldn't add the game: %s", w.Body.String())
+		}
+	}
+	defer serve(http.MethodPost, "/v1/trackers/games_tracker/delete_game", adminKey, `{"name": "Users Test Game"}`)
+
+	testTable := []struct {
+		key                string
+		query              string
+		expectedStatusCode int
+		// IDs of the users whose game is expected in the response
+		expectedUserIDs []int64
+	}{
+		{userKey, "", http.StatusOK, []int64{user.ID}},
+		{adminKey, "", http.StatusOK, []int64{database.DefaultUserID, user.ID}},
+		{adminKey, "?user=Test Entries User", http.StatusOK, []int64{user.ID}},
+		{adminKey, "?user=default", http.StatusOK, []int64{database.DefaultUserID}},
+		{adminKey, "?user=Test Nonexistent User", http.StatusNotFound, nil},
+		{userKey, "?user=default", http.StatusForbidden, nil},
+	}
+
+	for _, test := range testTable {
+		w := serve(http.MethodGet, "/v1/trackers/games_tracker/get_all_games"+test.query, test.key, "")
+		if test.expectedStatusCode != w.Code {
+			t.Errorf("%q: expected status code: %d, actual status code: %d, body: %s", test.query, test.expectedStatusCode, w.Code, w.Body.String())
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var res struct {
+			Games []*trackers.GetGameProperties `json:"games"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Error(err)
+			continue
+		}
+		var userIDs []int64
+		for _, game := range res.Games {
+			if test.key == userKey && game.UserID != user.ID {
+				t.Errorf("%q: the user can see the game %s of the user %d", test.query, game.Name, game.UserID)
+			}
+			if game.Name == "Users Test Game" {
+				userIDs = append(userIDs, game.UserID)
+			}
+		}
+		if fmt.Sprint(userIDs) != fmt.Sprint(test.expectedUserIDs) {
+			t.Errorf("%q: expected the game of the users %v, actual users: %v", test.query, test.expectedUserIDs, userIDs)
+		}
+	}
+
+	// Deleting the game of a user doesn't delete the game of the other users
+	w := serve(http.MethodPost, "/v1/trackers/games_tracker/delete_game", userKey, `{"name": "Users Test Game"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("couldn't delete the game: %s", w.Body.String())
+	}
+	w = serve(http.MethodPost, "/v1/trackers/games_tracker/get_game", adminKey, `{"name": "Users Test Game"}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the admin game to still exist, status code: %d, body: %s", w.Code, w.Body.String())
+	}
+}
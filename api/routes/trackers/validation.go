@@ -0,0 +1,335 @@
+package trackers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/go-playground/validator/v10"
+)
+
+// A FieldError is a request field that failed a validation rule.
+// The field is its JSON name, with the index of list items, like games[0].url.
+type FieldError struct {
+	Field string `json:"field"`
+	// Name of the failed validator rule, like required or IsValidDate.
+	// It's type for values of the wrong JSON type, and json for invalid request bodies.
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// newFieldsError returns a validation error with the fields as details, and their messages as the error message
+func newFieldsError(fieldErrors []FieldError) *apierror.Error {
+	messages := make([]string, len(fieldErrors))
+	for i, fieldError := range fieldErrors {
+		messages[i] = fieldError.Message
+	}
+
+	return apierror.Validation(strings.Join(messages, "; ")).WithDetails(fieldErrors)
+}
+
+// getBindingError returns a validation error with the fields of a ShouldBindJSON error of the request
+func getBindingError(err error, request any) *apierror.Error {
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+	switch {
+	case errors.As(err, &validationErrors):
+		fieldErrors := make([]FieldError, len(validationErrors))
+		for i, validationError := range validationErrors {
+			fieldErrors[i] = getValidationFieldError(validationError)
+		}
+		return newFieldsError(fieldErrors)
+	case errors.As(err, &typeError):
+		field := typeError.Field
+		if field == "" {
+			// The decoder doesn't add the field to the errors returned by the enums UnmarshalJSON
+			field = findJSONField(reflect.TypeOf(request), typeError.Type, "")
+		}
+		return newFieldsError([]FieldError{getTypeFieldError(typeError, field)})
+	case errors.As(err, &syntaxError):
+		return newFieldsError([]FieldError{{
+			Rule:    "json",
+			Message: fmt.Sprintf("the request body isn't valid JSON, error at the character %d: %s", syntaxError.Offset, syntaxError),
+		}})
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return newFieldsError([]FieldError{{Rule: "json", Message: "the request body isn't valid JSON, it ends before the JSON is complete"}})
+	case errors.Is(err, io.EOF):
+		return newFieldsError([]FieldError{{Rule: "json", Message: "the request body is empty"}})
+	}
+
+	return newFieldsError([]FieldError{{Rule: "json", Message: err.Error()}})
+}
+
+// jsonFieldName returns the JSON name of a struct field, used by the validator in the error namespaces
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+// An enumNamer is an enum with names, like Status
+type enumNamer interface {
+	EnumNames() []string
+}
+
+func getValidationFieldError(validationError validator.FieldError) FieldError {
+	// The namespace starts with the request struct name, like AddGamesRequest.games[0].url
+	field := validationError.Namespace()
+	if _, afterStruct, found := strings.Cut(field, "."); found {
+		field = afterStruct
+	}
+	fieldError := FieldError{Field: field, Rule: validationError.Tag()}
+
+	kind := validationError.Kind()
+	var requirement string
+	switch validationError.Tag() {
+	case "required":
+		requirement = "is required"
+	case "http_url", "url":
+		requirement = fmt.Sprintf("should be an HTTP URL, like https://store.steampowered.com/app/105600, got %q", validationError.Value())
+	case "IsValidDate":
+		requirement = fmt.Sprintf("should be a date in the YYYY-MM-DD format, like 2024-01-31, got %q", validationError.Value())
+	case "IsValidEnum":
+		requirement = fmt.Sprintf("should be one of %s, got %v", joinEnumNames(validationError.Value()), validationError.Value())
+	case "numeric":
+		requirement = fmt.Sprintf("should have only digits, got %q", validationError.Value())
+	case "IsNotBeforeStartedDate":
+		requirement = fmt.Sprintf("should not be before the started_date %s, got %s", validationError.Param(), validationError.Value())
+	case "IsFinishedOrDropped":
+		requirement = "can only be set for finished or dropped entries"
+	case "RequiredIfToBeReleased":
+		requirement = "is required for to_be_released entries"
+	case "gte", "min":
+		requirement = "should be at least " + describeLimit(kind, validationError.Param())
+	case "lte", "max":
+		requirement = "should be at most " + describeLimit(kind, validationError.Param())
+	default:
+		requirement = fmt.Sprintf("failed the %s rule", validationError.Tag())
+	}
+	fieldError.Message = fmt.Sprintf("%s %s", field, requirement)
+
+	return fieldError
+}
+
+// joinEnumNames returns the names of an enum value's type separated by commas
+func joinEnumNames(value any) string {
+	if enum, ok := value.(enumNamer); ok {
+		return strings.Join(enum.EnumNames(), ", ")
+	}
+
+	return "its valid values"
+}
+
+// describeLimit returns a min or max limit of the field kind, like "3 items" for slices
+func describeLimit(kind reflect.Kind, param string) string {
+	var unit string
+	switch kind {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " item"
+	case reflect.String:
+		unit = " character"
+	default:
+		return param
+	}
+	if param != "1" {
+		unit += "s"
+	}
+
+	return param + unit
+}
+
+// getTypeFieldError returns the field error of a JSON value with the wrong type, like a number for a string field.
+// The enums return these errors for unknown names.
+func getTypeFieldError(typeError *json.UnmarshalTypeError, field string) FieldError {
+	fieldError := FieldError{Field: field, Rule: "type"}
+	if field == "" {
+		field = "the request body"
+	}
+
+	if typeError.Type != nil {
+		if enum, ok := reflect.Zero(typeError.Type).Interface().(enumNamer); ok {
+			fieldError.Rule = "IsValidEnum"
+			fieldError.Message = fmt.Sprintf("%s should be one of %s, got %s", field, strings.Join(enum.EnumNames(), ", "), typeError.Value)
+			return fieldError
+		}
+	}
+	fieldError.Message = fmt.Sprintf("%s should be %s, got %s", field, describeJSONType(typeError.Type), typeError.Value)
+
+	return fieldError
+}
+
+// findJSONField returns the JSON name of the first field of the struct type with the field type, empty if there is none.
+// The fields of list items have [] after the list name, like games[].priority, as the item index isn't known.
+func findJSONField(structType, fieldType reflect.Type, prefix string) string {
+	for structType != nil && structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType == nil || structType.Kind() != reflect.Struct || fieldType == nil {
+		return ""
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := jsonFieldName(field)
+		if !field.IsExported() || name == "" {
+			continue
+		}
+
+		t := field.Type
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch {
+		case t == fieldType:
+			return prefix + name
+		case t.Kind() == reflect.Slice:
+			if found := findJSONField(t.Elem(), fieldType, prefix+name+"[]."); found != "" {
+				return found
+			}
+		case t.Kind() == reflect.Struct:
+			if found := findJSONField(t, fieldType, prefix+name+"."); found != "" {
+				return found
+			}
+		}
+	}
+
+	return ""
+}
+
+// describeJSONType returns the JSON type of a Go type, like "a number" for int
+func describeJSONType(t reflect.Type) string {
+	if t == nil {
+		return "another type"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+
+	return "a " + t.String()
+}
+
+// getDateFieldError returns the validation error of a date field that doesn't have the YYYY-MM-DD format
+func getDateFieldError(field, value string) *apierror.Error {
+	return newFieldsError([]FieldError{{
+		Field:   field,
+		Rule:    "IsValidDate",
+		Message: fmt.Sprintf("%s should be a date in the YYYY-MM-DD format, like 2024-01-31, got %q", field, value),
+	}})
+}
+
+// getListItemError returns the error of an item of a request list, with the field errors named by the item, like games[0].started_date.
+// Other errors are returned as they are.
+func getListItemError(err error, list string, i int) error {
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	fieldErrors, ok := apiErr.Details.([]FieldError)
+	if !ok {
+		return err
+	}
+
+	item := fmt.Sprintf("%s[%d]", list, i)
+	itemFieldErrors := make([]FieldError, len(fieldErrors))
+	for j, fieldError := range fieldErrors {
+		itemFieldErrors[j] = FieldError{
+			Field:   item + "." + fieldError.Field,
+			Rule:    fieldError.Rule,
+			Message: item + "." + fieldError.Message,
+		}
+	}
+
+	return newFieldsError(itemFieldErrors)
+}
+
+// validateEntryRules validates the rules between the fields of the games and medias requests.
+// The rules of the fields a request doesn't have are skipped, like the release date of the requests that scrape it.
+func validateEntryRules(sl validator.StructLevel) {
+	entry := sl.Current()
+	status, _ := entry.FieldByName("Status").Interface().(Status)
+
+	started := dateStrField(entry, "StartedDateStr")
+	finishedDropped := dateStrField(entry, "FinishedDroppedDateStr")
+	if started.IsValid() && finishedDropped.IsValid() && started.String() != "" && finishedDropped.String() != "" {
+		startedDate, startedErr := time.Parse("2006-01-02", started.String())
+		finishedDroppedDate, finishedDroppedErr := time.Parse("2006-01-02", finishedDropped.String())
+		// Invalid dates are reported by the IsValidDate rule
+		if startedErr == nil && finishedDroppedErr == nil && finishedDroppedDate.Before(startedDate) {
+			sl.ReportError(finishedDropped.String(), "finished_dropped_date", "FinishedDroppedDateStr", "IsNotBeforeStartedDate", started.String())
+		}
+	}
+
+	stars := entry.FieldByName("Stars")
+	if stars.IsValid() && stars.Int() != 0 && status != StatusFinished && status != StatusDropped {
+		sl.ReportError(stars.Int(), "stars", "Stars", "IsFinishedOrDropped", "")
+	}
+
+	release := dateStrField(entry, "ReleaseDateStr")
+	if release.IsValid() && release.String() == "" && status == StatusToBeReleased {
+		sl.ReportError(release.String(), "release_date", "ReleaseDateStr", "RequiredIfToBeReleased", "")
+	}
+}
+
+// checkEntryRules returns a validation error if the final properties of an entry break the rules of validateEntryRules.
+// The requests are validated when bound, but the dates kept by the updates and the imported and synced entries
+// are only checked here, before they're stored.
+func checkEntryRules(status Status, stars int, startedDate, finishedDroppedDate, releaseDate time.Time) error {
+	var fieldErrors []FieldError
+	if !startedDate.IsZero() && !finishedDroppedDate.IsZero() && finishedDroppedDate.Before(startedDate) {
+		fieldErrors = append(fieldErrors, FieldError{
+			Field: "finished_dropped_date",
+			Rule:  "IsNotBeforeStartedDate",
+			Message: fmt.Sprintf("finished_dropped_date should not be before the started_date %s, got %s",
+				startedDate.Format("2006-01-02"), finishedDroppedDate.Format("2006-01-02")),
+		})
+	}
+	if stars != 0 && status != StatusFinished && status != StatusDropped {
+		fieldErrors = append(fieldErrors, FieldError{Field: "stars", Rule: "IsFinishedOrDropped", Message: "stars can only be set for finished or dropped entries"})
+	}
+	if releaseDate.IsZero() && status == StatusToBeReleased {
+		fieldErrors = append(fieldErrors, FieldError{Field: "release_date", Rule: "RequiredIfToBeReleased", Message: "release_date is required for to_be_released entries"})
+	}
+	if len(fieldErrors) > 0 {
+		return newFieldsError(fieldErrors)
+	}
+
+	return nil
+}
+
+// dateStrField returns the date string field of the entry. The optional dates of the update requests
+// are invalid values if not set, so their rules are skipped.
+func dateStrField(entry reflect.Value, name string) reflect.Value {
+	field := entry.FieldByName(name)
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return reflect.Value{}
+		}
+		return field.Elem()
+	}
+
+	return field
+}
@@ -0,0 +1,35 @@
+package trackers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+)
+
+func TestGetListItemError(t *testing.T) {
+	err := getListItemError(getDateFieldError("started_date", "31/01/2024"), "games", 1)
+
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != apierror.CodeValidation {
+		t.Fatalf("expected a validation error, actual error: %v", err)
+	}
+	expected := []FieldError{{
+		Field:   "games[1].started_date",
+		Rule:    "IsValidDate",
+		Message: `games[1].started_date should be a date in the YYYY-MM-DD format, like 2024-01-31, got "31/01/2024"`,
+	}}
+	if !reflect.DeepEqual(apiErr.Details, expected) {
+		t.Errorf("expected field errors: %+v, actual field errors: %+v", expected, apiErr.Details)
+	}
+	if apiErr.Message != expected[0].Message {
+		t.Errorf("expected message: %q, actual message: %q", expected[0].Message, apiErr.Message)
+	}
+
+	// The errors without field errors are kept
+	internalErr := errors.New("database is locked")
+	if err := getListItemError(internalErr, "medias", 0); err != internalErr {
+		t.Errorf("expected the error to be kept, actual error: %v", err)
+	}
+}
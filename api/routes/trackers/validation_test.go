@@ -0,0 +1,175 @@
+package trackers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
+)
+
+var validationRouteTestTable = []struct {
+	path string
+	body string
+	// The field and rule of each expected field error, like "url:required"
+	expectedFieldErrors []string
+	// Expected in the message of the first field error
+	expectedMessage string
+}{
+	{
+		"/v1/trackers/games_tracker/add_game",
+		`{"url": "not a url", "priority": "high", "status": "finished", "stars": 7, "started_date": "31/01/2024"}`,
+		[]string{"url:http_url", "stars:lte", "started_date:IsValidDate"},
+		`url should be an HTTP URL`,
+	},
+	{
+		"/v1/trackers/games_tracker/add_game",
+		`{"url": "https://store.steampowered.com/app/105600", "priority": "urgent", "status": "finished"}`,
+		[]string{"priority:IsValidEnum"},
+		`priority should be one of high, medium, low, got "urgent"`,
+	},
+	{
+		"/v1/trackers/games_tracker/add_game",
+		`{"url": "https://store.steampowered.com/app/105600", "priority": 9, "status": "finished"}`,
+		[]string{"priority:IsValidEnum"},
+		`priority should be one of high, medium, low`,
+	},
+	{
+		"/v1/trackers/games_tracker/add_game_manually",
+		`{"url": "https://example.com", "name": "Game", "cover_img_url": "https://example.com/cover.png", "priority": "low", "status": 1, "stars": "five"}`,
+		[]string{"stars:type"},
+		`stars should be an integer, got string`,
+	},
+	{
+		"/v1/trackers/games_tracker/add_games",
+		`{"games": [{"url": "https://store.steampowered.com/app/105600", "priority": "high", "status": "not_started"}, {"priority": "high", "status": "not_started"}]}`,
+		[]string{"games[1].url:required"},
+		`games[1].url is required`,
+	},
+	{
+		"/v1/trackers/games_tracker/add_games",
+		`{"games": [{"url": "https://store.steampowered.com/app/105600", "priority": "urgent", "status": "not_started"}]}`,
+		[]string{"games[].priority:IsValidEnum"},
+		`games[].priority should be one of high, medium, low, got "urgent"`,
+	},
+	{
+		"/v1/trackers/games_tracker/update_game",
+		`{"priority": "high", "status": "finished", "finished_dropped_date": "2024-13-01"}`,
+		[]string{"name:required", "finished_dropped_date:IsValidDate"},
+		`name is required`,
+	},
+	{
+		"/v1/trackers/games_tracker/add_game",
+		`{"url": "https://store.steampowered.com/app/105600", "priority": "high", "status": "in_progress", "stars": 4, "started_date": "2024-02-01", "finished_dropped_date": "2024-01-31"}`,
+		[]string{"finished_dropped_date:IsNotBeforeStartedDate", "stars:IsFinishedOrDropped"},
+		`finished_dropped_date should not be before the started_date 2024-02-01, got 2024-01-31`,
+	},
+	{
+		"/v1/trackers/games_tracker/add_games",
+		`{"games": [{"url": "https://store.steampowered.com/app/105600", "priority": "high", "status": "not_started", "stars": 2}]}`,
+		[]string{"games[0].stars:IsFinishedOrDropped"},
+		`games[0].stars can only be set for finished or dropped entries`,
+	},
+	{
+		"/v1/trackers/games_tracker/update_game",
+		`{"name": "Game", "priority": "high", "status": "to_be_released", "release_date": ""}`,
+		[]string{"release_date:RequiredIfToBeReleased"},
+		`release_date is required for to_be_released entries`,
+	},
+	{
+		"/v1/trackers/games_tracker/sync_steam",
+		`{"steam_id": "abc", "owned": true}`,
+		[]string{"steam_id:numeric"},
+		`steam_id should have only digits`,
+	},
+	{
+		"/v1/trackers/games_tracker/get_game",
+		``,
+		[]string{":json"},
+		`the request body is empty`,
+	},
+	{
+		"/v1/trackers/medias_tracker/add_media",
+		`{"url": "https://www.imdb.com/title/tt0903747/", "type": "podcast", "priority": "high", "status": "finished"}`,
+		[]string{"type:IsValidEnum"},
+		`type should be one of series, movie, book, comic_book, got "podcast"`,
+	},
+	{
+		"/v1/trackers/medias_tracker/add_media_manually",
+		`{"url": "https://example.com", "name": "Media", "priority": "low", "status": "not_started", "media_type": "movie"}`,
+		[]string{"cover_img_url:required"},
+		`cover_img_url is required`,
+	},
+	{
+		"/v1/trackers/medias_tracker/update_media",
+		`{"name": "Media", "priority": "low", "status": "not_started", "media_type": "movie", "release_date": "2024/01/31"}`,
+		[]string{"release_date:IsValidDate"},
+		`release_date should be a date in the YYYY-MM-DD format, like 2024-01-31, got "2024/01/31"`,
+	},
+	{
+		"/v1/trackers/medias_tracker/add_media_manually",
+		`{"url": "https://example.com", "name": "Media", "cover_img_url": "https://example.com/cover.png", "priority": "low", "status": "to_be_released", "media_type": "movie", "stars": 5}`,
+		[]string{"stars:IsFinishedOrDropped", "release_date:RequiredIfToBeReleased"},
+		`stars can only be set for finished or dropped entries`,
+	},
+	{
+		"/v1/trackers/medias_tracker/update_media",
+		`{"name": "Media", "priority": "low", "status": "dropped", "media_type": "movie", "started_date": "2024-03-01", "finished_dropped_date": "2023-03-01"}`,
+		[]string{"finished_dropped_date:IsNotBeforeStartedDate"},
+		`finished_dropped_date should not be before the started_date 2024-03-01`,
+	},
+	{
+		"/v1/trackers/medias_tracker/add_medias",
+		`{"medias": []}`,
+		[]string{"medias:min"},
+		`medias should be at least 1 item`,
+	},
+	{
+		"/v1/trackers/medias_tracker/delete_media",
+		`{"name": "Media"`,
+		[]string{":json"},
+		`the request body isn't valid JSON, it ends before the JSON is complete`,
+	},
+}
+
+func TestValidationRoutes(t *testing.T) {
+	router := api.SetupRouter()
+
+	for _, test := range validationRouteTestTable {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, test.path, strings.NewReader(test.body))
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: expected status code: %d, actual status code: %d, body: %s", test.path, test.body, http.StatusBadRequest, w.Code, w.Body.String())
+			continue
+		}
+		var res struct {
+			Code    string                `json:"code"`
+			Message string                `json:"message"`
+			Details []trackers.FieldError `json:"details"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		var actualFieldErrors []string
+		for _, fieldError := range res.Details {
+			actualFieldErrors = append(actualFieldErrors, fieldError.Field+":"+fieldError.Rule)
+		}
+		if strings.Join(actualFieldErrors, ", ") != strings.Join(test.expectedFieldErrors, ", ") {
+			t.Errorf("%s %s: expected field errors: %v, actual field errors: %v", test.path, test.body, test.expectedFieldErrors, actualFieldErrors)
+			continue
+		}
+		if !strings.HasPrefix(res.Details[0].Message, test.expectedMessage) {
+			t.Errorf("%s %s: expected message starting with %q, actual message: %q", test.path, test.body, test.expectedMessage, res.Details[0].Message)
+		}
+		if res.Code != "validation_error" || !strings.Contains(res.Message, res.Details[0].Message) {
+			t.Errorf("%s %s: unexpected error: %s", test.path, test.body, w.Body.String())
+		}
+	}
+}
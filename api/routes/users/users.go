@@ -0,0 +1,104 @@
+package users
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/gin-gonic/gin"
+)
+
+// UsersRoutes registers the routes to manage the users, only admins with the write scope can use them
+func UsersRoutes(group *gin.RouterGroup) {
+	group.Use(auth.RequireScope(auth.ScopeWrite), auth.RequireAdmin())
+	{
+		group.GET("/get_all", GetUsers)
+		group.POST("/create", CreateUser)
+		group.POST("/delete", DeleteUser)
+	}
+}
+
+func GetUsers(c *gin.Context) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	users, err := auth.GetUsers(configs)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+type CreateUserRequest struct {
+	Name string    `json:"name" binding:"required"`
+	Role auth.Role `json:"role" binding:"required"`
+}
+
+func CreateUser(c *gin.Context) {
+	var requestData CreateUserRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		apierror.Respond(c, apierror.Validation(err.Error()))
+		return
+	}
+	if !requestData.Role.IsValid() {
+		apierror.Respond(c, apierror.Validation(`role should be "admin" or "user"`))
+		return
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	user, err := auth.CreateUser(configs, requestData.Name, requestData.Role)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: users.name") {
+			apierror.Respond(c, apierror.Conflict("there is already a user named "+requestData.Name))
+			return
+		}
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user created", "user": user})
+}
+
+type DeleteUserRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// DeleteUser deletes a user with all its entries and API keys.
+// The users can't delete themselves, the default user, or the last admin.
+func DeleteUser(c *gin.Context) {
+	var requestData DeleteUserRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		apierror.Respond(c, apierror.Validation(err.Error()))
+		return
+	}
+	if requestData.Name == auth.GetUser(c).Name {
+		apierror.Respond(c, apierror.Validation("users can't delete themselves"))
+		return
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	err = auth.DeleteUser(configs, requestData.Name)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user deleted"})
+}
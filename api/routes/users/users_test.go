@@ -0,0 +1,147 @@
+package users_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+func TestUsersRoutes(t *testing.T) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The default databases folder is the working directory, the package source folder
+	databaseConfigs := configs.Database
+	configs.Database.FolderPath = t.TempDir()
+	defer func() { configs.Database = databaseConfigs }()
+	authConfigs := configs.Auth
+	configs.Auth.Enabled = false
+	defer func() { configs.Auth = authConfigs }()
+
+	router := api.SetupRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	testTable := []struct {
+		method             string
+		path               string
+		body               string
+		expectedStatusCode int
+	}{
+		{http.MethodPost, "/v1/users/create", `{"name": "Test Routes User", "role": "user"}`, http.StatusOK},
+		{http.MethodPost, "/v1/users/create", `{"name": "Test Routes User", "role": "user"}`, http.StatusConflict},
+		{http.MethodPost, "/v1/users/create", `{"name": "Test Invalid Role User", "role": "owner"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/users/delete", `{"name": "default"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/users/delete", `{"name": "Test Routes User"}`, http.StatusOK},
+		{http.MethodPost, "/v1/users/delete", `{"name": "Test Routes User"}`, http.StatusNotFound},
+	}
+
+	for _, test := range testTable {
+		w := serve(test.method, test.path, test.body)
+		if test.expectedStatusCode != w.Code {
+			t.Errorf("%s %s: expected status code: %d, actual status code: %d, body: %s", test.path, test.body, test.expectedStatusCode, w.Code, w.Body.String())
+		}
+	}
+
+	w := serve(http.MethodGet, "/v1/users/get_all", "")
+	var res struct {
+		Users []*auth.User `json:"users"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, user := range res.Users {
+		if user.Name == "default" {
+			found = true
+			if !user.IsAdmin() {
+				t.Errorf("the default user should be an admin: %+v", user)
+			}
+		}
+	}
+	if !found {
+		t.Error("default user not listed")
+	}
+}
+
+func TestDeleteDefaultUser(t *testing.T) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The default databases folder is the working directory, the package source folder
+	databaseConfigs := configs.Database
+	configs.Database.FolderPath = t.TempDir()
+	defer func() { configs.Database = databaseConfigs }()
+	authConfigs := configs.Auth
+	configs.Auth.Enabled = true
+	defer func() { configs.Auth = authConfigs }()
+
+	admin, err := auth.CreateUser(configs, "Test Second Admin User", auth.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auth.DeleteUser(configs, admin.Name)
+	key, err := auth.CreateAPIKey(configs, admin.ID, "Test Second Admin Key", auth.ScopeWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := api.SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/v1/users/delete", strings.NewReader(`{"name": "default"}`))
+	req.Header.Set("Authorization", "Bearer "+key)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "default user") {
+		t.Errorf("expected the default user to not be deleted, got %d: %s", w.Code, w.Body.String())
+	}
+	if user, err := auth.GetUserByName(configs, "default"); err != nil || user == nil {
+		t.Errorf("expected the default user to exist, got %v, %v", user, err)
+	}
+}
+
+func TestUsersRoutesNeedAdmin(t *testing.T) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The default databases folder is the working directory, the package source folder
+	databaseConfigs := configs.Database
+	configs.Database.FolderPath = t.TempDir()
+	defer func() { configs.Database = databaseConfigs }()
+	authConfigs := configs.Auth
+	configs.Auth.Enabled = true
+	defer func() { configs.Auth = authConfigs }()
+
+	user, err := auth.CreateUser(configs, "Test Not Admin User", auth.RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auth.DeleteUser(configs, user.Name)
+	key, err := auth.CreateAPIKey(configs, user.ID, "Test Not Admin Key", auth.ScopeWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := api.SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/users/get_all", nil)
+	req.Header.Set("Authorization", "Bearer "+key)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status code: %d, actual status code: %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -0,0 +1,46 @@
+package webhooks
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/diogovalentte/dashboard/api/apierror"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/diogovalentte/dashboard/api/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhooksRoutes registers the routes of the webhooks, only admins can use them.
+// The webhooks are set in the configs file.
+func WebhooksRoutes(group *gin.RouterGroup) {
+	group.Use(auth.RequireAdmin())
+	{
+		group.GET("/get_deliveries", GetDeliveries)
+	}
+}
+
+// GetDeliveries returns the last deliveries to the webhooks, the newest first.
+// The limit query parameter is the number of deliveries, 50 by default.
+func GetDeliveries(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 1000 {
+		apierror.Respond(c, apierror.Validation(fmt.Sprintf("invalid limit %q, it should be a number from 1 to 1000", c.Query("limit"))))
+		return
+	}
+
+	configs, err := util.GetConfigs()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	deliveries, err := webhook.GetDeliveries(configs, limit)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
@@ -0,0 +1,69 @@
+package scraping
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// A geckoDriverOutput logs each line written by a GeckoDriver server, tagged with its port
+type geckoDriverOutput struct {
+	port int
+	// Written data without a line break yet
+	buffer []byte
+	mutex  sync.Mutex
+}
+
+func newGeckoDriverOutput(port int) *geckoDriverOutput {
+	return &geckoDriverOutput{port: port}
+}
+
+func (o *geckoDriverOutput) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.buffer = append(o.buffer, p...)
+	for {
+		i := bytes.IndexByte(o.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		o.logLine(string(o.buffer[:i]))
+		o.buffer = o.buffer[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// logLine logs a line with the level of the GeckoDriver and Firefox log lines,
+// like "1700000000000	geckodriver	INFO	Listening on 127.0.0.1:30000"
+func (o *geckoDriverOutput) logLine(line string) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	// The level is one of the first fields, the message can have any word
+	fields := strings.Fields(line)
+	if len(fields) > 4 {
+		fields = fields[:4]
+	}
+	level := slog.LevelInfo
+	for _, field := range fields {
+		switch field {
+		case "FATAL", "ERROR":
+			level = slog.LevelError
+		case "WARN":
+			level = slog.LevelWarn
+		case "DEBUG", "TRACE":
+			level = slog.LevelDebug
+		default:
+			continue
+		}
+		break
+	}
+
+	slog.Log(context.Background(), level, "geckodriver output", "geckodriver_port", o.port, "line", line)
+}
@@ -0,0 +1,47 @@
+package scraping
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestGeckoDriverOutput(t *testing.T) {
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+	var logs bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	output := newGeckoDriverOutput(30000)
+	// The lines can be split across writes
+	output.Write([]byte("1700000000000\tgeckodriver\tINFO\tListening on 127.0.0.1:30000\n1700000000001\tgeckodriver\tERR"))
+	output.Write([]byte("OR\tFirefox crashed\n\n1700000000002\tgeckodriver\tINFO\tmessage with ERROR and WARN words"))
+	if strings.Count(logs.String(), "\n") != 2 {
+		t.Fatalf("expected only the complete lines to be logged: %s", logs.String())
+	}
+	output.Write([]byte("\n"))
+
+	expected := []struct {
+		level string
+		line  string
+	}{
+		{"INFO", "1700000000000\tgeckodriver\tINFO\tListening on 127.0.0.1:30000"},
+		{"ERROR", "1700000000001\tgeckodriver\tERROR\tFirefox crashed"},
+		{"INFO", "1700000000002\tgeckodriver\tINFO\tmessage with ERROR and WARN words"},
+	}
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d log records, got %d: %s", len(expected), len(lines), logs.String())
+	}
+	for i, line := range lines {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record["level"] != expected[i].level || record["line"] != expected[i].line || record["geckodriver_port"] != float64(30000) {
+			t.Errorf("unexpected log record: %v", record)
+		}
+	}
+}
@@ -2,54 +2,76 @@ package scraping
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
-	"os"
-	"reflect"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/diogovalentte/dashboard/api/metrics"
 	"github.com/tebeka/selenium"
 	"github.com/tebeka/selenium/firefox"
 )
 
 var (
 	geckoDriverPoolStartPort = 30000
-	geckoDriverPool          GeckoDriverPool
+	geckoDriverPool          *GeckoDriverPool
 )
 
 func NewGeckoDriverPool(geckoDriverPath string, size int) (*GeckoDriverPool, error) {
-	if !reflect.DeepEqual(geckoDriverPool, GeckoDriverPool{}) {
-		return &geckoDriverPool, nil
+	if geckoDriverPool != nil {
+		return geckoDriverPool, nil
 	}
 
 	// Create pool
-	geckoDriverPool = GeckoDriverPool{
-		pool: make(map[int]*GeckoDriverServer, size),
-		size: size,
+	pool := &GeckoDriverPool{
+		pool:            make(map[int]*GeckoDriverServer, size),
+		geckoDriverPath: geckoDriverPath,
 	}
+	err := pool.addInstances(size)
+	if err != nil {
+		closeErrs := stopGeckoDrivers(pool.pool) // Stop open geckodriver instances
+		if closeErrs != nil {
+			return nil, closeErrs
+		}
+		return nil, err
+	}
+	geckoDriverPool = pool
+
+	return geckoDriverPool, nil
+}
+
+type GeckoDriverPool struct {
+	// A map of port to geckodriver server
+	pool            map[int]*GeckoDriverServer
+	ports           []int
+	size            int
+	geckoDriverPath string
+	// Locks the pool instances, the instances have their own lock
+	mutex sync.Mutex
+}
+
+// addInstances starts the GeckoDriver instances in the first available ports.
+// It fails after finding 20 ports in use by other processes.
+// It must be called with the pool locked.
+func (gdp *GeckoDriverPool) addInstances(n int) error {
 	nextPort := geckoDriverPoolStartPort
 	timeout := 20
 
-	for i := 0; i < size; {
+	for i := 0; i < n; {
 		if timeout == 0 {
-			closeErrs := stopGeckoDrivers(geckoDriverPool.pool) // Stop open geckodriver instances
-			if closeErrs != nil {
-				return nil, closeErrs
-			}
-			return nil, fmt.Errorf("unable to create geckodriver pool. All 20 tested ports are in use")
+			return fmt.Errorf("unable to create geckodriver pool. All 20 tested ports are in use")
+		}
+		if _, inPool := gdp.pool[nextPort]; inPool {
+			nextPort++
+			continue
 		}
 
 		// Check port
 		available, err := isPortAvailable(nextPort)
 		if err != nil {
-			closeErrs := stopGeckoDrivers(geckoDriverPool.pool)
-			if closeErrs != nil {
-				return nil, closeErrs
-			}
-			return nil, err
+			return err
 		}
 		if !available {
 			timeout--
@@ -58,32 +80,80 @@ func NewGeckoDriverPool(geckoDriverPath string, size int) (*GeckoDriverPool, err
 		}
 
 		// Add to pool
-		gds := NewGeckoDriverServer(geckoDriverPath, nextPort)
+		gds := NewGeckoDriverServer(gdp.geckoDriverPath, nextPort)
 		err = gds.start()
 		if err != nil {
-			closeErrs := stopGeckoDrivers(geckoDriverPool.pool)
-			if closeErrs != nil {
-				return nil, closeErrs
-			}
-			return nil, err
+			return err
 		}
 
-		geckoDriverPool.pool[nextPort] = gds
-		geckoDriverPool.ports = append(geckoDriverPool.ports, nextPort)
+		gdp.pool[nextPort] = gds
+		gdp.ports = append(gdp.ports, nextPort)
+		gdp.size = len(gdp.pool)
+		metrics.GeckoDriverPoolSize.Set(float64(gdp.size))
 		i++
+		nextPort++
 	}
 
-	return &geckoDriverPool, nil
+	return nil
 }
 
-type GeckoDriverPool struct {
-	// A map of port to geckodriver server
-	pool  map[int]*GeckoDriverServer
-	ports []int
-	size  int
+// removeInstance removes an instance from the pool without stopping it.
+// It must be called with the pool locked.
+func (gdp *GeckoDriverPool) removeInstance(port int) {
+	delete(gdp.pool, port)
+	for i, p := range gdp.ports {
+		if p == port {
+			gdp.ports = append(gdp.ports[:i], gdp.ports[i+1:]...)
+			break
+		}
+	}
+	gdp.size = len(gdp.pool)
+	metrics.GeckoDriverPoolSize.Set(float64(gdp.size))
+}
+
+// Resize starts or stops GeckoDriver instances until the pool has the size.
+// Idle instances are stopped first. Busy instances are removed from the pool, but only
+// stopped when released, so the scrapings using them are not interrupted.
+func (gdp *GeckoDriverPool) Resize(size int) error {
+	if size <= 0 {
+		return fmt.Errorf("the pool size should be greater than 0, got %d", size)
+	}
+
+	gdp.mutex.Lock()
+	defer gdp.mutex.Unlock()
+
+	if size > len(gdp.pool) {
+		return gdp.addInstances(size - len(gdp.pool))
+	}
+
+	// Remove the instances from the last ports, skipping the busy ones in the first pass
+	toStop := make(map[int]*GeckoDriverServer)
+	for _, skipBusy := range []bool{true, false} {
+		for i := len(gdp.ports) - 1; i >= 0 && len(gdp.pool) > size; i-- {
+			instance := gdp.pool[gdp.ports[i]]
+			instance.mutex.Lock()
+			busy := instance.busy
+			if busy && skipBusy {
+				instance.mutex.Unlock()
+				continue
+			}
+			instance.retired = true
+			instance.mutex.Unlock()
+
+			gdp.removeInstance(instance.Port)
+			if !busy {
+				toStop[instance.Port] = instance
+			}
+		}
+	}
+
+	return stopGeckoDrivers(toStop)
 }
 
 func (gdp *GeckoDriverPool) StopAll() error {
+	gdp.mutex.Lock()
+	defer gdp.mutex.Unlock()
+
 	err := stopGeckoDrivers(gdp.pool)
 	if err != nil {
 		return err
@@ -112,33 +182,95 @@ func stopGeckoDrivers(instances map[int]*GeckoDriverServer) error {
 }
 
 func (gdp *GeckoDriverPool) WaitGet() (*GeckoDriverServer, error) {
+	instance, err := gdp.get()
+	if instance != nil || err != nil {
+		return instance, err
+	}
+
 	// Wait for an available GeckoDriver instance
+	metrics.GeckoDriverPoolWaiters.Inc()
+	defer metrics.GeckoDriverPoolWaiters.Dec()
+	for {
+		time.Sleep(5 * time.Second)
+
+		instance, err := gdp.get()
+		if instance != nil || err != nil {
+			return instance, err
+		}
+	}
+}
+
+// get returns an available instance and marks it as busy, nil if all are busy
+func (gdp *GeckoDriverPool) get() (*GeckoDriverServer, error) {
+	gdp.mutex.Lock()
+	defer gdp.mutex.Unlock()
+
 	if len(gdp.pool) == 0 {
 		return nil, fmt.Errorf("empty pool")
 	}
-	for {
-		for _, instance := range gdp.pool {
-			if instance.busy {
-				continue
-			} else {
-				instance.busy = true
-				return instance, nil
-			}
+	for _, instance := range gdp.pool {
+		// Lock the instance so concurrent callers don't get the same one
+		instance.mutex.Lock()
+		if !instance.busy {
+			instance.busy = true
+			instance.mutex.Unlock()
+			metrics.GeckoDriverPoolInUse.Inc()
+			return instance, nil
 		}
+		instance.mutex.Unlock()
+	}
 
-		time.Sleep(5 * time.Second)
+	return nil, nil
+}
+
+// Size returns how many GeckoDriver instances the pool has
+func (gdp *GeckoDriverPool) Size() int {
+	gdp.mutex.Lock()
+	defer gdp.mutex.Unlock()
+
+	return gdp.size
+}
+
+// GetGeckoDriverPool returns the GeckoDriver pool, nil if it wasn't created
+func GetGeckoDriverPool() *GeckoDriverPool {
+	return geckoDriverPool
+}
+
+// GetGeckoDriverPoolSize returns the size of the GeckoDriver pool, 0 if it wasn't created
+func GetGeckoDriverPoolSize() int {
+	if geckoDriverPool == nil {
+		return 0
 	}
+
+	return geckoDriverPool.Size()
 }
 
+// List returns the addresses of the pool instances, ordered by port
 func (gdp *GeckoDriverPool) List() []string {
+	gdp.mutex.Lock()
+	defer gdp.mutex.Unlock()
+
 	var instancesAddr []string
-	for _, instance := range gdp.pool {
-		instancesAddr = append(instancesAddr, instance.addr)
+	for _, port := range gdp.ports {
+		instancesAddr = append(instancesAddr, gdp.pool[port].addr)
 	}
 
 	return instancesAddr
 }
 
+// Instances returns the pool instances, ordered by port
+func (gdp *GeckoDriverPool) Instances() []*GeckoDriverServer {
+	gdp.mutex.Lock()
+	defer gdp.mutex.Unlock()
+
+	instances := make([]*GeckoDriverServer, 0, len(gdp.ports))
+	for _, port := range gdp.ports {
+		instances = append(instances, gdp.pool[port])
+	}
+
+	return instances
+}
+
 func NewGeckoDriverServer(geckoDriverPath string, port int) *GeckoDriverServer {
 	localAddr := fmt.Sprintf("http://localhost:%d", port)
 
@@ -153,7 +285,9 @@ type GeckoDriverServer struct {
 	GeckoDriverPath string
 	Port            int
 	// Indicates whether the server is being used or not
-	busy       bool
+	busy bool
+	// Removed from the pool while busy, it's stopped when released
+	retired    bool
 	addr       string
 	service    *selenium.Service
 	mutex      sync.Mutex
@@ -165,16 +299,16 @@ func (gds *GeckoDriverServer) start() error {
 	defer gds.mutex.Unlock()
 
 	// Start a GeckoDriver WebDriver server instance (if one is not already running)
-	log.Println("Starting the GeckoDriver server")
+	slog.Info("starting the GeckoDriver server", "geckodriver_port", gds.Port)
 	opts := []selenium.ServiceOption{
-		selenium.Output(os.Stderr),
+		selenium.Output(newGeckoDriverOutput(gds.Port)),
 	}
 	service, err := selenium.NewGeckoDriverService(gds.GeckoDriverPath, gds.Port, opts...)
 	if err != nil {
 		gds.startError = err
 		return err
 	}
-	log.Println("GeckoDriver server started")
+	slog.Info("GeckoDriver server started", "geckodriver_port", gds.Port)
 
 	gds.service = service
 
@@ -199,10 +333,44 @@ func (gds *GeckoDriverServer) Wait() error {
 	return fmt.Errorf("server did not respond on port %d", gds.Port)
 }
 
+// Status returns an error if the GeckoDriver server doesn't answer its status endpoint with OK before the timeout
+func (gds *GeckoDriverServer) Status(timeout time.Duration) error {
+	client := http.Client{Timeout: timeout}
+	resp, err := client.Get(gds.addr + "/status")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the status endpoint responded with the status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// responds returns whether the GeckoDriver server answers its status endpoint
+func (gds *GeckoDriverServer) responds() bool {
+	return gds.Status(5*time.Second) == nil
+}
+
+// restart stops the GeckoDriver server, if it's running, and starts it again
+func (gds *GeckoDriverServer) restart() error {
+	slog.Warn("restarting the GeckoDriver server because it's not responding", "geckodriver_port", gds.Port)
+	if err := gds.Stop(); err != nil {
+		slog.Warn("couldn't stop the GeckoDriver server before restarting it", "geckodriver_port", gds.Port, "error", err)
+	}
+	if err := gds.start(); err != nil {
+		return err
+	}
+	metrics.GeckoDriverRestarts.Inc()
+
+	return gds.Wait()
+}
+
 func (gds *GeckoDriverServer) Stop() error {
 	gds.mutex.Lock()
 	defer gds.mutex.Unlock()
-	log.Println("Stopping the GeckoDriver server")
+	slog.Info("stopping the GeckoDriver server", "geckodriver_port", gds.Port)
 
 	if gds.service == nil {
 		return fmt.Errorf("GeckoDriver server is not running")
@@ -212,7 +380,7 @@ func (gds *GeckoDriverServer) Stop() error {
 		return err
 	}
 
-	fmt.Println("GeckoDriver server stopped")
+	slog.Info("GeckoDriver server stopped", "geckodriver_port", gds.Port)
 
 	gds.service = nil
 
@@ -221,9 +389,16 @@ func (gds *GeckoDriverServer) Stop() error {
 
 func (gds *GeckoDriverServer) Release() {
 	gds.mutex.Lock()
-	defer gds.mutex.Unlock()
-
 	gds.busy = false
+	retired := gds.retired
+	gds.mutex.Unlock()
+	metrics.GeckoDriverPoolInUse.Dec()
+
+	if retired {
+		if err := gds.Stop(); err != nil {
+			slog.Error("couldn't stop the retired GeckoDriver server", "geckodriver_port", gds.Port, "error", err)
+		}
+	}
 }
 
 func isPortAvailable(port int) (bool, error) {
@@ -241,6 +416,9 @@ func isPortAvailable(port int) (bool, error) {
 
 func GetWebDriver(firefoxPath string) (selenium.WebDriver, *GeckoDriverServer, error) {
 	// Get driver
+	if geckoDriverPool == nil {
+		return nil, nil, fmt.Errorf("the GeckoDriver pool was not created")
+	}
 	driver, err := geckoDriverPool.WaitGet()
 	if err != nil {
 		return nil, driver, err
@@ -256,7 +434,17 @@ func GetWebDriver(firefoxPath string) (selenium.WebDriver, *GeckoDriverServer, e
 	caps.AddFirefox(firefoxCaps)
 
 	wd, err := selenium.NewRemote(caps, fmt.Sprintf(driver.addr))
+	if err != nil && !driver.responds() {
+		// The GeckoDriver server crashed or hanged, restart it and try once more
+		if restartErr := driver.restart(); restartErr != nil {
+			err = fmt.Errorf("%s; couldn't restart the GeckoDriver server: %s", err, restartErr)
+		} else {
+			wd, err = selenium.NewRemote(caps, fmt.Sprintf(driver.addr))
+		}
+	}
 	if err != nil {
+		// The caller won't use the instance, so it must not stay busy
+		driver.Release()
 		return nil, driver, err
 	}
 
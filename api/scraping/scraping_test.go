@@ -6,7 +6,7 @@ import (
 )
 
 func TestGeckoDriverPoolLifeCycle(t *testing.T) {
-	configs, err := util.GetConfigsWithoutDefaults("../../configs")
+	configs, err := util.GetConfigs()
 	if err != nil {
 		t.Error(err)
 		return
@@ -63,3 +63,59 @@ func TestIsPortAvailable(t *testing.T) {
 		}
 	}
 }
+
+func TestGeckoDriverPoolResize(t *testing.T) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	pool := &GeckoDriverPool{pool: make(map[int]*GeckoDriverServer), geckoDriverPath: configs.GeckoDriver.BinaryPath}
+	defer pool.StopAll()
+
+	// Grow
+	if err = pool.Resize(3); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Size() != 3 || len(pool.List()) != 3 {
+		t.Fatalf("expected 3 instances, got %d: %v", pool.Size(), pool.List())
+	}
+
+	// Shrink with busy instances, the idle instance is removed first
+	busy1, err := pool.WaitGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy2, err := pool.WaitGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = pool.Resize(1); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Size() != 1 {
+		t.Fatalf("expected 1 instance, got %d", pool.Size())
+	}
+
+	// The busy instance removed from the pool keeps running until released
+	retired, kept := busy1, busy2
+	if _, inPool := pool.pool[busy1.Port]; inPool {
+		retired, kept = busy2, busy1
+	}
+	if retired.service == nil {
+		t.Error("the busy instance was stopped before being released")
+	}
+	retired.Release()
+	if retired.service != nil {
+		t.Error("the retired instance wasn't stopped when released")
+	}
+	kept.Release()
+	if kept.service == nil {
+		t.Error("the instance in the pool was stopped when released")
+	}
+
+	if err = pool.Resize(0); err == nil {
+		t.Error("expected an error resizing the pool to 0")
+	}
+}
@@ -0,0 +1,413 @@
+package util
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type Configs struct {
+	Database    DatabaseConfigs    `mapstructure:"database"`
+	GeckoDriver GeckoDriverConfigs `mapstructure:"geckodriver"`
+	Firefox     FirefoxConfigs     `mapstructure:"firefox"`
+	Steam       SteamConfigs       `mapstructure:"steam"`
+	Auth        AuthConfigs        `mapstructure:"auth"`
+	Log         LogConfigs         `mapstructure:"log"`
+	Health      HealthConfigs      `mapstructure:"health"`
+	Webhooks    WebhooksConfigs    `mapstructure:"webhooks"`
+	Reminders   RemindersConfigs   `mapstructure:"reminders"`
+}
+
+type DatabaseConfigs struct {
+	FolderPath string `mapstructure:"databases_folder_abs_path"`
+}
+
+type GeckoDriverConfigs struct {
+	BinaryPath string `mapstructure:"binary_path"`
+	PoolSize   int    `mapstructure:"pool_size"`
+}
+
+type FirefoxConfigs struct {
+	BinaryPath string `mapstructure:"binary_path"`
+}
+
+type SteamConfigs struct {
+	// Steam Web API key, used to get the owned games
+	APIKey string `mapstructure:"api_key"`
+	// Base URLs of the Steam Web API and store, empty to use the official ones
+	APIURL   string `mapstructure:"api_url"`
+	StoreURL string `mapstructure:"store_url"`
+	// Wait between the requests to the Steam store, which limits the requests of each IP address
+	StoreRequestIntervalMS int `mapstructure:"store_request_interval_ms"`
+	// Attempts of each request rate limited by Steam, including the first one
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// Wait before the first retry if Steam doesn't tell how long to wait, it doubles on each retry
+	InitialBackoffMS int `mapstructure:"initial_backoff_ms"`
+}
+
+type AuthConfigs struct {
+	// Whether the API requires an API key on the requests
+	Enabled bool `mapstructure:"enabled"`
+}
+
+type LogConfigs struct {
+	// Minimum level of the logs: debug, info, warn, or error
+	Level string `mapstructure:"level"`
+}
+
+type HealthConfigs struct {
+	// Minimum free disk space in the databases folder for the API to be ready, in megabytes
+	MinFreeDiskMB int `mapstructure:"min_free_disk_mb"`
+}
+
+type WebhooksConfigs struct {
+	// Attempts of each delivery before it fails, including the first one
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// Wait before the first retry, it doubles on each retry
+	InitialBackoffMS int `mapstructure:"initial_backoff_ms"`
+	// Timeout of each delivery request
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
+	// Days the deliveries are kept in the database, 0 keeps them forever
+	DeliveriesRetentionDays int                      `mapstructure:"deliveries_retention_days"`
+	Endpoints               []WebhookEndpointConfigs `mapstructure:"endpoints"`
+}
+
+// A WebhookEndpointConfigs is an URL that receives the events as POST requests
+type WebhookEndpointConfigs struct {
+	URL string `mapstructure:"url"`
+	// Key of the HMAC-SHA256 signature of the requests body, no signature if empty
+	Secret string `mapstructure:"secret"`
+	// Events sent to the URL, like "job.failed", all events if empty
+	Events []string `mapstructure:"events"`
+}
+
+// WebhookEvents are the events that can be sent to the webhooks
+var WebhookEvents = []string{"job.completed", "job.failed", "release.upcoming", "release.today"}
+
+type RemindersConfigs struct {
+	// Whether the to be released entries are checked daily to send reminders of their release dates
+	Enabled bool `mapstructure:"enabled"`
+	// Days before the release date to send reminders, a reminder is also sent on the release day
+	DaysBefore []int `mapstructure:"days_before"`
+	// Time of the daily check, in the API local time, like "09:00"
+	CheckTime string `mapstructure:"check_time"`
+	// Whether the entries are moved to not started by the check after their release date
+	MoveReleasedToNotStarted bool `mapstructure:"move_released_to_not_started"`
+	// Channels of the reminders
+	Webhooks bool        `mapstructure:"webhooks"`
+	SMTP     SMTPConfigs `mapstructure:"smtp"`
+	Ntfy     NtfyConfigs `mapstructure:"ntfy"`
+}
+
+// SMTPConfigs are the configs of the email notifications, they're not sent if the host is empty
+type SMTPConfigs struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+	// Credentials of the server, no authentication if the username is empty
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+// NtfyConfigs are the configs of the ntfy-style HTTP push notifications, they're not sent if the URL is empty
+type NtfyConfigs struct {
+	// URL of the topic, like https://ntfy.sh/releases
+	URL string `mapstructure:"url"`
+	// Access token of the topic, optional
+	Token string `mapstructure:"token"`
+}
+
+type GamesTrackerConfigs struct {
+	DBID string `mapstructure:"db_id"`
+}
+
+type MediasTrackerConfigs struct {
+	DBID string `mapstructure:"db_id"`
+}
+
+// Prefix of the environment variables that override the configs, like DASHBOARD_GECKODRIVER_POOL_SIZE
+const ConfigsEnvPrefix = "DASHBOARD"
+
+// Environment variable with the path of the configs file, the -config flag takes precedence over it
+const ConfigFileEnv = ConfigsEnvPrefix + "_CONFIG_FILE"
+
+// Path of the configs file looked for in the working directory and its parents if no path is set
+const defaultConfigFile = "configs/configs.json"
+
+// defaultConfigs are the values of the configs not set in the file or environment.
+// All configs need a default, even if empty, so they can be overridden by environment variables.
+var defaultConfigs = map[string]interface{}{
+	"database.databases_folder_abs_path":     "",
+	"geckodriver.binary_path":                "/opt/geckodriver/geckodriver",
+	"geckodriver.pool_size":                  3,
+	"firefox.binary_path":                    "/usr/bin/firefox",
+	"steam.api_key":                          "",
+	"steam.api_url":                          "",
+	"steam.store_url":                        "",
+	"steam.store_request_interval_ms":        1500,
+	"steam.max_attempts":                     5,
+	"steam.initial_backoff_ms":               10000,
+	"auth.enabled":                           true,
+	"log.level":                              "info",
+	"health.min_free_disk_mb":                100,
+	"webhooks.max_attempts":                  5,
+	"webhooks.initial_backoff_ms":            1000,
+	"webhooks.timeout_seconds":               10,
+	"webhooks.deliveries_retention_days":     30,
+	"webhooks.endpoints":                     []interface{}{},
+	"reminders.enabled":                      false,
+	"reminders.days_before":                  []int{7, 1},
+	"reminders.check_time":                   "09:00",
+	"reminders.move_released_to_not_started": false,
+	"reminders.webhooks":                     false,
+	"reminders.smtp.host":                    "",
+	"reminders.smtp.port":                    25,
+	"reminders.smtp.username":                "",
+	"reminders.smtp.password":                "",
+	"reminders.smtp.from":                    "",
+	"reminders.smtp.to":                      []string{},
+	"reminders.ntfy.url":                     "",
+	"reminders.ntfy.token":                   "",
+}
+
+var (
+	configs *Configs
+	// Path set by SetConfigFile
+	configFile string
+	// Path of the configs file loaded, empty if there is none
+	loadedConfigFile string
+	reloadStatus     ConfigsStatus
+	reloadListeners  []func(oldConfigs, newConfigs *Configs)
+	configsMutex     sync.Mutex
+)
+
+// SetConfigFile sets the path of the configs file loaded by GetConfigs.
+// It must be called before the first GetConfigs call, like with the -config flag value.
+func SetConfigFile(path string) {
+	configsMutex.Lock()
+	defer configsMutex.Unlock()
+
+	configFile = path
+}
+
+// GetConfigs returns the configs, loading them on the first call.
+// The configs are replaced instead of changed when reloaded, so they should be
+// gotten again instead of kept by long-running code.
+func GetConfigs() (*Configs, error) {
+	configsMutex.Lock()
+	defer configsMutex.Unlock()
+
+	if configs != nil {
+		return configs, nil
+	}
+
+	path := configFile
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
+	}
+	if path == "" {
+		path = findConfigFile()
+	}
+	loadedConfigs, err := LoadConfigs(path)
+	if err != nil {
+		return nil, err
+	}
+	configs = loadedConfigs
+	loadedConfigFile = path
+	reloadStatus = ConfigsStatus{File: path, LoadedAt: time.Now().Format("2006-01-02 15:04:05")}
+
+	return configs, nil
+}
+
+// LoadConfigs loads the configs layering the defaults, the configs file, and the DASHBOARD_* environment variables.
+// If path is empty, only the defaults and environment variables are used.
+func LoadConfigs(path string) (*Configs, error) {
+	v := viper.New()
+	for key, value := range defaultConfigs {
+		v.SetDefault(key, value)
+	}
+
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("configs file %s: %w", path, err)
+		}
+		v.SetConfigFile(path)
+		v.SetConfigType("json")
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("couldn't read the configs file %s: %w", path, err)
+		}
+	}
+
+	v.SetEnvPrefix(ConfigsEnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	var loadedConfigs Configs
+	if err := v.Unmarshal(&loadedConfigs); err != nil {
+		return nil, fmt.Errorf("invalid configs: %w", err)
+	}
+
+	return &loadedConfigs, nil
+}
+
+// findConfigFile returns the path of the default configs file in the working directory or its parents,
+// empty if there is none
+func findConfigFile() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, defaultConfigFile)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Validate returns an error listing all the invalid configs, like binaries that don't exist
+func (c *Configs) Validate() error {
+	var problems []string
+
+	if c.Database.FolderPath == "" {
+		problems = append(problems, "database.databases_folder_abs_path is required")
+	} else if err := checkWritableFolder(c.Database.FolderPath); err != nil {
+		problems = append(problems, fmt.Sprintf("database.databases_folder_abs_path: %s", err))
+	}
+	if err := CheckExecutable(c.GeckoDriver.BinaryPath); err != nil {
+		problems = append(problems, fmt.Sprintf("geckodriver.binary_path: %s", err))
+	}
+	if c.GeckoDriver.PoolSize <= 0 {
+		problems = append(problems, fmt.Sprintf("geckodriver.pool_size should be greater than 0, got %d", c.GeckoDriver.PoolSize))
+	}
+	if err := CheckExecutable(c.Firefox.BinaryPath); err != nil {
+		problems = append(problems, fmt.Sprintf("firefox.binary_path: %s", err))
+	}
+	if c.Health.MinFreeDiskMB < 0 {
+		problems = append(problems, fmt.Sprintf("health.min_free_disk_mb should be 0 or greater, got %d", c.Health.MinFreeDiskMB))
+	}
+	if c.Steam.StoreRequestIntervalMS < 0 {
+		problems = append(problems, fmt.Sprintf("steam.store_request_interval_ms should be 0 or greater, got %d", c.Steam.StoreRequestIntervalMS))
+	}
+	if c.Steam.MaxAttempts <= 0 {
+		problems = append(problems, fmt.Sprintf("steam.max_attempts should be greater than 0, got %d", c.Steam.MaxAttempts))
+	}
+	if c.Steam.InitialBackoffMS < 0 {
+		problems = append(problems, fmt.Sprintf("steam.initial_backoff_ms should be 0 or greater, got %d", c.Steam.InitialBackoffMS))
+	}
+	if c.Webhooks.MaxAttempts <= 0 {
+		problems = append(problems, fmt.Sprintf("webhooks.max_attempts should be greater than 0, got %d", c.Webhooks.MaxAttempts))
+	}
+	if c.Webhooks.InitialBackoffMS < 0 {
+		problems = append(problems, fmt.Sprintf("webhooks.initial_backoff_ms should be 0 or greater, got %d", c.Webhooks.InitialBackoffMS))
+	}
+	if c.Webhooks.TimeoutSeconds <= 0 {
+		problems = append(problems, fmt.Sprintf("webhooks.timeout_seconds should be greater than 0, got %d", c.Webhooks.TimeoutSeconds))
+	}
+	if c.Webhooks.DeliveriesRetentionDays < 0 {
+		problems = append(problems, fmt.Sprintf("webhooks.deliveries_retention_days should be 0 or greater, got %d", c.Webhooks.DeliveriesRetentionDays))
+	}
+	for i, endpoint := range c.Webhooks.Endpoints {
+		if !isHTTPURL(endpoint.URL) {
+			problems = append(problems, fmt.Sprintf("webhooks.endpoints[%d].url should be an HTTP URL, got %q", i, endpoint.URL))
+		}
+		for _, event := range endpoint.Events {
+			if !slices.Contains(WebhookEvents, event) {
+				problems = append(problems, fmt.Sprintf("webhooks.endpoints[%d].events should have only %s, got %q", i, strings.Join(WebhookEvents, ", "), event))
+			}
+		}
+	}
+	if c.Reminders.Enabled {
+		for _, days := range c.Reminders.DaysBefore {
+			if days <= 0 {
+				problems = append(problems, fmt.Sprintf("reminders.days_before should have only numbers greater than 0, got %d", days))
+			}
+		}
+		if _, err := time.Parse("15:04", c.Reminders.CheckTime); err != nil {
+			problems = append(problems, fmt.Sprintf("reminders.check_time should be a time like 09:00, got %q", c.Reminders.CheckTime))
+		}
+	}
+	if c.Reminders.SMTP.Host != "" {
+		if c.Reminders.SMTP.Port <= 0 {
+			problems = append(problems, fmt.Sprintf("reminders.smtp.port should be greater than 0, got %d", c.Reminders.SMTP.Port))
+		}
+		if c.Reminders.SMTP.From == "" || len(c.Reminders.SMTP.To) == 0 {
+			problems = append(problems, "reminders.smtp.from and reminders.smtp.to are required to send emails")
+		}
+	}
+	if c.Reminders.Ntfy.URL != "" && !isHTTPURL(c.Reminders.Ntfy.URL) {
+		problems = append(problems, fmt.Sprintf("reminders.ntfy.url should be an HTTP URL, got %q", c.Reminders.Ntfy.URL))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		problems = append(problems, fmt.Sprintf("log.level should be debug, info, warn, or error, got %q", c.Log.Level))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configs:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+
+	return nil
+}
+
+// isHTTPURL returns whether the value is an absolute HTTP or HTTPS URL
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// CheckExecutable returns an error if the path is not an executable file
+func CheckExecutable(path string) error {
+	if path == "" {
+		return fmt.Errorf("is required")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("binary %s doesn't exist", path)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a folder, not a binary", path)
+	}
+	if info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("binary %s is not executable", path)
+	}
+
+	return nil
+}
+
+// checkWritableFolder checks whether a file can be created in the folder
+func checkWritableFolder(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("%s should be an absolute path", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("folder %s doesn't exist", path)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a folder", path)
+	}
+	file, err := os.CreateTemp(path, ".write_check_*")
+	if err != nil {
+		return fmt.Errorf("folder %s is not writable: %s", path, err)
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package util
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// restartOnlyConfigs can't be changed while the API is running.
+// Changing them in the configs file only has effect after a restart.
+var restartOnlyConfigs = map[string]bool{
+	"database.databases_folder_abs_path": true,
+	"geckodriver.binary_path":            true,
+}
+
+// A ConfigChange is a config with a different value in the configs file than in the running API
+type ConfigChange struct {
+	Key      string `json:"key"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// ConfigsStatus is the state of the configs reloads
+type ConfigsStatus struct {
+	// Path of the configs file, empty if there is none
+	File     string `json:"file"`
+	LoadedAt string `json:"loaded_at"`
+	// Last time the configs were reloaded, successfully or not
+	ReloadedAt      string `json:"reloaded_at"`
+	LastReloadError string `json:"last_reload_error"`
+	// Changes in the configs file that need a restart to have effect
+	PendingChanges []ConfigChange `json:"pending_changes"`
+}
+
+// GetConfigsStatus returns the state of the configs reloads
+func GetConfigsStatus() ConfigsStatus {
+	configsMutex.Lock()
+	defer configsMutex.Unlock()
+
+	status := reloadStatus
+	status.PendingChanges = append([]ConfigChange{}, reloadStatus.PendingChanges...)
+
+	return status
+}
+
+// OnConfigsReload registers a function called with the old and new configs after they are reloaded
+// with changes, like to resize the GeckoDriver pool
+func OnConfigsReload(listener func(oldConfigs, newConfigs *Configs)) {
+	configsMutex.Lock()
+	defer configsMutex.Unlock()
+
+	reloadListeners = append(reloadListeners, listener)
+}
+
+// ReloadConfigs loads the configs again from the same file and replaces the running configs with them.
+// The restart-only configs keep their running values, and their changes are kept as pending.
+// If the new configs are invalid, the running configs are kept and an error is returned.
+// It returns the changes applied.
+func ReloadConfigs() ([]ConfigChange, error) {
+	configsMutex.Lock()
+
+	if configs == nil {
+		configsMutex.Unlock()
+		return nil, fmt.Errorf("the configs were not loaded yet")
+	}
+	reloadStatus.ReloadedAt = time.Now().Format("2006-01-02 15:04:05")
+
+	newConfigs, err := LoadConfigs(loadedConfigFile)
+	if err == nil {
+		err = newConfigs.Validate()
+	}
+	if err != nil {
+		reloadStatus.LastReloadError = err.Error()
+		configsMutex.Unlock()
+		return nil, err
+	}
+	reloadStatus.LastReloadError = ""
+
+	var appliedChanges, pendingChanges []ConfigChange
+	for _, change := range diffConfigs(configs, newConfigs) {
+		if restartOnlyConfigs[change.Key] {
+			pendingChanges = append(pendingChanges, change)
+		} else {
+			appliedChanges = append(appliedChanges, change)
+		}
+	}
+	reloadStatus.PendingChanges = pendingChanges
+	if len(appliedChanges) == 0 {
+		configsMutex.Unlock()
+		return nil, nil
+	}
+
+	newConfigs.Database.FolderPath = configs.Database.FolderPath
+	newConfigs.GeckoDriver.BinaryPath = configs.GeckoDriver.BinaryPath
+	oldConfigs := configs
+	configs = newConfigs
+	listeners := append([]func(oldConfigs, newConfigs *Configs){}, reloadListeners...)
+	configsMutex.Unlock()
+
+	// The listeners can get the configs, so they are called without the lock
+	for _, listener := range listeners {
+		listener(oldConfigs, newConfigs)
+	}
+
+	return appliedChanges, nil
+}
+
+// diffConfigs returns the configs with different values, ordered by key
+func diffConfigs(oldConfigs, newConfigs *Configs) []ConfigChange {
+	oldValues := map[string]interface{}{}
+	flattenConfigs("", reflect.ValueOf(*oldConfigs), oldValues)
+	newValues := map[string]interface{}{}
+	flattenConfigs("", reflect.ValueOf(*newConfigs), newValues)
+
+	var changes []ConfigChange
+	for key, newValue := range newValues {
+		if oldValue, ok := oldValues[key]; !ok || oldValue != newValue {
+			changes = append(changes, ConfigChange{key, formatConfigValue(oldValue, ok), fmt.Sprint(newValue)})
+		}
+	}
+	// Configs of list items that were removed
+	for key, oldValue := range oldValues {
+		if _, ok := newValues[key]; !ok {
+			changes = append(changes, ConfigChange{key, fmt.Sprint(oldValue), ""})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+// formatConfigValue returns the value of a config in a change, empty if the config didn't exist
+func formatConfigValue(value interface{}, exists bool) string {
+	if !exists {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+// flattenConfigs adds the configs values to the map by their keys, like "geckodriver.pool_size".
+// The configs of list items have the item index, like "webhooks.endpoints[0].url", and lists of
+// other values are formatted as strings, so all values can be compared.
+func flattenConfigs(prefix string, value reflect.Value, values map[string]interface{}) {
+	for i := 0; i < value.NumField(); i++ {
+		key := prefix + value.Type().Field(i).Tag.Get("mapstructure")
+		field := value.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct:
+			flattenConfigs(key+".", field, values)
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct:
+			for j := 0; j < field.Len(); j++ {
+				flattenConfigs(fmt.Sprintf("%s[%d].", key, j), field.Index(j), values)
+			}
+		case field.Kind() == reflect.Slice:
+			values[key] = fmt.Sprint(field.Interface())
+		default:
+			values[key] = field.Interface()
+		}
+	}
+}
+
+// WatchConfigs reloads the configs when the configs file changes or the process receives SIGHUP.
+// The reloads and their errors are logged.
+func WatchConfigs() error {
+	configsMutex.Lock()
+	path := loadedConfigFile
+	configsMutex.Unlock()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		// The folder is watched because editors usually replace the file instead of writing to it
+		path, err = filepath.Abs(path)
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+		if err = watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		defer watcher.Close()
+
+		// Editors can write a file many times when saving it, so the reload waits for the writes to stop
+		var debounce <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Name == path && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					debounce = time.After(500 * time.Millisecond)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("error watching the configs file", "error", err)
+			case <-hangup:
+				reloadConfigsAndLog("SIGHUP")
+			case <-debounce:
+				debounce = nil
+				reloadConfigsAndLog("configs file change")
+			}
+		}
+	}()
+
+	return nil
+}
+
+func reloadConfigsAndLog(reason string) {
+	changes, err := ReloadConfigs()
+	if err != nil {
+		slog.Error("couldn't reload the configs, keeping the running configs", "reason", reason, "error", err)
+		return
+	}
+
+	var keys []string
+	for _, change := range changes {
+		keys = append(keys, change.Key)
+	}
+	slog.Info("configs reloaded", "reason", reason, "changed", keys)
+	for _, change := range GetConfigsStatus().PendingChanges {
+		slog.Warn("the config change needs a restart to have effect", "key", change.Key)
+	}
+}
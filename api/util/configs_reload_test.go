@@ -0,0 +1,147 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// setTestConfigsFile writes a valid configs file in a temporary folder and makes GetConfigs load it.
+// It returns the folder, which is also the databases folder and has the binaries.
+func setTestConfigsFile(t *testing.T, poolSize int) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "binary"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestConfigsFile(t, dir, dir, poolSize)
+
+	configsMutex.Lock()
+	configs, configFile, reloadListeners = nil, filepath.Join(dir, "configs.json"), nil
+	configsMutex.Unlock()
+	t.Cleanup(func() {
+		configsMutex.Lock()
+		configs, configFile, reloadListeners = nil, "", nil
+		configsMutex.Unlock()
+	})
+
+	return dir
+}
+
+func writeTestConfigsFile(t *testing.T, dir, dbFolder string, poolSize int) {
+	binary := filepath.Join(dir, "binary")
+	content := fmt.Sprintf(
+		`{"database": {"databases_folder_abs_path": %q}, "geckodriver": {"binary_path": %q, "pool_size": %d}, "firefox": {"binary_path": %q}}`,
+		dbFolder, binary, poolSize, binary,
+	)
+	if err := os.WriteFile(filepath.Join(dir, "configs.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadConfigs(t *testing.T) {
+	dir := setTestConfigsFile(t, 2)
+	oldConfigs, err := GetConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var listenerOldSize, listenerNewSize int
+	OnConfigsReload(func(oldConfigs, newConfigs *Configs) {
+		listenerOldSize, listenerNewSize = oldConfigs.GeckoDriver.PoolSize, newConfigs.GeckoDriver.PoolSize
+	})
+
+	// A live change and a restart-only change
+	otherFolder := t.TempDir()
+	writeTestConfigsFile(t, dir, otherFolder, 4)
+	changes, err := ReloadConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0] != (ConfigChange{"geckodriver.pool_size", "2", "4"}) {
+		t.Errorf("unexpected applied changes: %+v", changes)
+	}
+	newConfigs, _ := GetConfigs()
+	if newConfigs.GeckoDriver.PoolSize != 4 {
+		t.Errorf("expected the new pool size, got %d", newConfigs.GeckoDriver.PoolSize)
+	}
+	if newConfigs.Database.FolderPath != dir {
+		t.Errorf("expected the running databases folder to be kept, got %s", newConfigs.Database.FolderPath)
+	}
+	if oldConfigs.GeckoDriver.PoolSize != 2 {
+		t.Error("the old configs were changed instead of replaced")
+	}
+	if listenerOldSize != 2 || listenerNewSize != 4 {
+		t.Errorf("expected the listener to be called with the old and new pool sizes, got %d and %d", listenerOldSize, listenerNewSize)
+	}
+	status := GetConfigsStatus()
+	if len(status.PendingChanges) != 1 || status.PendingChanges[0].Key != "database.databases_folder_abs_path" || status.PendingChanges[0].NewValue != otherFolder {
+		t.Errorf("unexpected pending changes: %+v", status.PendingChanges)
+	}
+
+	// Invalid configs are not applied
+	writeTestConfigsFile(t, dir, dir, 0)
+	if _, err = ReloadConfigs(); err == nil {
+		t.Error("expected an error reloading invalid configs")
+	}
+	if configs, _ := GetConfigs(); configs != newConfigs {
+		t.Error("the running configs were replaced by invalid configs")
+	}
+	if GetConfigsStatus().LastReloadError == "" {
+		t.Error("expected the reload error in the status")
+	}
+}
+
+func TestWatchConfigs(t *testing.T) {
+	dir := setTestConfigsFile(t, 1)
+	if _, err := GetConfigs(); err != nil {
+		t.Fatal(err)
+	}
+	if err := WatchConfigs(); err != nil {
+		t.Fatal(err)
+	}
+
+	waitPoolSize := func(expected int) {
+		for i := 0; i < 50; i++ {
+			if configs, _ := GetConfigs(); configs.GeckoDriver.PoolSize == expected {
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		configs, _ := GetConfigs()
+		t.Fatalf("expected the pool size %d to be reloaded, got %d", expected, configs.GeckoDriver.PoolSize)
+	}
+
+	writeTestConfigsFile(t, dir, dir, 2)
+	waitPoolSize(2)
+
+	// Without a file change, like after changing an environment variable
+	t.Setenv("DASHBOARD_GECKODRIVER_POOL_SIZE", "3")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	waitPoolSize(3)
+}
+
+func TestDiffConfigsLists(t *testing.T) {
+	oldConfigs := &Configs{Webhooks: WebhooksConfigs{Endpoints: []WebhookEndpointConfigs{
+		{URL: "http://localhost:8081/hook", Events: []string{"job.failed"}},
+		{URL: "http://localhost:8082/hook"},
+	}}}
+	newConfigs := &Configs{Webhooks: WebhooksConfigs{Endpoints: []WebhookEndpointConfigs{
+		{URL: "http://localhost:8081/hook", Events: []string{"job.failed", "job.completed"}},
+	}}}
+
+	expected := []ConfigChange{
+		{"webhooks.endpoints[0].events", "[job.failed]", "[job.failed job.completed]"},
+		{"webhooks.endpoints[1].events", "[]", ""},
+		{"webhooks.endpoints[1].secret", "", ""},
+		{"webhooks.endpoints[1].url", "http://localhost:8082/hook", ""},
+	}
+	if changes := diffConfigs(oldConfigs, newConfigs); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes: %+v, actual changes: %+v", expected, changes)
+	}
+}
@@ -0,0 +1,92 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "configs.json")
+	err := os.WriteFile(path, []byte(`{"database": {"databases_folder_abs_path": "/from/file"}, "geckodriver": {"pool_size": 5}, "auth": {"enabled": false}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DASHBOARD_GECKODRIVER_POOL_SIZE", "7")
+	t.Setenv("DASHBOARD_STEAM_API_KEY", "from-env")
+
+	configs, err := LoadConfigs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Defaults < file < environment
+	if configs.Firefox.BinaryPath != "/usr/bin/firefox" {
+		t.Errorf("expected the default Firefox binary path, got %q", configs.Firefox.BinaryPath)
+	}
+	if configs.Database.FolderPath != "/from/file" {
+		t.Errorf("expected the database folder of the file, got %q", configs.Database.FolderPath)
+	}
+	if configs.Auth.Enabled {
+		t.Error("expected the auth disabled by the file")
+	}
+	if configs.GeckoDriver.PoolSize != 7 {
+		t.Errorf("expected the pool size of the environment, got %d", configs.GeckoDriver.PoolSize)
+	}
+	if configs.Steam.APIKey != "from-env" {
+		t.Errorf("expected the Steam API key of the environment, got %q", configs.Steam.APIKey)
+	}
+
+	if _, err = LoadConfigs(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected an error with a configs file that doesn't exist")
+	}
+}
+
+func TestValidateConfigs(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "geckodriver")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	configs := Configs{
+		Database:    DatabaseConfigs{FolderPath: dir},
+		GeckoDriver: GeckoDriverConfigs{BinaryPath: binary, PoolSize: 1},
+		Firefox:     FirefoxConfigs{BinaryPath: binary},
+		Log:         LogConfigs{Level: "info"},
+		Steam:       SteamConfigs{MaxAttempts: 1},
+		Webhooks: WebhooksConfigs{
+			MaxAttempts:    1,
+			TimeoutSeconds: 1,
+			Endpoints:      []WebhookEndpointConfigs{{URL: "http://localhost:8081/hook", Events: []string{"job.failed"}}},
+		},
+	}
+	if err := configs.Validate(); err != nil {
+		t.Errorf("expected valid configs, got: %s", err)
+	}
+
+	configs.Database.FolderPath = filepath.Join(dir, "missing")
+	configs.GeckoDriver.PoolSize = 0
+	configs.Firefox.BinaryPath = dir
+	configs.Log.Level = "verbose"
+	configs.Steam.StoreRequestIntervalMS = -1
+	configs.Webhooks.DeliveriesRetentionDays = -1
+	configs.Reminders = RemindersConfigs{Enabled: true, DaysBefore: []int{7, 0}, CheckTime: "9am", SMTP: SMTPConfigs{Host: "localhost", Port: 25}}
+	configs.Webhooks.Endpoints = append(configs.Webhooks.Endpoints, WebhookEndpointConfigs{URL: "localhost/hook", Events: []string{"job.started"}})
+	err := configs.Validate()
+	if err == nil {
+		t.Fatal("expected invalid configs")
+	}
+	for _, expected := range []string{"databases_folder_abs_path", "pool_size", "firefox.binary_path", "log.level", "steam.store_request_interval_ms", "webhooks.deliveries_retention_days", "webhooks.endpoints[1].url", "webhooks.endpoints[1].events", "reminders.days_before", "reminders.check_time", "reminders.smtp.from"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %s in the error: %s", expected, err)
+		}
+	}
+	for _, unexpected := range []string{"geckodriver.binary_path", "webhooks.endpoints[0]"} {
+		if strings.Contains(err.Error(), unexpected) {
+			t.Errorf("unexpected %s in the error: %s", unexpected, err)
+		}
+	}
+}
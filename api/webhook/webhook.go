@@ -0,0 +1,290 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/google/uuid"
+)
+
+// Events sent to the webhooks, the util.WebhookEvents have all of them
+const (
+	EventJobCompleted = "job.completed"
+	EventJobFailed    = "job.failed"
+	// Release reminders of the to be released entries, before and on the release day
+	EventReleaseUpcoming = "release.upcoming"
+	EventReleaseToday    = "release.today"
+)
+
+// Headers of the webhooks requests
+const (
+	EventHeader    = "X-Dashboard-Event"
+	DeliveryHeader = "X-Dashboard-Delivery"
+	// HMAC-SHA256 signature of the request body with the webhook secret, like "sha256=<hex digest>"
+	SignatureHeader = "X-Dashboard-Signature-256"
+)
+
+// States of the deliveries
+const (
+	StatePending   = "pending"
+	StateDelivered = "delivered"
+	StateFailed    = "failed"
+)
+
+// Payload is the body of the webhooks requests
+type Payload struct {
+	DeliveryID string `json:"delivery_id"`
+	Event      string `json:"event"`
+	// When the event happened, with format "2006-01-02 15:04:05"
+	CreatedAt string `json:"created_at"`
+	// Data of the event, like the job of the job events
+	Data any `json:"data"`
+}
+
+// A Delivery is an event sent to a webhook URL, with the result of its last attempt
+type Delivery struct {
+	ID      string `json:"id"`
+	URL     string `json:"url"`
+	Event   string `json:"event"`
+	Payload string `json:"payload"`
+	// pending while it's being attempted, then delivered or failed
+	State    string `json:"state"`
+	Attempts int    `json:"attempts"`
+	// Status code of the last attempt response, 0 if there was no response
+	LastStatusCode int    `json:"last_status_code"`
+	LastError      string `json:"last_error"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
+
+// Deliveries being sent, including their retries
+var deliveries sync.WaitGroup
+
+// Send sends the event to the webhooks that receive it in the background.
+// The deliveries are retried with backoff when they fail, and logged in the database.
+func Send(event string, data any) {
+	configs, err := util.GetConfigs()
+	if err != nil {
+		slog.Error("couldn't send the event to the webhooks", "event", event, "error", err)
+		return
+	}
+
+	send(configs, event, data)
+}
+
+func send(configs *util.Configs, event string, data any) {
+	createdAt := time.Now().Format("2006-01-02 15:04:05")
+	for _, endpoint := range configs.Webhooks.Endpoints {
+		if len(endpoint.Events) > 0 && !slices.Contains(endpoint.Events, event) {
+			continue
+		}
+
+		payload := Payload{DeliveryID: uuid.New().String(), Event: event, CreatedAt: createdAt, Data: data}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			slog.Error("couldn't create the webhook payload", "event", event, "url", endpoint.URL, "error", err)
+			continue
+		}
+
+		deliveries.Add(1)
+		go func(endpoint util.WebhookEndpointConfigs) {
+			defer deliveries.Done()
+			deliver(configs, endpoint, payload, body)
+		}(endpoint)
+	}
+}
+
+// Wait waits for the deliveries being sent to be delivered or fail
+func Wait() {
+	deliveries.Wait()
+}
+
+// deliver sends the payload to the webhook until it's delivered or all attempts fail,
+// waiting twice as long before each retry
+func deliver(configs *util.Configs, endpoint util.WebhookEndpointConfigs, payload Payload, body []byte) {
+	logger := slog.Default().With("delivery_id", payload.DeliveryID, "event", payload.Event, "url", endpoint.URL)
+	if err := insertDelivery(configs, endpoint.URL, payload, body); err != nil {
+		logger.Error("couldn't log the webhook delivery", "error", err)
+	}
+
+	client := &http.Client{Timeout: time.Duration(configs.Webhooks.TimeoutSeconds) * time.Second}
+	backoff := time.Duration(configs.Webhooks.InitialBackoffMS) * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		statusCode, err := post(client, endpoint, payload, body)
+
+		state, lastError := StateDelivered, ""
+		if err != nil {
+			state, lastError = StatePending, err.Error()
+			if attempt >= configs.Webhooks.MaxAttempts {
+				state = StateFailed
+			}
+		}
+		if logErr := updateDelivery(configs, payload.DeliveryID, state, attempt, statusCode, lastError); logErr != nil {
+			logger.Error("couldn't log the webhook delivery", "error", logErr)
+		}
+
+		switch state {
+		case StateDelivered:
+			logger.Info("webhook delivered", "attempts", attempt)
+			return
+		case StateFailed:
+			logger.Error("webhook delivery failed", "attempts", attempt, "error", err)
+			return
+		}
+		logger.Warn("webhook delivery attempt failed, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends the payload to the webhook URL and returns the response status code.
+// Responses that are not 2xx are errors.
+func post(client *http.Client, endpoint util.WebhookEndpointConfigs, payload Payload, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dashboard-webhooks")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, payload.DeliveryID)
+	if endpoint.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(endpoint.Secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("the webhook responded with the status code %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature of the body with the secret, like the SignatureHeader value.
+// The receivers can compare it with the header to check the request was sent by the API.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func insertDelivery(configs *util.Configs, url string, payload Payload, body []byte) error {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(
+		"INSERT INTO webhook_deliveries (id, url, event, payload, state, attempts, last_error, created_at, updated_at) VALUES (?, ?, ?, ?, ?, 0, '', ?, ?);",
+		payload.DeliveryID, url, payload.Event, string(body), StatePending, payload.CreatedAt, payload.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return deleteOldDeliveries(db, configs.Webhooks.DeliveriesRetentionDays)
+}
+
+// deleteOldDeliveries deletes the deliveries created more than the retention days ago, none if the retention days are 0
+func deleteOldDeliveries(db *sql.DB, retentionDays int) error {
+	if retentionDays <= 0 {
+		return nil
+	}
+
+	createdBefore := time.Now().AddDate(0, 0, -retentionDays).Format("2006-01-02 15:04:05")
+	_, err := db.Exec("DELETE FROM webhook_deliveries WHERE created_at < ?;", createdBefore)
+
+	return err
+}
+
+// CleanUpDeliveries marks as failed the deliveries left pending by the last time the API stopped, as they're not retried,
+// and deletes the old deliveries. It should be called when the API starts, before any event is sent.
+func CleanUpDeliveries(configs *util.Configs) error {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(
+		"UPDATE webhook_deliveries SET state = ?, last_error = ?, updated_at = ? WHERE state = ?;",
+		StateFailed, "the API stopped before the delivery was done", time.Now().Format("2006-01-02 15:04:05"), StatePending,
+	)
+	if err != nil {
+		return err
+	}
+
+	return deleteOldDeliveries(db, configs.Webhooks.DeliveriesRetentionDays)
+}
+
+func updateDelivery(configs *util.Configs, id, state string, attempts, statusCode int, lastError string) error {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	lastStatusCode := sql.NullInt64{Int64: int64(statusCode), Valid: statusCode != 0}
+	_, err = db.Exec(
+		"UPDATE webhook_deliveries SET state = ?, attempts = ?, last_status_code = ?, last_error = ?, updated_at = ? WHERE id = ?;",
+		state, attempts, lastStatusCode, lastError, time.Now().Format("2006-01-02 15:04:05"), id,
+	)
+
+	return err
+}
+
+// GetDeliveries returns the last deliveries, the newest first
+func GetDeliveries(configs *util.Configs, limit int) ([]*Delivery, error) {
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(
+		"SELECT id, url, event, payload, state, attempts, last_status_code, last_error, created_at, updated_at FROM webhook_deliveries ORDER BY created_at DESC, rowid DESC LIMIT ?;",
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*Delivery{}
+	for rows.Next() {
+		var delivery Delivery
+		var lastStatusCode sql.NullInt64
+		err = rows.Scan(
+			&delivery.ID, &delivery.URL, &delivery.Event, &delivery.Payload, &delivery.State, &delivery.Attempts,
+			&lastStatusCode, &delivery.LastError, &delivery.CreatedAt, &delivery.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		delivery.LastStatusCode = int(lastStatusCode.Int64)
+		list = append(list, &delivery)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diogovalentte/dashboard/api/util"
+)
+
+// testReceiver is a local webhook that fails the first requests
+type testReceiver struct {
+	failures int
+	mutex    sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (receiver *testReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	receiver.mutex.Lock()
+	defer receiver.mutex.Unlock()
+	receiver.requests = append(receiver.requests, r)
+	receiver.bodies = append(receiver.bodies, body)
+	if len(receiver.requests) <= receiver.failures {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func TestSend(t *testing.T) {
+	retried := &testReceiver{failures: 1}
+	retriedServer := httptest.NewServer(retried)
+	defer retriedServer.Close()
+	failed := &testReceiver{failures: 10}
+	failedServer := httptest.NewServer(failed)
+	defer failedServer.Close()
+	filtered := &testReceiver{}
+	filteredServer := httptest.NewServer(filtered)
+	defer filteredServer.Close()
+
+	configs := &util.Configs{
+		Database: util.DatabaseConfigs{FolderPath: t.TempDir()},
+		Webhooks: util.WebhooksConfigs{
+			MaxAttempts:      3,
+			InitialBackoffMS: 10,
+			TimeoutSeconds:   5,
+			Endpoints: []util.WebhookEndpointConfigs{
+				{URL: retriedServer.URL, Secret: "secret", Events: []string{EventJobFailed}},
+				{URL: failedServer.URL},
+				{URL: filteredServer.URL, Events: []string{EventJobCompleted}},
+			},
+		},
+	}
+	send(configs, EventJobFailed, map[string]string{"task": "Add game to Games Tracker database"})
+	Wait()
+
+	if len(retried.requests) != 2 {
+		t.Fatalf("expected the first delivery to be retried once, got %d requests", len(retried.requests))
+	}
+	request, body := retried.requests[1], retried.bodies[1]
+	if signature := request.Header.Get(SignatureHeader); signature != Sign("secret", body) {
+		t.Errorf("expected the signature %s, got %s", Sign("secret", body), signature)
+	}
+	if request.Header.Get(EventHeader) != EventJobFailed {
+		t.Errorf("unexpected event header: %s", request.Header.Get(EventHeader))
+	}
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != EventJobFailed || payload.DeliveryID != request.Header.Get(DeliveryHeader) {
+		t.Errorf("unexpected payload: %s", body)
+	}
+	if len(failed.requests) != 3 {
+		t.Errorf("expected the failed delivery to be attempted 3 times, got %d requests", len(failed.requests))
+	}
+	if failed.requests[0].Header.Get(SignatureHeader) != "" {
+		t.Error("expected no signature without a secret")
+	}
+	if len(filtered.requests) != 0 {
+		t.Errorf("expected no requests to the webhook without the event, got %d", len(filtered.requests))
+	}
+
+	deliveries, err := GetDeliveries(configs, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("expected 2 logged deliveries, got %d", len(deliveries))
+	}
+	expected := map[string]Delivery{
+		retriedServer.URL: {State: StateDelivered, Attempts: 2, LastStatusCode: http.StatusOK},
+		failedServer.URL:  {State: StateFailed, Attempts: 3, LastStatusCode: http.StatusInternalServerError, LastError: "the webhook responded with the status code 500"},
+	}
+	for _, delivery := range deliveries {
+		e := expected[delivery.URL]
+		if delivery.State != e.State || delivery.Attempts != e.Attempts || delivery.LastStatusCode != e.LastStatusCode || delivery.LastError != e.LastError {
+			t.Errorf("%s: expected delivery %+v, got %+v", delivery.URL, e, delivery)
+		}
+	}
+}
+
+func TestCleanUpDeliveries(t *testing.T) {
+	configs := &util.Configs{
+		Database: util.DatabaseConfigs{FolderPath: t.TempDir()},
+		Webhooks: util.WebhooksConfigs{DeliveriesRetentionDays: 30},
+	}
+	now := time.Now()
+	for _, delivery := range []struct {
+		id        string
+		createdAt time.Time
+	}{{"old", now.AddDate(0, 0, -31)}, {"stranded", now.Add(-time.Hour)}} {
+		payload := Payload{DeliveryID: delivery.id, Event: EventJobFailed, CreatedAt: delivery.createdAt.Format("2006-01-02 15:04:05")}
+		if err := insertDelivery(configs, "http://localhost:8081/hook", payload, []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The old delivery was deleted when the stranded delivery was logged
+	deliveries, err := GetDeliveries(configs, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].ID != "stranded" {
+		t.Fatalf("expected only the stranded delivery, got %+v", deliveries)
+	}
+
+	if err = CleanUpDeliveries(configs); err != nil {
+		t.Fatal(err)
+	}
+	deliveries, err = GetDeliveries(configs, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].State != StateFailed || deliveries[0].LastError == "" {
+		t.Errorf("expected the stranded delivery to be failed, got %+v", deliveries)
+	}
+}
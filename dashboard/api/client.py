@@ -1,4 +1,5 @@
 import logging
+import os
 import time
 from urllib.parse import urljoin
 
@@ -43,7 +44,7 @@ class SystemAPIClient:
         path = "/v1/system/get_geckodrivers"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code != 200:
             raise APIException(
                 "error while getting the geckodriver instances addresses from the API",
@@ -55,6 +56,23 @@ class SystemAPIClient:
 
         return res.json().get("addresses", [])
 
+    def get_readiness(self) -> dict:
+        """Returns the API readiness checks, with the status of the database and each geckodriver instance"""
+        path = "/v1/health/ready"
+        url = urljoin(self.base_url, path)
+
+        res = self.session.get(url)
+        if res.status_code not in (200, 503):
+            raise APIException(
+                "error while getting the readiness checks from the API",
+                url,
+                "GET",
+                res.status_code,
+                res.text,
+            )
+
+        return res.json()
+
 class JobsAPIClient:
     def __init__(self) -> None:
         self.base_url: str = ""
@@ -63,7 +81,7 @@ class JobsAPIClient:
         path = "/v1/jobs/get_all"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code != 200:
             raise APIException(
                 "error while getting all jobs from the API",
@@ -79,7 +97,7 @@ class JobsAPIClient:
         path = "/v1/jobs/delete_all"
         url = urljoin(self.base_url, path)
 
-        res = requests.delete(url)
+        res = self.session.delete(url)
         if res.status_code != 200:
             raise APIException(
                 "error while deleting all jobs of the API",
@@ -151,7 +169,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/add_media"
         url = urljoin(self.base_url, path)
 
-        res = requests.post(url, json=media_properties)
+        res = self.session.post(url, json=media_properties)
 
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
@@ -166,7 +184,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/add_media_manually"
         url = urljoin(self.base_url, path)
 
-        res = requests.post(url, json=media_properties)
+        res = self.session.post(url, json=media_properties)
 
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
@@ -181,7 +199,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/update_media"
         url = urljoin(self.base_url, path)
 
-        res = requests.post(url, json=game_properties)
+        res = self.session.post(url, json=game_properties)
 
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
@@ -198,7 +216,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/get_media"
         url = urljoin(self.base_url, path)
 
-        res = requests.post(url, json={"name": name})
+        res = self.session.post(url, json={"name": name})
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 f"error while getting the media '{name}' from the medias tracker database",
@@ -216,7 +234,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/delete_media"
         url = urljoin(self.base_url, path)
 
-        res = requests.post(url, json={"name": name})
+        res = self.session.post(url, json={"name": name})
 
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
@@ -231,7 +249,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/add_game"
         url = urljoin(self.base_url, path)
 
-        res = requests.post(url, json=game_properties)
+        res = self.session.post(url, json=game_properties)
 
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
@@ -246,7 +264,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/add_game_manually"
         url = urljoin(self.base_url, path)
 
-        res = requests.post(url, json=game_properties)
+        res = self.session.post(url, json=game_properties)
 
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
@@ -261,7 +279,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/update_game"
         url = urljoin(self.base_url, path)
 
-        res = requests.post(url, json=game_properties)
+        res = self.session.post(url, json=game_properties)
 
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
@@ -278,7 +296,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/get_game"
         url = urljoin(self.base_url, path)
 
-        res = requests.post(url, json={"name": name})
+        res = self.session.post(url, json={"name": name})
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 f"error while getting the game '{name}' from the games tracker database",
@@ -296,7 +314,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/delete_game"
         url = urljoin(self.base_url, path)
 
-        res = requests.post(url, json={"name": name})
+        res = self.session.post(url, json={"name": name})
 
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
@@ -313,7 +331,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/get_all_games"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting all games from the games tracker database",
@@ -336,7 +354,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/get_playing_games"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting playing games from the games tracker database",
@@ -359,7 +377,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/get_to_be_released_games"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting to be released games from the games tracker database",
@@ -382,7 +400,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/get_not_started_games"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting not started games from the games tracker database",
@@ -405,7 +423,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/get_finished_games"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting finished games from the games tracker database",
@@ -428,7 +446,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/games_tracker/get_dropped_games"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting dropped games from the games tracker database",
@@ -452,7 +470,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/get_all_medias"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting all medias from the medias tracker database",
@@ -476,7 +494,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/get_watching_reading_medias"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting watching/reading medias from the medias tracker database",
@@ -500,7 +518,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/get_to_be_released_medias"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting to be released medias from the medias tracker database",
@@ -524,7 +542,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/get_not_started_medias"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting not started medias from the medias tracker database",
@@ -548,7 +566,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/get_finished_medias"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting finished medias from the medias tracker database",
@@ -572,7 +590,7 @@ class TrackersAPIClient:
         path = "/v1/trackers/medias_tracker/get_dropped_medias"
         url = urljoin(self.base_url, path)
 
-        res = requests.get(url)
+        res = self.session.get(url)
         if res.status_code not in self.acceptable_status_codes:
             raise APIException(
                 "error while getting dropped medias from the medias tracker database",
@@ -594,3 +612,9 @@ class APIClient(JobsAPIClient, TrackersAPIClient, SystemAPIClient):
     def __init__(self, base_URL: str, port: int) -> None:
         self.base_url = f"{base_URL}:{port}"
         self.acceptable_status_codes = (200, 400)
+
+        # The API key is sent on all requests, create one with "go run main.go create_api_key"
+        self.session = requests.Session()
+        api_key = os.environ.get("DASHBOARD_API_KEY", "")
+        if api_key:
+            self.session.headers["Authorization"] = f"Bearer {api_key}"
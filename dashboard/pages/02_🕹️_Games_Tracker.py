@@ -21,13 +21,17 @@ st.set_page_config(
 class GamesTrackerPage:
     def __init__(self) -> None:
         self.api_client = get_api_client()
-        self._game_priority_options = {1: "🤩 High", 2: "😆 Medium", 3: "🙂 Low"}
+        self._game_priority_options = {
+            "high": "🤩 High",
+            "medium": "😆 Medium",
+            "low": "🙂 Low",
+        }
         self._game_status_options = {
-            1: "📅 To be released",
-            2: "🗂️ Not started",
-            3: "🎮 Playing",
-            4: "✅ Finished",
-            5: "❌ Dropped",
+            "to_be_released": "📅 To be released",
+            "not_started": "🗂️ Not started",
+            "in_progress": "🎮 Playing",
+            "finished": "✅ Finished",
+            "dropped": "❌ Dropped",
         }
         star = "⭐"
         self._game_stars_options = {
@@ -391,7 +395,7 @@ class GamesTrackerPage:
                 "%B %d, %Y"
             )
 
-    def _get_priority(self, priority: int | str):
+    def _get_priority(self, priority: str):
         correct_priority = self._game_priority_options.get(priority, None)
         if correct_priority is None:
             game_priority_options = {
@@ -411,7 +415,7 @@ class GamesTrackerPage:
 
         return correct_stars
 
-    def _get_status(self, status: int | str):
+    def _get_status(self, status: str):
         correct_status = self._game_status_options.get(status, None)
         if correct_status is None:
             game_status_options = {
@@ -21,19 +21,23 @@ st.set_page_config(
 class MediasTrackerPage:
     def __init__(self) -> None:
         self.api_client = get_api_client()
-        self._media_priority_options = {1: "🤩 High", 2: "😆 Medium", 3: "🙂 Low"}
+        self._media_priority_options = {
+            "high": "🤩 High",
+            "medium": "😆 Medium",
+            "low": "🙂 Low",
+        }
         self._media_status_options = {
-            1: "📅 To be released",
-            2: "🗂️ Not started",
-            3: "🍿 Watching/Reading",
-            4: "✅ Finished",
-            5: "❌ Dropped",
+            "to_be_released": "📅 To be released",
+            "not_started": "🗂️ Not started",
+            "in_progress": "🍿 Watching/Reading",
+            "finished": "✅ Finished",
+            "dropped": "❌ Dropped",
         }
         self._media_type_options = {
-            1: "📺 Series",
-            2: "🍿 Movie",
-            3: "📖 Book",
-            4: "🗯️ Comic book",
+            "series": "📺 Series",
+            "movie": "🍿 Movie",
+            "book": "📖 Book",
+            "comic_book": "🗯️ Comic book",
         }
         star = "⭐"
         self._media_stars_options = {
@@ -380,7 +384,7 @@ class MediasTrackerPage:
                 "%B %d, %Y"
             )
 
-    def _get_priority(self, priority: int | str):
+    def _get_priority(self, priority: str):
         correct_priority = self._media_priority_options.get(priority, None)
         if correct_priority is None:
             media_priority_options = {
@@ -400,7 +404,7 @@ class MediasTrackerPage:
 
         return correct_stars
 
-    def _get_status(self, status: int | str):
+    def _get_status(self, status: str):
         correct_status = self._media_status_options.get(status, None)
         if correct_status is None:
             media_status_options = {
@@ -410,7 +414,7 @@ class MediasTrackerPage:
 
         return correct_status
 
-    def _get_media_type(self, media_type: int | str):
+    def _get_media_type(self, media_type: str):
         correct_media_type = self._media_type_options.get(media_type, None)
         if correct_media_type is None:
             media_type_options = {
@@ -16,14 +16,12 @@ class MaintenancePage:
 
     def sidebar(self):
         self.create_service_status_widget("Dashboard", "http://localhost:8501/healthz")
-        self.create_service_status_widget(
-            "Backend API", "http://localhost:8080/v1/health"
-        )
-        geckodriver_instances_addresses = self.api_client.get_geckodriver_instances_addresses()
-        count = 1
-        for addr in geckodriver_instances_addresses:
-            self.create_service_status_widget(f"Geckodriver {count}", addr + "/status")
-            count += 1
+        readiness = self.api_client.get_readiness()
+        self.show_service_status_widget("Backend API", readiness["status"] == "ok")
+        for check in readiness["checks"]:
+            self.show_service_status_widget(
+                check["name"], check["status"] == "ok", check.get("message", "")
+            )
 
         st.sidebar.divider()
 
@@ -56,22 +54,20 @@ class MaintenancePage:
         self, service_name: str, url: str, expected_status_code: int = 200
     ):
         res = requests.get(url)
-        status_code = res.status_code
+        self.show_service_status_widget(
+            service_name, res.status_code == expected_status_code
+        )
 
-        if status_code == expected_status_code:
-            st.sidebar.metric(
-                label="Health status",
-                value=service_name,
-                delta="Healthy",
-                label_visibility="collapsed",
-            )
-        else:
-            st.sidebar.metric(
-                label="Health status",
-                value=service_name,
-                delta="-Unhealthy",
-                label_visibility="collapsed",
-            )
+    def show_service_status_widget(
+        self, service_name: str, healthy: bool, help: str = ""
+    ):
+        st.sidebar.metric(
+            label="Health status",
+            value=service_name,
+            delta="Healthy" if healthy else "-Unhealthy",
+            label_visibility="collapsed",
+            help=help or None,
+        )
 
 
 page = MaintenancePage()
@@ -1,26 +1,136 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
 	"github.com/diogovalentte/dashboard/api"
+	"github.com/diogovalentte/dashboard/api/auth"
+	"github.com/diogovalentte/dashboard/api/database"
+	"github.com/diogovalentte/dashboard/api/logging"
+	"github.com/diogovalentte/dashboard/api/routes/trackers"
 	"github.com/diogovalentte/dashboard/api/scraping"
 	"github.com/diogovalentte/dashboard/api/util"
+	"github.com/diogovalentte/dashboard/api/webhook"
 )
 
-func init() {
+func main() {
+	configFile := flag.String("config", "", fmt.Sprintf("path of the configs file, overrides the %s environment variable", util.ConfigFileEnv))
+	flag.Parse()
+	if *configFile != "" {
+		util.SetConfigFile(*configFile)
+	}
+
 	configs, err := util.GetConfigs()
 	if err != nil {
-		panic(err)
+		logging.Fatal("couldn't load the configs", err)
+	}
+	if err = configs.Validate(); err != nil {
+		logging.Fatal("couldn't start the API", err)
+	}
+	if err = logging.Setup(os.Stderr, configs.Log.Level); err != nil {
+		logging.Fatal("couldn't set up the logs", err)
+	}
+
+	// Apply the pending database migrations
+	db, err := database.OpenTrackersDB(configs)
+	if err != nil {
+		logging.Fatal("couldn't open the database", err)
+	}
+	db.Close()
+
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "create_api_key":
+			createAPIKey(configs, args[1:])
+			return
+		case "create_user":
+			createUser(configs, args[1:])
+			return
+		default:
+			logging.Fatal("couldn't run the command", fmt.Errorf("unknown command %s, should be create_api_key or create_user", args[0]))
+		}
+	}
+
+	if !configs.Auth.Enabled {
+		slog.Warn("the API auth is disabled, anyone that can reach the API can use it")
 	}
 
 	// Start the GeckoDriver pool
-	_, err = scraping.NewGeckoDriverPool(configs.GeckoDriver.BinaryPath, configs.GeckoDriver.PoolSize)
+	pool, err := scraping.NewGeckoDriverPool(configs.GeckoDriver.BinaryPath, configs.GeckoDriver.PoolSize)
 	if err != nil {
-		panic(err)
+		logging.Fatal("couldn't start the GeckoDriver pool", err)
 	}
-}
 
-func main() {
+	// Apply the configs changes without restarting
+	util.OnConfigsReload(func(oldConfigs, newConfigs *util.Configs) {
+		if newConfigs.GeckoDriver.PoolSize != oldConfigs.GeckoDriver.PoolSize {
+			if err := pool.Resize(newConfigs.GeckoDriver.PoolSize); err != nil {
+				slog.Error("couldn't resize the GeckoDriver pool", "pool_size", newConfigs.GeckoDriver.PoolSize, "error", err)
+			}
+		}
+		if newConfigs.Log.Level != oldConfigs.Log.Level {
+			logging.SetLevel(newConfigs.Log.Level)
+		}
+	})
+	if err = util.WatchConfigs(); err != nil {
+		slog.Error("couldn't watch the configs for changes", "error", err)
+	}
+
+	// The webhook deliveries pending when the API stopped are not retried
+	if err = webhook.CleanUpDeliveries(configs); err != nil {
+		slog.Error("couldn't clean up the webhook deliveries", "error", err)
+	}
+
+	// Send the release reminders of the to be released entries daily
+	trackers.StartReleaseReminders()
+
 	router := api.SetupRouter()
 
 	router.Run()
 }
+
+// createAPIKey creates an API key and prints it, it's used to create the first key
+func createAPIKey(configs *util.Configs, args []string) {
+	flags := flag.NewFlagSet("create_api_key", flag.ExitOnError)
+	name := flags.String("name", "", "name of the API key")
+	scope := flags.String("scope", string(auth.ScopeWrite), `scope of the API key, "read" or "write"`)
+	userName := flags.String("user", "default", "name of the user that owns the API key")
+	flags.Parse(args)
+
+	user, err := auth.GetUserByName(configs, *userName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if user == nil {
+		fmt.Fprintf(os.Stderr, "user %s does not exist\n", *userName)
+		os.Exit(1)
+	}
+
+	key, err := auth.CreateAPIKey(configs, user.ID, *name, auth.Scope(*scope))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Println(key)
+}
+
+// createUser creates a user, API keys can then be created for it with create_api_key
+func createUser(configs *util.Configs, args []string) {
+	flags := flag.NewFlagSet("create_user", flag.ExitOnError)
+	name := flags.String("name", "", "name of the user")
+	role := flags.String("role", string(auth.RoleUser), `role of the user, "admin" or "user"`)
+	flags.Parse(args)
+
+	user, err := auth.CreateUser(configs, *name, auth.Role(*role))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("user %s created with ID %d\n", user.Name, user.ID)
+}